@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"satnet-simulator/internal/verification"
+)
+
+const auditUsage = "usage: satnet audit verify [-key KEY] <log.jsonl>..."
+
+// runAudit handles `satnet audit verify [-key KEY] <log.jsonl>...`. It
+// replays each log independently of the run that wrote it and exits non-zero
+// if any log fails. Signatures are checked against the prover's key from
+// -key, never the one the log carries, so a signed log fails without it.
+func runAudit(args []string) int {
+	if len(args) < 1 || args[0] != "verify" {
+		fmt.Fprintln(os.Stderr, auditUsage)
+		return 2
+	}
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	keyArg := fs.String("key", "", "the prover's ed25519 public key, in hex or as a file holding it")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, auditUsage)
+		return 2
+	}
+	var key ed25519.PublicKey
+	if *keyArg != "" {
+		var err error
+		if key, err = readProverKey(*keyArg); err != nil {
+			fmt.Fprintf(os.Stderr, "key: %v\n", err)
+			return 2
+		}
+	}
+
+	status := 0
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		rep, err := verification.ReplayAuditLog(f, key)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+
+		fmt.Printf("%s\n", path)
+		fmt.Printf("  entries=%d  chain_intact=%t  signatures_checked=%d  bad_signatures=%d\n",
+			rep.Entries, rep.ChainIntact, rep.SignaturesChecked, len(rep.BadSignatures))
+		if rep.LoggedVerdict != nil {
+			fmt.Printf("  logged:   %s (queries=%d, H0=%.4f H1=%.4f H2=%.4f)\n",
+				rep.LoggedVerdict.Verdict, rep.LoggedVerdict.TotalQueries,
+				rep.LoggedVerdict.PosteriorH0, rep.LoggedVerdict.PosteriorH1, rep.LoggedVerdict.PosteriorH2)
+		}
+		fmt.Printf("  replayed: %s (queries=%d, H0=%.4f H1=%.4f H2=%.4f)\n",
+			rep.Replayed.Verdict, rep.Replayed.TotalQueries,
+			rep.Replayed.PosteriorH0, rep.Replayed.PosteriorH1, rep.Replayed.PosteriorH2)
+		for _, c := range rep.Contradictions {
+			q := c.Query
+			fmt.Printf("  signed contradiction: entry %d, batch %d, pkt %d claimed minimal at delay %.6fs (batch min %.6fs), answered at t=%.3f\n",
+				c.Seq, q.BatchID, q.PacketID, q.ObservedDelay, q.BatchMinDelay, q.Timestamp)
+		}
+		for _, p := range rep.Problems {
+			fmt.Printf("  PROBLEM: %s\n", p)
+		}
+		if rep.OK() {
+			fmt.Println("  OK")
+		} else {
+			fmt.Println("  FAILED")
+			status = 1
+		}
+	}
+	return status
+}
+
+// readProverKey takes a key in hex, or the name of a file holding one such
+// as the trial_NNNN.key files written next to audit logs.
+func readProverKey(arg string) (ed25519.PublicKey, error) {
+	text := arg
+	if data, err := os.ReadFile(arg); err == nil {
+		text = string(data)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither a file nor a hex key", arg)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("key is %d bytes, want %d", len(key), ed25519.PublicKeySize)
+	}
+	return key, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"satnet-simulator/internal/plot"
+)
+
+// plotResults handles `satnet plot`: SVG figures for each results file, and
+// with -overlay one figure of the same sweep across several seeds' files.
+func plotResults(args []string) int {
+	fs := flag.NewFlagSet("plot", flag.ContinueOnError)
+	outDir := fs.String("out", "", "directory for the figures; default beside each results file")
+	overlay := fs.String("overlay", "", "also overlay every file's rates as one seed each, with their mean, in this SVG file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	status := 0
+	var all []plot.Results
+	for _, path := range fs.Args() {
+		res, err := plot.ReadResults(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		all = append(all, res)
+		figs, err := res.Figures()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		dir := filepath.Dir(path)
+		if *outDir != "" {
+			dir = *outDir
+		}
+		for _, fig := range figs {
+			out := filepath.Join(dir, res.Name+"."+fig.Name+".svg")
+			if err := writeFigure(out, fig.SVG); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", out, err)
+				status = 1
+				continue
+			}
+			fmt.Printf("wrote %s\n", out)
+		}
+	}
+
+	if *overlay != "" && status == 0 {
+		title := strings.TrimSuffix(filepath.Base(*overlay), filepath.Ext(*overlay))
+		p, err := plot.Overlay(title, all)
+		if err == nil {
+			err = writeFigure(*overlay, p)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "overlay: %v\n", err)
+			return 1
+		}
+		fmt.Printf("wrote %s\n", *overlay)
+	}
+	return status
+}
+
+func writeFigure(path string, fig interface{ WriteSVG(w io.Writer) error }) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fig.WriteSVG(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"satnet-simulator/internal/experiment"
+)
+
+// runScenarios handles `satnet run`. Every scenario is loaded and validated
+// before the first one starts, so a typo in the last file does not surface
+// hours into the run.
+func runScenarios(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	seed := fs.Int64("seed", 0, "base RNG seed; if omitted, current time is used")
+	auditDir := fs.String("audit-dir", "", "write a signed, hash-chained audit log for every trial under this directory")
+	timelinePath := fs.String("timeline", "", "JSON timeline of parameter changes applied during every trial, replacing the scenario's")
+	only := fs.String("only", "", "comma-separated run ids to run; default all")
+	format := fs.String("format", "", "json, csv or jsonl for every output, replacing its extension; default by extension")
+	cacheDir := fs.String("cache", "results/cache", "keep finished runs here and skip them when rerun with the same -seed; empty disables")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+
+	if err := experiment.CheckFormat(*format); err != nil {
+		fmt.Fprintf(os.Stderr, "format: %v\n", err)
+		return 2
+	}
+	scenarios, ok := loadScenarios(fs.Args())
+	if !ok {
+		return 1
+	}
+	var timeline experiment.Timeline
+	if *timelinePath != "" {
+		tl, err := experiment.LoadTimeline(*timelinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "timeline: %v\n", err)
+			return 1
+		}
+		timeline = tl
+	}
+	var ids []string
+	if *only != "" {
+		ids = strings.Split(*only, ",")
+	}
+
+	baseSeed := *seed
+	if baseSeed == 0 {
+		baseSeed = time.Now().UnixNano()
+	}
+	fmt.Println("================================================================================")
+	fmt.Println("     SATNET SIMULATOR")
+	fmt.Println("================================================================================")
+	fmt.Printf("     RNG base seed: %d\n", baseSeed)
+	fmt.Println("     (trial streams are derived per config and trial index)")
+	if *seed == 0 && *cacheDir != "" {
+		fmt.Printf("     (pass -seed %d to resume this run from the cache)\n", baseSeed)
+	}
+
+	runner := experiment.NewRunner()
+	runner.SetBaseSeed(baseSeed)
+	runner.AuditDir = *auditDir
+	runner.Format = *format
+	if *cacheDir != "" {
+		store, err := experiment.OpenResultStore(*cacheDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cache: %v\n", err)
+			return 1
+		}
+		runner.Store = store
+	}
+
+	status := 0
+	for _, sc := range scenarios {
+		if err := runner.RunScenario(sc, baseSeed, ids, timeline); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", sc.Name, err)
+			status = 1
+		}
+	}
+	if len(runner.Results) > 0 {
+		runner.PrintSummary()
+	}
+	return status
+}
+
+// validateScenarios handles `satnet validate`: everything run would check
+// before starting, including that timeline files load.
+func validateScenarios(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	scenarios, ok := loadScenarios(args)
+	for _, sc := range scenarios {
+		fmt.Printf("%s: OK (%s, %d runs)\n", sc.Name, sc.Kind, len(sc.Runs))
+	}
+	if !ok {
+		return 1
+	}
+	return 0
+}
+
+func loadScenarios(names []string) ([]experiment.ScenarioFile, bool) {
+	var out []experiment.ScenarioFile
+	ok := true
+	for _, name := range names {
+		sc, err := experiment.LoadScenario(name)
+		if err == nil {
+			err = sc.CheckFiles()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			ok = false
+			continue
+		}
+		out = append(out, sc)
+	}
+	return out, ok
+}
+
+// listScenarios handles `satnet list`: the bundled scenarios, then what a
+// scenario file of each kind can ask for.
+func listScenarios(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	scenarios, err := experiment.BundledScenarios()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println("Bundled scenarios:")
+	for _, sc := range scenarios {
+		fmt.Printf("  %-26s %s\n", sc.Name, sc.Description)
+		ids := make([]string, len(sc.Runs))
+		for i, run := range sc.Runs {
+			ids[i] = run.ID
+		}
+		fmt.Printf("  %-26s runs: %s\n", "", strings.Join(ids, ", "))
+	}
+	fmt.Printf("\nDerivations for the grid's derived fields: %s\n", strings.Join(experiment.Derivations(), ", "))
+	for _, k := range experiment.ScenarioKinds() {
+		fmt.Printf("\nKind %q\n  sweeps:\n", k.Name)
+		for _, s := range k.Sweeps {
+			fmt.Printf("    %s\n", s)
+		}
+		if len(k.Presets) > 0 {
+			fmt.Println("  presets:")
+			for _, p := range k.Presets {
+				fmt.Printf("    %s\n", p)
+			}
+		}
+	}
+	return 0
+}
@@ -27,8 +27,9 @@ func (h *EventHeap) Pop() any {
 }
 
 type Simulation struct {
-	Now    float64
-	events EventHeap
+	Now     float64
+	events  EventHeap
+	stopped bool
 }
 
 func NewSimulation() *Simulation {
@@ -50,7 +51,8 @@ func (s *Simulation) Schedule(delay float64, action func()) {
 }
 
 func (s *Simulation) Run(until float64) {
-	for s.events.Len() > 0 {
+	s.stopped = false
+	for s.events.Len() > 0 && !s.stopped {
 		if s.events[0].time > until {
 			break
 		}
@@ -60,6 +62,11 @@ func (s *Simulation) Run(until float64) {
 	}
 }
 
+// Stop makes Run return after the current event. Pending events are kept.
+func (s *Simulation) Stop() {
+	s.stopped = true
+}
+
 func (s *Simulation) Clear() {
 	s.events = make(EventHeap, 0)
 }
@@ -67,4 +74,5 @@ func (s *Simulation) Clear() {
 func (s *Simulation) Reset() {
 	s.Now = 0.0
 	s.events = make(EventHeap, 0)
+	s.stopped = false
 }
@@ -0,0 +1,106 @@
+package experiment
+
+import "fmt"
+
+// defaultAdaptiveBlock is how many trials an adaptive run adds between
+// looks at its intervals when Adaptive.Block is unset.
+const defaultAdaptiveBlock = 10
+
+// Why a run stopped adding trials.
+const (
+	StopFixed         = "FIXED"          // not adaptive: ran NumTrials
+	StopTargetReached = "TARGET_REACHED" // every tracked interval was narrow enough
+	StopMaxTrials     = "MAX_TRIALS"     // reached NumTrials first
+)
+
+// Adaptive runs trials in blocks until the Wilson interval of every rate a
+// run tracks has a half-width of at most HalfWidth, with the config's
+// NumTrials as the cap. Rates near 0 or 1 settle in a few dozen trials;
+// rates near a phase boundary use the whole cap. Trial i keeps its seed
+// either way, so an adaptive run is a prefix of the fixed run it caps.
+type Adaptive struct {
+	HalfWidth float64 // zero runs NumTrials, as without it
+	Block     int     // trials between looks; zero is defaultAdaptiveBlock
+}
+
+func (a Adaptive) enabled() bool { return a.HalfWidth > 0 }
+
+// Stopping records how many trials a run took and why it stopped there.
+type Stopping struct {
+	TrialsRun    int
+	StopReason   string
+	MaxHalfWidth float64 // of the widest tracked interval when it stopped
+}
+
+// halfWidth is half the width of ci.
+func halfWidth(ci RateCI) float64 {
+	return (ci.Upper - ci.Lower) / 2
+}
+
+// widest is the largest half-width among cis.
+func widest(cis ...RateCI) float64 {
+	var w float64
+	for _, ci := range cis {
+		w = max(w, halfWidth(ci))
+	}
+	return w
+}
+
+// runAdaptive runs trial for i = 0, 1, ... up to maxTrials, stopping early
+// once ad is enabled and width, the widest tracked half-width over the
+// trials so far, is within ad.HalfWidth. It looks only between blocks.
+func runAdaptive[T any](r *Runner, name string, ad Adaptive, maxTrials int, trial func(i int) T, width func([]T) float64) ([]T, Stopping) {
+	if !ad.enabled() {
+		trials := make([]T, maxTrials)
+		for i := range maxTrials {
+			trials[i] = trial(i)
+		}
+		return trials, Stopping{TrialsRun: maxTrials, StopReason: StopFixed, MaxHalfWidth: width(trials)}
+	}
+
+	block := ad.Block
+	if block <= 0 {
+		block = defaultAdaptiveBlock
+	}
+	trials := make([]T, 0, maxTrials)
+	s := Stopping{StopReason: StopMaxTrials}
+	for len(trials) < maxTrials {
+		for range min(block, maxTrials-len(trials)) {
+			trials = append(trials, trial(len(trials)))
+		}
+		s.MaxHalfWidth = width(trials)
+		if s.MaxHalfWidth <= ad.HalfWidth {
+			s.StopReason = StopTargetReached
+			break
+		}
+	}
+	s.TrialsRun = len(trials)
+	if r.Verbose {
+		fmt.Printf("    %s: stopped after %d of %d trials: %s (widest CI ±%.3f, target ±%.3f)\n",
+			name, s.TrialsRun, maxTrials, s.StopReason, s.MaxHalfWidth, ad.HalfWidth)
+	}
+	return trials, s
+}
+
+// widestCI is the widest interval among a's verdict-class, accused and
+// correct rates.
+func (a Aggregate) widestCI() float64 {
+	v := a.VerdictRates
+	return widest(v.TrustedRateCI, v.CaughtIncompetentRateCI, v.CaughtMaliciousRateCI,
+		v.SLABreachedRateCI, v.InconclusiveRateCI, a.AccusedRateCI, a.CorrectRateCI)
+}
+
+// widestCI is the widest of a's attribution intervals.
+func (a PathAggregate) widestCI() float64 {
+	return widest(a.CorrectAttributionCI, a.WrongAttributionCI)
+}
+
+// widestCI is the widest interval of a's confusion matrix and accuracy;
+// rows for truths no trial drew are empty and do not count.
+func (a PopulationAggregate) widestCI() float64 {
+	w := halfWidth(a.Confusion.AccuracyCI)
+	for _, row := range a.Confusion.RatesCI {
+		w = max(w, widest(row...))
+	}
+	return w
+}
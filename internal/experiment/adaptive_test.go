@@ -0,0 +1,91 @@
+package experiment
+
+import "testing"
+
+func TestRunAdaptive(t *testing.T) {
+	r := NewRunner()
+	r.Verbose = false
+	// width scores bools as one rate, hits among trials.
+	width := func(ts []bool) float64 {
+		hits := 0
+		for _, hit := range ts {
+			if hit {
+				hits++
+			}
+		}
+		_, ci := rateOf(hits, len(ts))
+		return halfWidth(ci)
+	}
+	always := func(int) bool { return true }
+	alternate := func(i int) bool { return i%2 == 0 }
+
+	for _, tc := range []struct {
+		name   string
+		ad     Adaptive
+		trial  func(int) bool
+		trials int
+		reason string
+	}{
+		{"Disabled", Adaptive{}, always, 200, StopFixed},
+		// A certain outcome's interval is within ±0.05 after 35 trials; the
+		// look after the fourth block is the first to see it.
+		{"CertainOutcome", Adaptive{HalfWidth: 0.05}, always, 40, StopTargetReached},
+		{"CustomBlock", Adaptive{HalfWidth: 0.05, Block: 25}, always, 50, StopTargetReached},
+		// Half and half needs about 380 trials for ±0.05.
+		{"CapReached", Adaptive{HalfWidth: 0.05, Block: 30}, alternate, 200, StopMaxTrials},
+	} {
+		var calls []int
+		trials, s := runAdaptive(r, tc.name, tc.ad, 200, func(i int) bool {
+			calls = append(calls, i)
+			return tc.trial(i)
+		}, width)
+		if len(trials) != tc.trials || s.TrialsRun != tc.trials || s.StopReason != tc.reason {
+			t.Errorf("%s: %d trials (TrialsRun %d), %s; want %d, %s", tc.name, len(trials), s.TrialsRun, s.StopReason, tc.trials, tc.reason)
+		}
+		for i, c := range calls {
+			if c != i {
+				t.Fatalf("%s: call %d ran trial %d", tc.name, i, c)
+			}
+		}
+		if got := width(trials); s.MaxHalfWidth != got {
+			t.Errorf("%s: MaxHalfWidth %v, trials give %v", tc.name, s.MaxHalfWidth, got)
+		}
+		if s.StopReason == StopTargetReached && s.MaxHalfWidth > tc.ad.HalfWidth {
+			t.Errorf("%s: stopped at ±%v, target ±%v", tc.name, s.MaxHalfWidth, tc.ad.HalfWidth)
+		}
+	}
+}
+
+func TestAdaptiveScenario(t *testing.T) {
+	sc := DefaultHonestBaseline().Scenario()
+	sc.Name = "adaptive"
+	sc.NumTrials, sc.NumPackets, sc.SimDuration = 40, 200, 50
+	sc.Adaptive = Adaptive{HalfWidth: 0.2, Block: 10}
+
+	r := NewRunner()
+	r.Verbose = false
+	agg := r.Run(sc)
+	if agg.TrialsRun != len(agg.Trials) || agg.TrialsRun%10 != 0 || agg.TrialsRun > sc.NumTrials {
+		t.Fatalf("%d trials, TrialsRun %d, cap %d", len(agg.Trials), agg.TrialsRun, sc.NumTrials)
+	}
+	switch agg.StopReason {
+	case StopTargetReached:
+		if agg.MaxHalfWidth > sc.Adaptive.HalfWidth {
+			t.Errorf("target reached at ±%v", agg.MaxHalfWidth)
+		}
+	case StopMaxTrials:
+		if agg.TrialsRun != sc.NumTrials {
+			t.Errorf("cap reached after %d trials", agg.TrialsRun)
+		}
+	default:
+		t.Errorf("stop reason %q", agg.StopReason)
+	}
+	if got := agg.widestCI(); got != agg.MaxHalfWidth {
+		t.Errorf("MaxHalfWidth %v, aggregate's widest ±%v", agg.MaxHalfWidth, got)
+	}
+
+	sc.Adaptive = Adaptive{}
+	if fixed := r.Run(sc); fixed.StopReason != StopFixed || fixed.TrialsRun != sc.NumTrials {
+		t.Errorf("fixed run: %s after %d trials", fixed.StopReason, fixed.TrialsRun)
+	}
+}
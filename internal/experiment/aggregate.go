@@ -0,0 +1,295 @@
+package experiment
+
+import (
+	"slices"
+	"strings"
+
+	"satnet-simulator/internal/verification"
+)
+
+// The verdict classes a trial falls into, whatever the truth: the verdict,
+// with accusations split by the posterior's pick and SLA breaches apart.
+const (
+	ClassTrusted           = "TRUSTED"
+	ClassCaughtIncompetent = "CAUGHT_INCOMPETENT"
+	ClassCaughtMalicious   = "CAUGHT_MALICIOUS"
+	ClassSLABreached       = "SLA_BREACHED"
+	ClassInconclusive      = "INCONCLUSIVE"
+)
+
+// classifyVerdict sorts res into a verdict class. A DISHONEST verdict names
+// whichever of H1 and H2 the posterior favours; on a tie it names the truth,
+// as the per-baseline classifiers it replaced each did for their own.
+func classifyVerdict(res verification.VerificationResult, truth Truth) string {
+	if strings.Contains(res.Verdict, "SLA_BREACHED") {
+		return ClassSLABreached
+	}
+	switch res.Verdict {
+	case "TRUSTED":
+		return ClassTrusted
+	case "INCONCLUSIVE", "INSUFFICIENT_DATA":
+		return ClassInconclusive
+	case "DISHONEST":
+		if res.PosteriorH2 > res.PosteriorH1 || (res.PosteriorH2 == res.PosteriorH1 && truth == TruthMalicious) {
+			return ClassCaughtMalicious
+		}
+		return ClassCaughtIncompetent
+	}
+	return "UNKNOWN"
+}
+
+// accuses reports whether a verdict class is an accusation.
+func accuses(class string) bool {
+	switch class {
+	case ClassCaughtIncompetent, ClassCaughtMalicious, ClassSLABreached:
+		return true
+	}
+	return false
+}
+
+// correctClass is the verdict class that names truth.
+func correctClass(truth Truth) string {
+	switch truth {
+	case TruthIncompetent:
+		return ClassCaughtIncompetent
+	case TruthMalicious:
+		return ClassCaughtMalicious
+	}
+	return ClassTrusted
+}
+
+// VerdictRates is the share of trials in each verdict class.
+type VerdictRates struct {
+	TrustedRate             float64
+	CaughtIncompetentRate   float64 // H1 posterior wins
+	CaughtMaliciousRate     float64 // H2 posterior wins
+	SLABreachedRate         float64 // flagging-rate threshold crossed
+	InconclusiveRate        float64
+	TrustedRateCI           RateCI
+	CaughtIncompetentRateCI RateCI
+	CaughtMaliciousRateCI   RateCI
+	SLABreachedRateCI       RateCI
+	InconclusiveRateCI      RateCI
+}
+
+// TrialStats are what every aggregate reports besides its verdict rates.
+type TrialStats struct {
+	// Over trials that reached a verdict, inconclusive ones aside.
+	MeanQueriesToVerdict   float64
+	MedianQueriesToVerdict int
+	P90QueriesToVerdict    int
+	MinQueriesToVerdict    int
+	MaxQueriesToVerdict    int
+
+	// Simulated seconds until the verdict; only populated when Streaming is enabled.
+	MeanTimeToVerdict   float64
+	MedianTimeToVerdict float64
+	P90TimeToVerdict    float64
+
+	MeanPosteriorH0 float64
+	MeanPosteriorH1 float64
+	MeanPosteriorH2 float64
+	// one Beta posterior over the hits and trials of every trial pooled, as
+	// if all were of the same operator (see verification.ModelRates)
+	PooledLieRate         verification.RateEstimate
+	PooledHiddenDelayRate verification.RateEstimate
+
+	MeanContradictions  float64
+	ContradictionRate   float64 // trials with at least one contradiction
+	ContradictionRateCI RateCI
+	MeanProofFailures   float64
+	MeanUnanswered      float64
+	MeanBoundViolations float64
+	MeanProbesDelayed   float64
+
+	// Deliberate-delay throughput: the mean share of packets delayed, and the
+	// same mean counting every trial where the operator was accused as zero.
+	MeanTargetedShare float64
+	EvadedDelayShare  float64
+
+	// Only with Regimes: accusations before the first switch, accusations
+	// after it, and how long after it they came in simulated seconds.
+	FalseAlarmRate          float64
+	DetectedAfterSwitchRate float64
+	MeanDetectionDelay      float64
+	MedianDetectionDelay    float64
+	P90DetectionDelay       float64
+
+	// Among accusations, the fraction in which each kind of evidence turned
+	// up; one accusation can count towards several.
+	DetectionsByEvidence map[string]float64
+
+	MeanExpectedCost float64
+	MeanRealisedCost float64
+
+	Stopping // how many trials ran and why no more; see Adaptive
+}
+
+// Aggregate is the outcome of a scenario's trials, scored against its truth.
+type Aggregate struct {
+	Scenario Scenario
+	Truth    Truth
+	Trials   []TrialResult
+
+	VerdictRates
+	AccusedRate   float64 // any accusation; false ones when the truth is honest
+	CorrectRate   float64 // the verdict class names the truth
+	AccusedRateCI RateCI
+	CorrectRateCI RateCI
+
+	TrialStats
+}
+
+func aggregate(sc Scenario, trials []TrialResult) Aggregate {
+	truth := sc.truth()
+	agg := Aggregate{Scenario: sc, Truth: truth, Trials: trials}
+	n := len(trials)
+	if n == 0 {
+		return agg
+	}
+	var accused, correct int
+	for _, t := range trials {
+		if accuses(t.VerdictClass) {
+			accused++
+		}
+		if t.VerdictClass == correctClass(truth) {
+			correct++
+		}
+	}
+	agg.VerdictRates = verdictRates(trials)
+	agg.AccusedRate, agg.AccusedRateCI = rateOf(accused, n)
+	agg.CorrectRate, agg.CorrectRateCI = rateOf(correct, n)
+	agg.TrialStats = trialStats(trials, sc.Verification, len(sc.Regimes) > 0)
+	return agg
+}
+
+// rateOf is k of n as a rate with its Wilson interval.
+func rateOf(k, n int) (float64, RateCI) {
+	if n == 0 {
+		return 0, RateCI{}
+	}
+	return float64(k) / float64(n), wilsonRateCI(k, n)
+}
+
+func verdictRates(trials []TrialResult) VerdictRates {
+	counts := make(map[string]int)
+	for _, t := range trials {
+		counts[t.VerdictClass]++
+	}
+	n := len(trials)
+	var v VerdictRates
+	v.TrustedRate, v.TrustedRateCI = rateOf(counts[ClassTrusted], n)
+	v.CaughtIncompetentRate, v.CaughtIncompetentRateCI = rateOf(counts[ClassCaughtIncompetent], n)
+	v.CaughtMaliciousRate, v.CaughtMaliciousRateCI = rateOf(counts[ClassCaughtMalicious], n)
+	v.SLABreachedRate, v.SLABreachedRateCI = rateOf(counts[ClassSLABreached], n)
+	v.InconclusiveRate, v.InconclusiveRateCI = rateOf(counts[ClassInconclusive], n)
+	return v
+}
+
+// trialStats computes everything but the verdict rates; the regime-switch
+// statistics only when the trials had regimes. cfg is the verifier the
+// trials ran under, whose rate prior the pooled rates start from.
+func trialStats(trials []TrialResult, cfg verification.VerificationConfig, regimes bool) TrialStats {
+	var s TrialStats
+	n := len(trials)
+	if n == 0 {
+		return s
+	}
+	var withContradictions int
+	var sumH0, sumH1, sumH2 float64
+	var sumExpected, sumRealised float64
+	var sumTargetedShare, sumEvadedShare float64
+	var totalContradictions, totalProofFailures, totalUnanswered, totalBoundViolations, totalProbesDelayed int
+	queriesToVerdict := make([]int, 0, n)
+	timesToVerdict := make([]float64, 0, n)
+	var lies, aboveMin, hiddenDelays, unflagged int
+
+	for _, t := range trials {
+		if t.VerdictClass != ClassInconclusive {
+			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
+			timesToVerdict = append(timesToVerdict, t.DecisionTime)
+		}
+		if !accuses(t.VerdictClass) {
+			sumEvadedShare += t.TargetedShare
+		}
+		sumH0 += t.PosteriorH0
+		sumH1 += t.PosteriorH1
+		sumH2 += t.PosteriorH2
+		lies, aboveMin = lies+t.LieRate.Hits, aboveMin+t.LieRate.Trials
+		hiddenDelays, unflagged = hiddenDelays+t.HiddenDelayRate.Hits, unflagged+t.HiddenDelayRate.Trials
+		sumExpected += t.ExpectedCost
+		sumRealised += t.RealisedCost
+		totalContradictions += t.ContradictionsFound
+		if t.ContradictionsFound > 0 {
+			withContradictions++
+		}
+		totalProofFailures += t.ProofFailures
+		totalUnanswered += t.Unanswered
+		totalBoundViolations += t.BoundViolations
+		totalProbesDelayed += t.ProbesDelayed
+		sumTargetedShare += t.TargetedShare
+	}
+
+	fn := float64(n)
+	s.MeanPosteriorH0 = sumH0 / fn
+	s.MeanPosteriorH1 = sumH1 / fn
+	s.MeanPosteriorH2 = sumH2 / fn
+	s.PooledLieRate = cfg.RateEstimate(lies, aboveMin)
+	s.PooledHiddenDelayRate = cfg.RateEstimate(hiddenDelays, unflagged)
+	s.MeanContradictions = float64(totalContradictions) / fn
+	s.ContradictionRate, s.ContradictionRateCI = rateOf(withContradictions, n)
+	s.MeanProofFailures = float64(totalProofFailures) / fn
+	s.MeanUnanswered = float64(totalUnanswered) / fn
+	s.MeanBoundViolations = float64(totalBoundViolations) / fn
+	s.MeanProbesDelayed = float64(totalProbesDelayed) / fn
+	s.MeanTargetedShare = sumTargetedShare / fn
+	s.EvadedDelayShare = sumEvadedShare / fn
+	s.DetectionsByEvidence = detectionsByEvidence(trials)
+	s.MeanExpectedCost = sumExpected / fn
+	s.MeanRealisedCost = sumRealised / fn
+
+	if len(queriesToVerdict) > 0 {
+		slices.Sort(queriesToVerdict)
+		var sum int
+		for _, q := range queriesToVerdict {
+			sum += q
+		}
+		s.MeanQueriesToVerdict = float64(sum) / float64(len(queriesToVerdict))
+		s.MedianQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)/2]
+		p90 := min((len(queriesToVerdict)*90)/100, len(queriesToVerdict)-1)
+		s.P90QueriesToVerdict = queriesToVerdict[p90]
+		s.MinQueriesToVerdict = queriesToVerdict[0]
+		s.MaxQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)-1]
+		s.MeanTimeToVerdict, s.MedianTimeToVerdict, s.P90TimeToVerdict = timeToVerdictStats(timesToVerdict)
+	}
+	if regimes {
+		s.regimeStats(trials)
+	}
+	return s
+}
+
+// detectionsByEvidence is, among the trials that accused the prover, the
+// fraction in which each kind of evidence turned up.
+func detectionsByEvidence(trials []TrialResult) map[string]float64 {
+	counts := make(map[string]int)
+	detections := 0
+	for _, t := range trials {
+		if !accuses(t.VerdictClass) {
+			continue
+		}
+		detections++
+		for _, k := range t.Evidence {
+			counts[k]++
+		}
+	}
+	out := make(map[string]float64, len(counts))
+	for k, c := range counts {
+		out[k] = float64(c) / float64(detections)
+	}
+	return out
+}
+
+// SaveScenarioAggregates writes results as indented JSON.
+func (r *Runner) SaveScenarioAggregates(path string, results []Aggregate) error {
+	return r.saveResults(path, results)
+}
@@ -0,0 +1,125 @@
+package experiment
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// prepareVerifier wires up verification for one trial and must be called
+// before any traffic is scheduled. It returns the destination the customer's
+// packets should be forwarded to and a func that produces the verdict once
+// sim.Run has returned.
+//
+// With stream.Enabled the destination is a StreamingVerifier that audits
+// during the run; otherwise packets are sunk and the prover's records are
+// audited after the fact, still over stream.Channel and within
+// stream.QueryDeadline, and against roots, the commitments published during
+// the run, when it is set. When the verifier demands signatures the prover
+// gets a key pair, and when Runner.AuditDir is set the run is written to
+// <AuditDir>/<scope>/<config>/trial_NNNN.jsonl, with the prover's public key
+// in hex beside it in trial_NNNN.key: the key an operator would publish, for
+// `satnet audit verify -key`. With cfg.ProbeRate set, the
+// returned prober injects the verifier's probes and the destination records
+// their arrival first.
+func (r *Runner) prepareVerifier(sim *engine.Simulation, prover *verification.Prover, cfg verification.VerificationConfig, stream verification.StreamingConfig, roots *verification.PublishedRoots, batchSize int, scope, cfgName string, trialNum int) (network.Destination, prober, func() verification.VerificationResult) {
+	audit, closeAudit := r.openAuditLog(scope, cfgName, trialNum)
+	pr := newProber(cfg, batchSize)
+
+	if stream.Enabled {
+		enableSigning(prover, cfg)
+		if stream.BatchSize <= 0 {
+			stream.BatchSize = batchSize + pr.perBatch
+		}
+		sv := verification.NewStreamingVerifier(sim, prover, cfg, stream)
+		sv.Audit = audit
+		sv.Probes = pr.log
+		return pr.wrap(sv), pr, func() verification.VerificationResult {
+			res := sv.Result()
+			closeAudit(prover.PublicKey)
+			return res
+		}
+	}
+
+	return pr.wrap(&honestDest{}), pr, func() verification.VerificationResult {
+		// Keys are drawn after the run so batch trials consume the RNG in
+		// the same order as before signing existed.
+		enableSigning(prover, cfg)
+		// every query is asked after the run, so answers are stamped with
+		// its end
+		if prover.Clock == nil {
+			end := sim.Now
+			prover.Clock = func() float64 { return end }
+		}
+		verifier := verification.NewVerifier(prover, cfg)
+		verifier.IngestPackets(prover.Packets)
+		verifier.Audit = audit
+		verifier.Probes = pr.log
+		verifier.Channel, verifier.QueryDeadline = stream.Channel, stream.QueryDeadline
+		verifier.Roots = roots
+		res := verifier.RunVerification()
+		closeAudit(prover.PublicKey)
+		return res
+	}
+}
+
+func enableSigning(prover *verification.Prover, cfg verification.VerificationConfig) {
+	if cfg.RequireSignatures && prover.PublicKey == nil {
+		prover.EnableSigning()
+	}
+}
+
+// openAuditLog returns the trial's log and a func that closes it, writing the
+// prover's key beside it when there is one.
+func (r *Runner) openAuditLog(scope, cfgName string, trialNum int) (*verification.AuditLog, func(ed25519.PublicKey)) {
+	if r.AuditDir == "" {
+		return nil, func(ed25519.PublicKey) {}
+	}
+	path := filepath.Join(r.AuditDir, scope, cfgName, fmt.Sprintf("trial_%04d.jsonl", trialNum))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Printf("warning: could not create audit dir: %v\n", err)
+		return nil, func(ed25519.PublicKey) {}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("warning: could not create audit log: %v\n", err)
+		return nil, func(ed25519.PublicKey) {}
+	}
+	audit := verification.NewAuditLog(f)
+	return audit, func(key ed25519.PublicKey) {
+		if err := audit.Err(); err != nil {
+			fmt.Printf("warning: audit log %s incomplete: %v\n", path, err)
+		}
+		f.Close()
+		if key == nil {
+			return
+		}
+		keyPath := strings.TrimSuffix(path, ".jsonl") + ".key"
+		if err := os.WriteFile(keyPath, []byte(hex.EncodeToString(key)+"\n"), 0o644); err != nil {
+			fmt.Printf("warning: could not write prover key: %v\n", err)
+		}
+	}
+}
+
+// timeToVerdictStats returns the mean, median and 90th percentile of the
+// simulated times at which decisive verdicts were reached.
+func timeToVerdictStats(times []float64) (mean, median, p90 float64) {
+	if len(times) == 0 {
+		return 0, 0, 0
+	}
+	sort.Float64s(times)
+	var sum float64
+	for _, t := range times {
+		sum += t
+	}
+	idx := min((len(times)*90)/100, len(times)-1)
+	return sum / float64(len(times)), times[len(times)/2], times[idx]
+}
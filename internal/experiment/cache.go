@@ -0,0 +1,166 @@
+package experiment
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+)
+
+// ResultStore keeps every finished run on disk, keyed by a hash of what
+// determines it: the kind, the full config, the base seed and the code
+// version. A sweep that dies halfway, or a scenario rerun with one more run
+// enabled, then only runs the points it has not finished.
+type ResultStore struct {
+	Dir     string
+	Version string // see CodeVersion
+}
+
+// OpenResultStore creates dir if needed and stamps the store with the running
+// binary's CodeVersion.
+func OpenResultStore(dir string) (*ResultStore, error) {
+	version := CodeVersion()
+	if version == "" {
+		return nil, errors.New("cannot tell which code is running, so cached results could be stale")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ResultStore{Dir: dir, Version: version}, nil
+}
+
+// CodeVersion is the commit the binary was built from, or, for a build with
+// uncommitted changes or without VCS stamping (go run, go test), a hash of
+// the executable itself. Empty when neither can be had.
+var CodeVersion = sync.OnceValue(func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var revision, modified string
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.modified":
+				modified = s.Value
+			}
+		}
+		if revision != "" && modified == "false" {
+			return revision
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return "exe-" + hex.EncodeToString(h.Sum(nil))
+})
+
+// storeKey is what a stored result was run from. It is hashed as JSON, whose
+// struct fields keep their order and whose map keys are sorted, so equal
+// configs always hash alike.
+type storeKey[C any] struct {
+	Kind    string
+	Seed    int64
+	Version string
+	Config  C
+}
+
+type storeEntry[C, A any] struct {
+	storeKey[C]
+	Result A
+}
+
+func (s *ResultStore) path(kind, hash string) string {
+	return filepath.Join(s.Dir, kind, hash+".json")
+}
+
+func hashKey[C any](key storeKey[C]) (string, error) {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// load reads the result stored under hash, if there is one.
+func load[C, A any](s *ResultStore, kind, hash string) (A, bool) {
+	var e storeEntry[C, A]
+	b, err := os.ReadFile(s.path(kind, hash))
+	if err != nil {
+		return e.Result, false
+	}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return e.Result, false
+	}
+	return e.Result, true
+}
+
+// store writes the entry to a temporary file and renames it into place, so
+// a run killed mid-write leaves no half-written result behind.
+func store[C, A any](s *ResultStore, hash string, e storeEntry[C, A]) error {
+	dir := filepath.Join(s.Dir, e.Kind)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, hash+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), s.path(e.Kind, hash))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// cachedRun returns the stored result of running cfg if r's store has one,
+// and otherwise runs it and stores the result. Runs bypass the store without
+// a fixed base seed, whose trials could not be told apart from a rerun's,
+// and with an AuditDir, whose logs only a real run writes.
+func cachedRun[C, A any](r *Runner, kind, name string, cfg C, run func(C) A) A {
+	if r.Store == nil || !r.deterministicSeeding || r.AuditDir != "" {
+		return run(cfg)
+	}
+	key := storeKey[C]{Kind: kind, Seed: r.baseSeed, Version: r.Store.Version, Config: cfg}
+	hash, err := hashKey(key)
+	if err != nil {
+		fmt.Printf("warning: %s not cached: %v\n", name, err)
+		return run(cfg)
+	}
+	if a, ok := load[C, A](r.Store, kind, hash); ok {
+		if r.Verbose {
+			fmt.Printf(">>> %s: cached (%s)\n", name, hash[:12])
+		}
+		return a
+	}
+	a := run(cfg)
+	if err := store(r.Store, hash, storeEntry[C, A]{key, a}); err != nil {
+		fmt.Printf("warning: %s not cached: %v\n", name, err)
+	}
+	return a
+}
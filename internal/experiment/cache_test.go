@@ -0,0 +1,85 @@
+package experiment
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCachedRun(t *testing.T) {
+	store, err := OpenResultStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRunner()
+	r.Verbose = false
+	r.Store = store
+	r.SetBaseSeed(5)
+
+	runs := 0
+	run := func(cfg HonestBaselineConfig) int { runs++; return cfg.NumTrials * 10 }
+	cfg := DefaultHonestBaseline()
+	for range 2 {
+		if got := cachedRun(r, "honest", cfg.Name, cfg, run); got != cfg.NumTrials*10 {
+			t.Fatalf("result %d", got)
+		}
+	}
+	if runs != 1 {
+		t.Errorf("same config ran %d times", runs)
+	}
+
+	changed := cfg
+	changed.Verification.ErrorTolerance *= 2
+	cachedRun(r, "honest", cfg.Name, changed, run)
+	r.SetBaseSeed(6)
+	cachedRun(r, "honest", cfg.Name, cfg, run)
+	if runs != 3 {
+		t.Errorf("a new config and a new seed gave %d runs, want 3", runs)
+	}
+	if files, _ := filepath.Glob(filepath.Join(store.Dir, "honest", "*.json")); len(files) != 3 {
+		t.Errorf("%d stored results, want 3", len(files))
+	}
+
+	other := *store
+	other.Version = "another build"
+	r.Store = &other
+	cachedRun(r, "honest", cfg.Name, cfg, run)
+	r.Store = store
+	r.AuditDir = t.TempDir()
+	cachedRun(r, "honest", cfg.Name, cfg, run)
+	if runs != 5 {
+		t.Errorf("another code version and an audit dir gave %d runs, want 5", runs)
+	}
+}
+
+// A stored aggregate must come back exactly as it was run.
+func TestStoredAggregate(t *testing.T) {
+	store, err := OpenResultStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRunner()
+	r.Verbose = false
+	r.Store = store
+	r.SetBaseSeed(9)
+
+	p := DefaultPopulation()
+	p.NumTrials, p.NumPackets, p.SimDuration = 6, 200, 50
+	first := r.RunPopulation(p)
+	again := r.RunPopulation(p)
+	if !reflect.DeepEqual(first.Confusion, again.Confusion) || len(again.Trials) != len(first.Trials) {
+		t.Fatalf("stored population differs: %+v, then %+v", first.Confusion, again.Confusion)
+	}
+	for i := range first.Trials {
+		if !reflect.DeepEqual(first.Trials[i], again.Trials[i]) {
+			t.Errorf("trial %d: %+v, then %+v", i, first.Trials[i], again.Trials[i])
+		}
+	}
+
+	// The temporary file a result is written through is renamed away.
+	entries, _ := os.ReadDir(filepath.Join(store.Dir, "population"))
+	if len(entries) != 1 {
+		t.Errorf("%d files in the store, want 1", len(entries))
+	}
+}
@@ -0,0 +1,215 @@
+package experiment
+
+import (
+	"math"
+	"slices"
+)
+
+// calibrationBins is how many equal-width bins a reliability diagram uses.
+const calibrationBins = 10
+
+// logScoreFloor stands in for a posterior of exactly zero on the truth, which
+// would otherwise make the log score infinite.
+const logScoreFloor = 1e-12
+
+// ROCPoint is the verifier's outcome if it accused every trial whose score
+// is at least Threshold. The first point of a curve accuses nothing.
+type ROCPoint struct {
+	Threshold float64
+	TP, FP    int
+	TPR       float64 // recall
+	FPR       float64
+	Precision float64 // 1 while nothing is accused
+}
+
+// Curves are the ROC and precision-recall curves of one score over the
+// trials, with the areas under them.
+type Curves struct {
+	Positives int
+	Negatives int
+	Points    []ROCPoint `export:"-"` // thresholds descending; too many for a flat row
+	ROCAUC    float64
+	PRAUC     float64 // average precision
+}
+
+// AtFPR is the point with the highest TPR whose FPR is at most maxFPR: the
+// strictest useful threshold for that false-alarm budget.
+func (c Curves) AtFPR(maxFPR float64) ROCPoint {
+	var best ROCPoint
+	for _, p := range c.Points {
+		if p.FPR <= maxFPR && p.TPR >= best.TPR {
+			best = p
+		}
+	}
+	return best
+}
+
+// ReliabilityBin is one bin of a reliability diagram: the trials whose
+// posterior on a hypothesis fell in [Lower, Upper), and how often that
+// hypothesis was the truth. A calibrated posterior has Observed close to
+// MeanPredicted in every bin.
+type ReliabilityBin struct {
+	Lower         float64
+	Upper         float64
+	Count         int
+	MeanPredicted float64
+	Observed      float64
+	ObservedCI    RateCI
+}
+
+// Reliability is the reliability diagram of the posterior on one hypothesis.
+type Reliability struct {
+	Hypothesis Truth
+	Bins       []ReliabilityBin // empty bins included
+	ECE        float64          // expected calibration error: the count-weighted mean |Observed - MeanPredicted|
+}
+
+// PosteriorAnalysis judges trials' final posteriors rather than their
+// verdicts, so thresholds other than the run's ConfidenceThreshold can be
+// read off one run. Trials stop once a posterior crosses α, so only
+// thresholds up to the run's α are meaningful; run at the strictest α of
+// interest, and read looser ones off the curves.
+type PosteriorAnalysis struct {
+	Trials int
+
+	// Dishonest scores each trial by 1-P(H0) against any dishonest truth;
+	// Malicious by P(H2) against the malicious truth alone.
+	Dishonest Curves
+	Malicious Curves
+
+	Reliability []Reliability // one per hypothesis, H0 to H2
+	Brier       float64       // mean over trials of Σ_k (P(H_k) - [truth is k])²
+	LogScore    float64       // mean -ln P(truth); see logScoreFloor
+}
+
+// AnalysePosteriors builds the curves and calibration of trials' posteriors
+// against their truths.
+func AnalysePosteriors(trials []TrialResult) PosteriorAnalysis {
+	a := PosteriorAnalysis{Trials: len(trials)}
+	if len(trials) == 0 {
+		return a
+	}
+	a.Dishonest = curves(trials,
+		func(t TrialResult) float64 { return 1 - t.PosteriorH0 },
+		func(t TrialResult) bool { return t.Truth != TruthHonest })
+	a.Malicious = curves(trials,
+		func(t TrialResult) float64 { return t.PosteriorH2 },
+		func(t TrialResult) bool { return t.Truth == TruthMalicious })
+
+	var brier, logScore float64
+	for _, t := range trials {
+		ps := posteriors(t)
+		for k, p := range ps {
+			y := 0.0
+			if k == t.Truth.hypothesis() {
+				y = 1
+			}
+			brier += (p - y) * (p - y)
+		}
+		logScore -= math.Log(max(ps[t.Truth.hypothesis()], logScoreFloor))
+	}
+	n := float64(len(trials))
+	a.Brier = brier / n
+	a.LogScore = logScore / n
+
+	for k, h := range []Truth{TruthHonest, TruthIncompetent, TruthMalicious} {
+		a.Reliability = append(a.Reliability, reliability(trials, h, k))
+	}
+	return a
+}
+
+// posteriors is t's posterior indexed by hypothesis.
+func posteriors(t TrialResult) [3]float64 {
+	return [3]float64{t.PosteriorH0, t.PosteriorH1, t.PosteriorH2}
+}
+
+// curves sweeps the threshold down through every distinct score; trials with
+// equal scores are accused together.
+func curves(trials []TrialResult, score func(TrialResult) float64, positive func(TrialResult) bool) Curves {
+	type scored struct {
+		s   float64
+		pos bool
+	}
+	ss := make([]scored, len(trials))
+	var c Curves
+	for i, t := range trials {
+		ss[i] = scored{score(t), positive(t)}
+		if ss[i].pos {
+			c.Positives++
+		} else {
+			c.Negatives++
+		}
+	}
+	if c.Positives == 0 || c.Negatives == 0 {
+		return c
+	}
+	slices.SortFunc(ss, func(a, b scored) int {
+		switch {
+		case a.s > b.s:
+			return -1
+		case a.s < b.s:
+			return 1
+		}
+		return 0
+	})
+
+	point := func(threshold float64, tp, fp int) ROCPoint {
+		p := ROCPoint{
+			Threshold: threshold,
+			TP:        tp,
+			FP:        fp,
+			TPR:       float64(tp) / float64(c.Positives),
+			FPR:       float64(fp) / float64(c.Negatives),
+			Precision: 1,
+		}
+		if tp+fp > 0 {
+			p.Precision = float64(tp) / float64(tp+fp)
+		}
+		return p
+	}
+	c.Points = []ROCPoint{point(math.Nextafter(ss[0].s, math.Inf(1)), 0, 0)}
+	var tp, fp int
+	for i := 0; i < len(ss); {
+		s := ss[i].s
+		for ; i < len(ss) && ss[i].s == s; i++ {
+			if ss[i].pos {
+				tp++
+			} else {
+				fp++
+			}
+		}
+		prev := c.Points[len(c.Points)-1]
+		p := point(s, tp, fp)
+		c.ROCAUC += (p.FPR - prev.FPR) * (p.TPR + prev.TPR) / 2
+		c.PRAUC += (p.TPR - prev.TPR) * p.Precision
+		c.Points = append(c.Points, p)
+	}
+	return c
+}
+
+func reliability(trials []TrialResult, h Truth, k int) Reliability {
+	r := Reliability{Hypothesis: h, Bins: make([]ReliabilityBin, calibrationBins)}
+	hits := make([]int, calibrationBins)
+	sums := make([]float64, calibrationBins)
+	for _, t := range trials {
+		p := posteriors(t)[k]
+		b := min(int(p*calibrationBins), calibrationBins-1)
+		r.Bins[b].Count++
+		sums[b] += p
+		if t.Truth == h {
+			hits[b]++
+		}
+	}
+	for b := range r.Bins {
+		bin := &r.Bins[b]
+		bin.Lower = float64(b) / calibrationBins
+		bin.Upper = float64(b+1) / calibrationBins
+		if bin.Count == 0 {
+			continue
+		}
+		bin.MeanPredicted = sums[b] / float64(bin.Count)
+		bin.Observed, bin.ObservedCI = rateOf(hits[b], bin.Count)
+		r.ECE += float64(bin.Count) / float64(len(trials)) * math.Abs(bin.Observed-bin.MeanPredicted)
+	}
+	return r
+}
@@ -0,0 +1,83 @@
+package experiment
+
+import (
+	"math"
+	"testing"
+)
+
+func posteriorTrial(truth Truth, h0, h1, h2 float64) TrialResult {
+	return TrialResult{Truth: truth, PosteriorH0: h0, PosteriorH1: h1, PosteriorH2: h2}
+}
+
+func TestCurves(t *testing.T) {
+	separated := []TrialResult{
+		posteriorTrial(TruthHonest, 0.9, 0.1, 0),
+		posteriorTrial(TruthHonest, 0.8, 0.1, 0.1),
+		posteriorTrial(TruthIncompetent, 0.3, 0.7, 0),
+		posteriorTrial(TruthMalicious, 0.1, 0.1, 0.8),
+	}
+	a := AnalysePosteriors(separated)
+	if a.Dishonest.ROCAUC != 1 || a.Dishonest.PRAUC != 1 {
+		t.Errorf("separated trials: ROC AUC %v, PR AUC %v, want 1", a.Dishonest.ROCAUC, a.Dishonest.PRAUC)
+	}
+	if a.Dishonest.Positives != 2 || a.Dishonest.Negatives != 2 || a.Malicious.Positives != 1 {
+		t.Errorf("positives %d/%d, malicious %d", a.Dishonest.Positives, a.Dishonest.Negatives, a.Malicious.Positives)
+	}
+	first, last := a.Dishonest.Points[0], a.Dishonest.Points[len(a.Dishonest.Points)-1]
+	if first.TP+first.FP != 0 || last.TPR != 1 || last.FPR != 1 {
+		t.Errorf("curve runs from %+v to %+v", first, last)
+	}
+	if p := a.Dishonest.AtFPR(0); p.TPR != 1 || p.Threshold != 0.7 {
+		t.Errorf("at FPR 0: %+v, want every dishonest trial at threshold 0.7", p)
+	}
+
+	reversed := []TrialResult{
+		posteriorTrial(TruthHonest, 0.1, 0.9, 0),
+		posteriorTrial(TruthMalicious, 0.9, 0, 0.1),
+	}
+	if auc := AnalysePosteriors(reversed).Dishonest.ROCAUC; auc != 0 {
+		t.Errorf("reversed trials: ROC AUC %v, want 0", auc)
+	}
+	tied := []TrialResult{
+		posteriorTrial(TruthHonest, 0.5, 0.5, 0),
+		posteriorTrial(TruthMalicious, 0.5, 0, 0.5),
+	}
+	c := AnalysePosteriors(tied).Dishonest
+	if c.ROCAUC != 0.5 || len(c.Points) != 2 {
+		t.Errorf("tied trials: ROC AUC %v over %d points, want 0.5 over 2", c.ROCAUC, len(c.Points))
+	}
+	if one := AnalysePosteriors(separated[:2]).Dishonest; one.Points != nil || one.ROCAUC != 0 {
+		t.Errorf("honest trials alone gave a curve: %+v", one)
+	}
+}
+
+func TestCalibration(t *testing.T) {
+	// Four trials at P(H0)=0.75, three of them honest: calibrated.
+	trials := []TrialResult{
+		posteriorTrial(TruthHonest, 0.75, 0.25, 0),
+		posteriorTrial(TruthHonest, 0.75, 0.25, 0),
+		posteriorTrial(TruthHonest, 0.75, 0.25, 0),
+		posteriorTrial(TruthIncompetent, 0.75, 0.25, 0),
+	}
+	a := AnalysePosteriors(trials)
+	h0 := a.Reliability[0]
+	bin := h0.Bins[7]
+	if bin.Count != 4 || bin.Observed != 0.75 || math.Abs(bin.MeanPredicted-0.75) > 1e-12 {
+		t.Errorf("H0 bin [0.7, 0.8): %+v", bin)
+	}
+	if h0.ECE > 1e-12 || a.Reliability[1].ECE > 1e-12 {
+		t.Errorf("calibrated posteriors: ECE %v and %v", h0.ECE, a.Reliability[1].ECE)
+	}
+	// Brier: 3 × (0.25² + 0.25²) + (0.75² + 0.75²), over 4.
+	if want := (3*0.125 + 1.125) / 4; math.Abs(a.Brier-want) > 1e-12 {
+		t.Errorf("Brier %v, want %v", a.Brier, want)
+	}
+	if want := -(3*math.Log(0.75) + math.Log(0.25)) / 4; math.Abs(a.LogScore-want) > 1e-12 {
+		t.Errorf("log score %v, want %v", a.LogScore, want)
+	}
+
+	certain := AnalysePosteriors([]TrialResult{posteriorTrial(TruthMalicious, 1, 0, 0)})
+	if certain.LogScore != -math.Log(logScoreFloor) || certain.Reliability[0].Bins[calibrationBins-1].Count != 1 {
+		t.Errorf("confidently wrong trial: log score %v, bins %+v", certain.LogScore, certain.Reliability[0].Bins)
+	}
+}
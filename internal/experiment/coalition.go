@@ -0,0 +1,113 @@
+package experiment
+
+import (
+	"fmt"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// CoalitionSetup puts several customers on one operator. Each sends
+// NumPackets of its own, BatchSize at a time, at the same send times as the
+// rest, so the operator's batches hold packets from all of them. Coalition
+// trials are audited after the run: Streaming, probes and Regimes are
+// ignored.
+type CoalitionSetup struct {
+	Clients int // 0 or 1 is the usual lone customer
+	Victims int // the adversary only targets the first Victims clients
+	Members int // the first Members clients audit together; 0 means 1
+	Sharing verification.CoalitionConfig
+}
+
+func clientName(c int) string {
+	return fmt.Sprintf("client%d", c)
+}
+
+func (r *Runner) runCoalitionTrial(cfg Scenario, trialNum int) TrialResult {
+	co := cfg.Coalition
+	sim := engine.NewSimulation()
+
+	dm := network.NewDelayModelConfig(cfg.DelayModel)
+	dm.Initialise(cfg.SimDuration + 10.0)
+
+	prover := verification.NewProver(cfg.Answering.adversary())
+
+	targeting := cfg.Targeting
+	targeting.Sources = nil
+	for c := range co.Victims {
+		targeting.Sources = append(targeting.Sources, clientName(c))
+	}
+	router := network.NewRouter(dm, targeting, cfg.Flagging.fn())
+	router.OnTransmission = prover.RecordTransmission
+	cfg.Timeline.schedule(sim, &timelineTarget{dm: dm, router: router, prover: prover, flagging: cfg.Flagging})
+
+	batchSize := max(2, cfg.BatchSize)
+	numBatches := max(1, cfg.NumPackets/batchSize)
+	client := make(map[string]int, co.Clients)
+	dest := &honestDest{}
+	pktID := 0
+	for b := range numBatches {
+		sendTime := float64(b) * (cfg.SimDuration / float64(numBatches))
+		for c := range co.Clients {
+			src := clientName(c)
+			client[src] = c
+			for range batchSize {
+				id := pktID
+				pktID++
+				sim.Schedule(sendTime, func() {
+					router.Forward(sim, network.NewPacket(id, b, src, sim.Now), dest)
+				})
+			}
+		}
+	}
+	sim.Run(cfg.SimDuration + 10.0)
+
+	vcfg := cfg.Verification
+	if cfg.DeclareSchedule {
+		vcfg.DeclaredBaseDelay = declaredSchedule(dm)
+	}
+	enableSigning(prover, vcfg)
+	members := make([][]*network.Packet, max(1, co.Members))
+	for _, p := range prover.Packets {
+		if c := client[p.Src]; c < len(members) {
+			members[c] = append(members[c], p)
+		}
+	}
+	coalition := &verification.Coalition{Prover: prover, Members: members, Config: vcfg, Sharing: co.Sharing}
+	return newTrialResult(cfg, trialNum, coalition.Run(), prover.Packets, 0)
+}
+
+// CoalitionConfig spreads base's adversary over clients customers, of which
+// it targets the first victims, and has the first members of them audit
+// together.
+func CoalitionConfig(base MaliciousBaselineConfig, clients, victims, members int, sharing verification.CoalitionConfig) MaliciousBaselineConfig {
+	cfg := base
+	cfg.Coalition = CoalitionSetup{Clients: clients, Victims: victims, Members: members, Sharing: sharing}
+	return cfg
+}
+
+// SweepCoalitionSize audits an operator that targets victims of clients
+// customers with coalitions of each size, for each way of pooling: queries,
+// log-likelihoods, and each with cross-customer batches. Each size is run
+// against base's adversary and then against an honest operator, where any
+// accusation is a false one.
+func (r *Runner) SweepCoalitionSize(base MaliciousBaselineConfig, clients, victims int, sizes []int) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: coalition size (%d of %d clients targeted) [%s] ===\n", victims, clients, base.Name)
+	return runAll(r.RunMalicious, coalitionSizeConfigs(base, clients, victims, sizes))
+}
+
+func coalitionSizeConfigs(base MaliciousBaselineConfig, clients, victims int, sizes []int) []MaliciousBaselineConfig {
+	cfg := CoalitionConfig(base, clients, victims, 0, verification.CoalitionConfig{})
+	pools := []string{string(verification.PoolQueries), string(verification.PoolLikelihoods)}
+	return mustGridConfigs(cfg, Grid{
+		{{Field: "Coalition.Members", Values: floats(sizes), Format: "%d"}},
+		{
+			{Field: "Coalition.Sharing.Pool", Names: append(pools, pools...),
+				Labels: []string{"poolQUERIES", "poolLIKELIHOODS", "poolQUERIES", "poolLIKELIHOODS"}},
+			{Field: "Coalition.Sharing.CrossBatches", Names: []string{"false", "false", "true", "true"}, Label: "cross", Format: "%t"},
+		},
+		{{Field: "Targeting", Names: []string{mustJSON(cfg.Targeting), mustJSON(network.DefaultHonestTargeting())},
+			Labels: []string{"", "honest"}}},
+	}, nil)
+}
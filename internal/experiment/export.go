@@ -0,0 +1,470 @@
+package experiment
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportSchemaVersion changes when the rules that name and type the flat
+// columns change. New config or result fields only add columns, which the
+// header lists, and leave it alone. Version 2 took the columns from the
+// types instead of the rows, writing variable-length slices and maps as JSON.
+// Version 3 marks malicious results taking the shared class names: in trials'
+// VerdictClass MISSED became TRUSTED and MISCLASSIFIED_INCOMPETENT became
+// CAUGHT_INCOMPETENT, and the aggregate columns MissedRate and
+// MisclassifiedIncompRate became TrustedRate and CaughtIncompetentRate.
+const exportSchemaVersion = 3
+
+// Export formats; see Runner.Format.
+const (
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var exportFormats = []string{FormatJSON, FormatCSV, FormatJSONL}
+
+// ExportHeader opens every flat export: the first line of a JSON Lines file,
+// and the first line of a CSV file after "# ".
+type ExportHeader struct {
+	Schema      string         `json:"schema"` // satnet.aggregates or satnet.trials
+	Version     int            `json:"version"`
+	Type        string         `json:"type"` // the Go type the rows flatten, e.g. MaliciousAggregate
+	CodeVersion string         `json:"code_version"`
+	Seed        int64          `json:"seed"`
+	Columns     []ExportColumn `json:"columns"`
+}
+
+type ExportColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // bool, int, float, string or duration_ns
+}
+
+// CheckFormat reports whether format names an export format.
+func CheckFormat(format string) error {
+	if format != "" && !slices.Contains(exportFormats, format) {
+		return fmt.Errorf("unknown format %q (want %s)", format, strings.Join(exportFormats, ", "))
+	}
+	return nil
+}
+
+// saveResults writes a slice of aggregates in r.Format, or failing that in
+// the format path's extension names: indented JSON, or for csv and jsonl a
+// flat file of one row per aggregate plus one of one row per trial beside it
+// (x.csv and x.trials.csv). The flat files' columns come from the types
+// alone, so each row is written as soon as it is flattened.
+func (r *Runner) saveResults(path string, results any) error {
+	format := r.Format
+	ext := filepath.Ext(path)
+	if format == "" {
+		format = strings.TrimPrefix(ext, ".")
+	} else {
+		path = strings.TrimSuffix(path, ext) + "." + format
+		ext = "." + format
+	}
+	if format != FormatCSV && format != FormatJSONL {
+		return r.saveJSON(path, results)
+	}
+
+	v := reflect.ValueOf(results)
+	aggType := v.Type().Elem()
+	aggs, err := r.createFlat(path, format, "satnet.aggregates", aggType.Name(), aggregateColumns(aggType))
+	if err != nil {
+		return err
+	}
+	var trials *flatFile
+	var trialType reflect.Type
+	if f, ok := aggType.FieldByName("Trials"); ok {
+		trialType = f.Type.Elem()
+		trialPath := strings.TrimSuffix(path, ext) + ".trials" + ext
+		if trials, err = r.createFlat(trialPath, format, "satnet.trials", trialType.Name(), trialColumns(aggType, trialType)); err != nil {
+			aggs.close()
+			return err
+		}
+	}
+
+	for i := range v.Len() {
+		if err = writeAggregate(aggs, trials, i, v.Index(i)); err != nil {
+			break
+		}
+	}
+	for _, ff := range []*flatFile{aggs, trials} {
+		if ff == nil {
+			continue
+		}
+		if cerr := ff.close(); err == nil {
+			err = cerr
+		}
+		if err == nil && r.Verbose {
+			fmt.Printf("    wrote %s\n", ff.path)
+		}
+	}
+	return err
+}
+
+// cell is one column of a flat row; value is nil, bool, int64, uint64,
+// float64, string or time.Duration.
+type cell struct {
+	name  string
+	value any
+}
+
+// configFields are the aggregate fields holding what was run. Trial rows
+// carry them so each row stands alone.
+var configFields = []string{"Config", "Scenario", "Population"}
+
+// visitor is called once per column in schema order, with the column's type
+// and its value, which is invalid when the row has nothing there.
+type visitor func(name string, t reflect.Type, v reflect.Value)
+
+// walkAggregate visits an aggregate's columns, trials aside. With agg
+// invalid it visits the columns alone.
+func walkAggregate(t reflect.Type, agg reflect.Value, visit visitor) {
+	for i := range t.NumField() {
+		if f := t.Field(i); f.Name != "Trials" {
+			walkField("", f, field(agg, f.Index), visit)
+		}
+	}
+}
+
+// walkTrial visits a trial's columns: its aggregate's config, then its own.
+func walkTrial(aggType reflect.Type, agg reflect.Value, trialType reflect.Type, trial reflect.Value, visit visitor) {
+	for _, name := range configFields {
+		if f, ok := aggType.FieldByName(name); ok {
+			walkField("", f, field(agg, f.Index), visit)
+		}
+	}
+	walk("", trialType, trial, visit)
+}
+
+func aggregateColumns(t reflect.Type) []ExportColumn {
+	cols := []ExportColumn{{Name: "Index", Type: "int"}}
+	walkAggregate(t, reflect.Value{}, columnsOf(&cols))
+	return cols
+}
+
+func trialColumns(aggType, trialType reflect.Type) []ExportColumn {
+	cols := []ExportColumn{{Name: "Aggregate", Type: "int"}}
+	walkTrial(aggType, reflect.Value{}, trialType, reflect.Value{}, columnsOf(&cols))
+	return cols
+}
+
+func columnsOf(cols *[]ExportColumn) visitor {
+	return func(name string, t reflect.Type, _ reflect.Value) {
+		*cols = append(*cols, ExportColumn{Name: name, Type: columnType(t)})
+	}
+}
+
+// writeAggregate writes aggregate i's row and then its trials' rows.
+func writeAggregate(aggs, trials *flatFile, i int, agg reflect.Value) error {
+	var err error
+	cells := func(row *[]cell) visitor {
+		return func(name string, t reflect.Type, v reflect.Value) {
+			value, verr := cellValue(t, v)
+			if verr != nil && err == nil {
+				err = fmt.Errorf("%s: %w", name, verr)
+			}
+			*row = append(*row, cell{name, value})
+		}
+	}
+
+	row := []cell{{"Index", int64(i)}}
+	walkAggregate(agg.Type(), agg, cells(&row))
+	if err != nil {
+		return err
+	}
+	if err := aggs.write(row); err != nil {
+		return err
+	}
+	if trials == nil {
+		return nil
+	}
+	ts := agg.FieldByName("Trials")
+	for j := range ts.Len() {
+		row = append(row[:0], cell{"Aggregate", int64(i)})
+		walkTrial(agg.Type(), agg, ts.Type().Elem(), ts.Index(j), cells(&row))
+		if err != nil {
+			return err
+		}
+		if err := trials.write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field is v's field at index, or invalid when v is.
+func field(v reflect.Value, index []int) reflect.Value {
+	if !v.IsValid() {
+		return v
+	}
+	return v.FieldByIndex(index)
+}
+
+func walkField(prefix string, f reflect.StructField, v reflect.Value, visit visitor) {
+	if !f.IsExported() || f.Tag.Get("export") == "-" {
+		return
+	}
+	if f.Anonymous && f.Type.Kind() == reflect.Struct {
+		walk(prefix, f.Type, v, visit)
+		return
+	}
+	walk(join(prefix, f.Name), f.Type, v, visit)
+}
+
+// walk visits the columns of type t below prefix, named by their Go path as
+// in a Grid axis: struct fields by name, embedded structs' fields as their
+// own, the elements of fixed-size arrays by index, and what a nil pointer
+// leaves out as empty. Columns depend on t alone, so every row has the same
+// ones. A slice or array of scalars is one column, its elements joined by
+// ";"; any other slice, a map or an interface varies in shape from row to
+// row and is one column holding its JSON. Enums are written by name,
+// durations in nanoseconds.
+func walk(prefix string, t reflect.Type, v reflect.Value, visit visitor) {
+	if t == durationType || scalar(t) {
+		visit(prefix, t, v)
+		return
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsValid() && !v.IsNil() {
+			v = v.Elem()
+		} else {
+			v = reflect.Value{}
+		}
+		walk(prefix, t.Elem(), v, visit)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			walkField(prefix, t.Field(i), field(v, []int{i}), visit)
+		}
+	case reflect.Array:
+		if scalar(t.Elem()) {
+			visit(prefix, t, v)
+			return
+		}
+		for i := range t.Len() {
+			var elem reflect.Value
+			if v.IsValid() {
+				elem = v.Index(i)
+			}
+			walk(join(prefix, strconv.Itoa(i)), t.Elem(), elem, visit)
+		}
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+	default:
+		visit(prefix, t, v)
+	}
+}
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	stringerType = reflect.TypeFor[fmt.Stringer]()
+)
+
+func join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func scalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func scalarValue(v reflect.Value) any {
+	if s, ok := v.Interface().(fmt.Stringer); ok && v.Kind() != reflect.String {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+	return v.String()
+}
+
+// cellValue is v as a cell of a column of type t, or nil when the row has
+// nothing there.
+func cellValue(t reflect.Type, v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch {
+	case t == durationType:
+		return time.Duration(v.Int()), nil
+	case scalar(t):
+		return scalarValue(v), nil
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && scalar(t.Elem()):
+		parts := make([]string, v.Len())
+		for i := range v.Len() {
+			parts[i] = formatValue(scalarValue(v.Index(i)))
+		}
+		return strings.Join(parts, ";"), nil
+	}
+	if v.IsNil() {
+		return nil, nil
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// columnType is the type of a column of Go type t, matching the values
+// cellValue gives it.
+func columnType(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration_ns"
+	case !scalar(t) || t.Kind() != reflect.String && t.Implements(stringerType):
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	}
+	return "string"
+}
+
+func formatValue(value any) string {
+	switch x := value.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case time.Duration:
+		return strconv.FormatInt(int64(x), 10)
+	}
+	return fmt.Sprint(value)
+}
+
+// flatFile is one flat export being written row by row, under a header
+// fixed before the first row.
+type flatFile struct {
+	path string
+	f    *os.File
+	w    *bufio.Writer
+	cw   *csv.Writer // nil for JSON Lines
+}
+
+// createFlat creates path and writes its header.
+func (r *Runner) createFlat(path, format, schema, typ string, columns []ExportColumn) (*flatFile, error) {
+	meta, err := json.Marshal(ExportHeader{
+		Schema:      schema,
+		Version:     exportSchemaVersion,
+		Type:        typ,
+		CodeVersion: CodeVersion(),
+		Seed:        r.baseSeed,
+		Columns:     columns,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	ff := &flatFile{path: path, f: f, w: bufio.NewWriter(f)}
+	switch format {
+	case FormatCSV:
+		fmt.Fprintf(ff.w, "# %s\n", meta)
+		ff.cw = csv.NewWriter(ff.w)
+		names := make([]string, len(columns))
+		for i, c := range columns {
+			names[i] = c.Name
+		}
+		err = ff.cw.Write(names)
+	case FormatJSONL:
+		_, err = fmt.Fprintf(ff.w, "%s\n", meta)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return ff, nil
+}
+
+// write writes one row, its cells in the header's column order.
+func (ff *flatFile) write(row []cell) error {
+	if ff.cw == nil {
+		return writeJSONLine(ff.w, row)
+	}
+	record := make([]string, len(row))
+	for i, c := range row {
+		record[i] = formatValue(c.value)
+	}
+	return ff.cw.Write(record)
+}
+
+func (ff *flatFile) close() error {
+	var err error
+	if ff.cw != nil {
+		ff.cw.Flush()
+		err = ff.cw.Error()
+	}
+	if err == nil {
+		err = ff.w.Flush()
+	}
+	if cerr := ff.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeJSONLine writes row as one JSON object, its keys in column order.
+// JSON has no NaN or infinities, so those are written as null.
+func writeJSONLine(w *bufio.Writer, row []cell) error {
+	w.WriteByte('{')
+	for i, c := range row {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		name, _ := json.Marshal(c.name)
+		w.Write(name)
+		w.WriteByte(':')
+		value := c.value
+		if x, ok := value.(float64); ok && (math.IsNaN(x) || math.IsInf(x, 0)) {
+			value = nil
+		}
+		if d, ok := value.(time.Duration); ok {
+			value = int64(d)
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.Write(b)
+	}
+	_, err := w.WriteString("}\n")
+	return err
+}
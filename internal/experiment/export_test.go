@@ -0,0 +1,180 @@
+package experiment
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"satnet-simulator/internal/network"
+)
+
+func TestFlatten(t *testing.T) {
+	type inner struct{ A, B float64 }
+	type Embedded struct{ E int }
+	v := struct {
+		Embedded
+		Name     string
+		Mode     network.TargetingMode
+		In       inner
+		List     []inner
+		Tags     []string
+		Counts   map[string]int
+		Took     time.Duration
+		Optional *inner
+		Hidden   []inner `export:"-"`
+		private  int
+	}{
+		Embedded: Embedded{7},
+		Name:     "x",
+		Mode:     network.TargetPeriodic,
+		In:       inner{1, 2},
+		List:     []inner{{3, 4}},
+		Tags:     []string{"SLA", "PROBE"},
+		Counts:   map[string]int{"b": 2, "a": 1},
+		Took:     time.Second,
+		Hidden:   []inner{{5, 6}},
+	}
+	var got []cell
+	walk("", reflect.TypeOf(v), reflect.ValueOf(v), func(name string, typ reflect.Type, v reflect.Value) {
+		value, err := cellValue(typ, v)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got = append(got, cell{name, value})
+	})
+	want := []cell{
+		{"E", int64(7)},
+		{"Name", "x"},
+		{"Mode", "PERIODIC"},
+		{"In.A", 1.0}, {"In.B", 2.0},
+		{"List", `[{"A":3,"B":4}]`},
+		{"Tags", "SLA;PROBE"},
+		{"Counts", `{"a":1,"b":2}`},
+		{"Took", time.Second},
+		{"Optional.A", nil}, {"Optional.B", nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("flattened to\n%v\nwant\n%v", got, want)
+	}
+
+	var cols []ExportColumn
+	walk("", reflect.TypeOf(v), reflect.Value{}, columnsOf(&cols))
+	wantCols := []string{"E:int", "Name:string", "Mode:string", "In.A:float", "In.B:float", "List:string",
+		"Tags:string", "Counts:string", "Took:duration_ns", "Optional.A:float", "Optional.B:float"}
+	if len(cols) != len(wantCols) {
+		t.Fatalf("columns %v, want %v", cols, wantCols)
+	}
+	for i, c := range cols {
+		if c.Name+":"+c.Type != wantCols[i] {
+			t.Errorf("column %d is %s:%s, want %s", i, c.Name, c.Type, wantCols[i])
+		}
+	}
+}
+
+func TestSaveFlat(t *testing.T) {
+	cfg := DefaultHonestBaseline()
+	results := []HonestAggregate{
+		{Config: cfg, Trials: []TrialResult{{TrialNum: 0, Verdict: "TRUSTED"}, {TrialNum: 1, Verdict: "DISHONEST", Evidence: []string{"SLA"}}}, TrustedRate: 0.5},
+		{Config: cfg, Trials: []TrialResult{{TrialNum: 0, Verdict: "TRUSTED", Confidence: math.NaN()}}, TrustedRate: 1},
+	}
+	dir := t.TempDir()
+	r := NewRunner()
+	r.Verbose = false
+	r.SetBaseSeed(4)
+
+	if err := r.SaveAggregates(filepath.Join(dir, "honest.csv"), results); err != nil {
+		t.Fatal(err)
+	}
+	header, records := readCSVExport(t, filepath.Join(dir, "honest.csv"))
+	if header.Schema != "satnet.aggregates" || header.Type != "HonestAggregate" || header.Seed != 4 || header.Version != exportSchemaVersion {
+		t.Errorf("header %+v", header)
+	}
+	if len(records) != 3 || len(header.Columns) != len(records[0]) {
+		t.Fatalf("%d records of %d columns, header lists %d", len(records), len(records[0]), len(header.Columns))
+	}
+	col := func(name string) int {
+		for i, c := range header.Columns {
+			if c.Name == name {
+				return i
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return 0
+	}
+	if records[2][col("TrustedRate")] != "1" || records[1][col("Config.Verification.ErrorTolerance")] == "" {
+		t.Errorf("aggregate rows %v", records)
+	}
+	if header.Columns[col("TrustedRateCI.Lower")].Type != "float" {
+		t.Errorf("CI columns are %s", header.Columns[col("TrustedRateCI.Lower")].Type)
+	}
+
+	trialHeader, trials := readCSVExport(t, filepath.Join(dir, "honest.trials.csv"))
+	if trialHeader.Schema != "satnet.trials" || trialHeader.Type != "TrialResult" || len(trials) != 4 {
+		t.Fatalf("trials export: %+v with %d records", trialHeader, len(trials))
+	}
+
+	// The columns come from the types, so no results at all still have them.
+	if err := r.SaveAggregates(filepath.Join(dir, "none.csv"), []HonestAggregate{}); err != nil {
+		t.Fatal(err)
+	}
+	if h, rows := readCSVExport(t, filepath.Join(dir, "none.trials.csv")); !reflect.DeepEqual(h.Columns, trialHeader.Columns) || len(rows) != 1 {
+		t.Errorf("empty export has %d columns and %d records; want the %d columns alone", len(h.Columns), len(rows), len(trialHeader.Columns))
+	}
+
+	r.Format = FormatJSONL
+	if err := r.SaveAggregates(filepath.Join(dir, "honest.json"), results); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(filepath.Join(dir, "honest.trials.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []map[string]any
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("line %d: %v", len(lines)+1, err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 4 || lines[0]["schema"] != "satnet.trials" {
+		t.Fatalf("%d lines, header %v", len(lines), lines[0])
+	}
+	if lines[2]["Aggregate"] != 0.0 || lines[2]["Evidence"] != "SLA" || lines[3]["Aggregate"] != 1.0 {
+		t.Errorf("trial lines %v", lines[1:])
+	}
+	if v, ok := lines[3]["Confidence"]; !ok || v != nil {
+		t.Errorf("NaN confidence written as %v", v)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "honest.json")); err == nil {
+		t.Error("jsonl format also wrote the .json path")
+	}
+}
+
+func readCSVExport(t *testing.T, path string) (ExportHeader, [][]string) {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, rest, _ := strings.Cut(string(b), "\n")
+	var h ExportHeader
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(first, "# ")), &h); err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(rest)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, records
+}
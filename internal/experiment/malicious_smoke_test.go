@@ -1,8 +1,10 @@
 package experiment
 
 import (
+	"slices"
 	"testing"
 
+	"satnet-simulator/internal/network"
 	"satnet-simulator/internal/verification"
 )
 
@@ -24,9 +26,9 @@ func TestMaliciousStrategiesSmoke(t *testing.T) {
 		cfg := NaiveLiarConfig(base, 0.10)
 		cfg.Name = "test_naive_liar"
 		agg := runner.RunMalicious(cfg)
-		if agg.MissedRate+agg.CorrectDetectionRate+agg.InconclusiveRate < 0.999 {
-			t.Errorf("verdict rates do not sum to 1: missed=%.3f caught=%.3f inconclusive=%.3f",
-				agg.MissedRate, agg.CorrectDetectionRate, agg.InconclusiveRate)
+		if agg.TrustedRate+agg.CorrectDetectionRate+agg.InconclusiveRate < 0.999 {
+			t.Errorf("verdict rates do not sum to 1: trusted=%.3f caught=%.3f inconclusive=%.3f",
+				agg.TrustedRate, agg.CorrectDetectionRate, agg.InconclusiveRate)
 		}
 	})
 
@@ -45,8 +47,8 @@ func TestMaliciousStrategiesSmoke(t *testing.T) {
 		cfg := SmartStrategyConfig(base, tauFlag*0.5)
 		cfg.Name = "test_smart_compliant"
 		agg := runner.RunMalicious(cfg)
-		if agg.MissedRate < 1.0 {
-			t.Errorf("smart strategy within SLA should be TRUSTED but missed=%.2f", agg.MissedRate)
+		if agg.TrustedRate < 1.0 {
+			t.Errorf("smart strategy within SLA should be TRUSTED but trusted=%.2f", agg.TrustedRate)
 		}
 	})
 
@@ -80,8 +82,172 @@ func TestMaliciousStrategiesSmoke(t *testing.T) {
 		cfg1.PLie = 1.0
 		agg := runner.RunMalicious(cfg1)
 		// Should never see hidden-delay admissions (p_lie=1 always lies)
-		if agg.MisclassifiedIncompRate > 0 {
-			t.Logf("note: p_lie=1 produced misclassified_incompetent=%.3f (possible with small trials)", agg.MisclassifiedIncompRate)
+		if agg.CaughtIncompetentRate > 0 {
+			t.Logf("note: p_lie=1 produced caught_incompetent=%.3f (possible with small trials)", agg.CaughtIncompetentRate)
+		}
+	})
+
+	t.Run("StreamingStopsEarly", func(t *testing.T) {
+		cfg := NaiveLiarConfig(base, 0.10)
+		cfg.Name = "test_streaming"
+		cfg.Streaming = verification.DefaultStreamingConfig()
+		cfg.Streaming.HaltSimulation = true
+		agg := runner.RunMalicious(cfg)
+		if agg.TrustedRate+agg.CorrectDetectionRate+agg.InconclusiveRate < 0.999 {
+			t.Errorf("verdict rates do not sum to 1: trusted=%.3f caught=%.3f inconclusive=%.3f",
+				agg.TrustedRate, agg.CorrectDetectionRate, agg.InconclusiveRate)
+		}
+		if agg.P90TimeToVerdict <= 0 || agg.P90TimeToVerdict >= cfg.SimDuration {
+			t.Errorf("streaming verdict should land mid-run: p90=%.2fs of %.0fs", agg.P90TimeToVerdict, cfg.SimDuration)
+		}
+	})
+
+	t.Run("StallingTimeoutPolicies", func(t *testing.T) {
+		results := runner.SweepMaliciousTimeoutPolicies(base, 0.5)
+		if len(results) != 9 {
+			t.Fatalf("expected 9 stalling/timeout combinations, got %d", len(results))
+		}
+		for _, agg := range results {
+			total := agg.TrustedRate + agg.CorrectDetectionRate + agg.InconclusiveRate
+			if total < 0.999 {
+				t.Errorf("%s: verdict rates sum to %.3f", agg.Config.Name, total)
+			}
+			if agg.MeanContradictions > 0 && agg.Config.Verification.TimeoutPolicy != verification.TimeoutContradiction {
+				t.Errorf("%s: a staller that never lies produced contradictions", agg.Config.Name)
+			}
+		}
+	})
+
+	t.Run("DelayBounds", func(t *testing.T) {
+		results := runner.SweepMaliciousDelayBounds(base)
+		if len(results) != 3 {
+			t.Fatalf("expected 3 bound variants, got %d", len(results))
+		}
+		if results[0].MeanBoundViolations != 0 {
+			t.Errorf("%s: bound violations without any bound", results[0].Config.Name)
+		}
+		if declared := results[2]; declared.CorrectDetectionRate < results[0].CorrectDetectionRate {
+			t.Errorf("declaring the schedule made global inflation harder to catch: %.2f < %.2f",
+				declared.CorrectDetectionRate, results[0].CorrectDetectionRate)
+		}
+	})
+
+	t.Run("ProbeDistinguishability", func(t *testing.T) {
+		results := runner.SweepMaliciousProbeDistinguishability(NaiveLiarConfig(base, 0.5), 0.2, []float64{0, 1})
+		if len(results) != 3 {
+			t.Fatalf("expected a no-probe reference plus 2 runs, got %d", len(results))
+		}
+		for _, tr := range results[0].Trials {
+			if tr.ProbesQueried > 0 {
+				t.Fatalf("%s: probes queried with ProbeRate 0", results[0].Config.Name)
+			}
+		}
+		if spotted := results[2]; spotted.MeanProbesDelayed != 0 {
+			t.Errorf("%s: an adversary that spots every probe still delayed %.2f per trial",
+				spotted.Config.Name, spotted.MeanProbesDelayed)
+		}
+	})
+
+	t.Run("Timeline", func(t *testing.T) {
+		bad := []TimelineEvent{
+			{At: 10, Set: map[string]any{"NoSuchParameter": 1.0}},
+			{At: 10, Set: map[string]any{"TargetingMode": "SOMETIMES"}},
+			{At: 10, Set: map[string]any{"Quota": 2.5}},
+			{At: 10, Set: map[string]any{"Period": -1}},
+			{At: 10, Set: map[string]any{"PLie": 1.5}},
+			{At: 10, Set: map[string]any{"IncompetenceRate": -0.1}},
+			{At: 10, Until: 5, Outage: true},
+			{At: 10},
+		}
+		for _, ev := range bad {
+			if err := (Timeline{Events: []TimelineEvent{ev}}).Validate(); err == nil {
+				t.Errorf("%+v should not validate", ev)
+			}
+		}
+
+		cfg := NaiveLiarConfig(base, 0.10)
+		cfg.Name = "test_timeline_outage"
+		cfg.Timeline = Timeline{Events: []TimelineEvent{
+			{At: 0, Set: map[string]any{"TargetingMode": "QUOTA", "Quota": 10, "BatchSize": 10}},
+			{At: 0, Until: base.SimDuration + 1, Outage: true},
+		}}
+		if err := cfg.Timeline.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		agg := runner.RunMalicious(cfg)
+		if agg.InconclusiveRate != 1 {
+			t.Errorf("nothing gets through an outage covering the whole run, yet inconclusive rate %.3f", agg.InconclusiveRate)
+		}
+	})
+
+	t.Run("RegimeSwitch", func(t *testing.T) {
+		results := runner.SweepRegimeForgetting(base, NaiveLiarConfig(base, 0.5), 20, 30, []float64{0.2}, []int{10})
+		if len(results) != 3 {
+			t.Fatalf("expected no forgetting, one share and one window, got %d", len(results))
+		}
+		for _, agg := range results {
+			for _, tr := range agg.Trials {
+				if tr.SwitchTime < 20 || tr.SwitchTime >= 30 {
+					t.Fatalf("%s: switch at %.2f, outside [20, 30)", agg.Config.Name, tr.SwitchTime)
+				}
+			}
+			if agg.FalseAlarmRate+agg.DetectedAfterSwitchRate > 1+1e-12 {
+				t.Errorf("%s: false alarms %.3f and detections %.3f exceed 1", agg.Config.Name, agg.FalseAlarmRate, agg.DetectedAfterSwitchRate)
+			}
+			if agg.MeanDetectionDelay < 0 {
+				t.Errorf("%s: negative detection delay %.3f", agg.Config.Name, agg.MeanDetectionDelay)
+			}
+		}
+	})
+
+	t.Run("SafeLiars", func(t *testing.T) {
+		results := runner.SweepMaliciousSafeLiars(base, []float64{0.2})
+		if len(results) != 2 {
+			t.Fatalf("expected a random and a whole-batch safe liar, got %d", len(results))
+		}
+		for _, agg := range results {
+			for _, tr := range agg.Trials {
+				if tr.ContradictionsFound > 0 {
+					t.Fatalf("%s: a safe liar was contradicted %d times", agg.Config.Name, tr.ContradictionsFound)
+				}
+			}
+			if agg.EvadedDelayShare > agg.MeanTargetedShare+1e-12 {
+				t.Errorf("%s: evaded %.3f of packets but only delayed %.3f", agg.Config.Name, agg.EvadedDelayShare, agg.MeanTargetedShare)
+			}
+		}
+	})
+
+	t.Run("MinimumQueries", func(t *testing.T) {
+		results := runner.SweepMaliciousMinimumQueries(base, []float64{0.2})
+		if len(results) != 2 {
+			t.Fatalf("expected query-minimum off/on, got %d", len(results))
+		}
+		for _, agg := range results {
+			for _, tr := range agg.Trials {
+				if slices.Contains(tr.Evidence, verification.EvidenceFalseDenial) && !agg.Config.Verification.QueryMinimum {
+					t.Fatalf("%s: false denial scored without QueryMinimum", agg.Config.Name)
+				}
+			}
+		}
+	})
+
+	t.Run("OverFlagging", func(t *testing.T) {
+		results := runner.SweepMaliciousOverFlagging(base, 0.2, []float64{0, 0.2})
+		if len(results) != 4 {
+			t.Fatalf("expected 2 over-flag rates x check off/on, got %d", len(results))
+		}
+		for _, agg := range results {
+			check := agg.Config.Verification.CheckFalseFlags
+			for _, tr := range agg.Trials {
+				if tr.FalseFlags > 0 && (!check || agg.Config.POverFlag == 0) {
+					t.Fatalf("%s: %d false flags counted", agg.Config.Name, tr.FalseFlags)
+				}
+			}
+			for kind, frac := range agg.DetectionsByEvidence {
+				if frac <= 0 || frac > 1 {
+					t.Errorf("%s: %s share of detections is %.3f", agg.Config.Name, kind, frac)
+				}
+			}
 		}
 	})
 
@@ -91,13 +257,62 @@ func TestMaliciousStrategiesSmoke(t *testing.T) {
 			t.Errorf("expected 4 targeting modes, got %d", len(results))
 		}
 		for _, agg := range results {
-			total := agg.MissedRate + agg.CorrectDetectionRate + agg.InconclusiveRate
+			total := agg.TrustedRate + agg.CorrectDetectionRate + agg.InconclusiveRate
 			if total < 0.999 {
 				t.Errorf("mode %s: verdict rates sum to %.3f", agg.Config.Name, total)
 			}
 		}
 	})
 
+	t.Run("PathBlame", func(t *testing.T) {
+		pathBase := DefaultPathBaseline()
+		pathBase.NumTrials = 3
+		pathBase.NumPackets = 200
+		results := runner.SweepPathBlame(pathBase, 0.2)
+		if len(results) != 1+2*3*len(pathBase.Segments) {
+			t.Fatalf("expected an honest path and 18 liars, got %d", len(results))
+		}
+		if results[0].UnblamedRate != 1 {
+			t.Errorf("an honest path was blamed: %+v", results[0].MeanBlame)
+		}
+		for _, agg := range results[1:] {
+			total := agg.CorrectAttributionRate + agg.WrongAttributionRate + agg.AmbiguousRate + agg.UnblamedRate
+			if total < 0.999 || total > 1.001 {
+				t.Errorf("%s: attribution rates sum to %.3f", agg.Config.Name, total)
+			}
+			if agg.WrongAttributionRate > 0 {
+				t.Errorf("%s: an honest segment was blamed alone", agg.Config.Name)
+			}
+			// a liar no query landed on goes unblamed, but receipts leave no ties
+			if agg.Config.Verification.Receipts && agg.AmbiguousRate > 0 {
+				t.Errorf("%s: receipts should settle every handoff, got ambiguous rate %.3f", agg.Config.Name, agg.AmbiguousRate)
+			}
+		}
+	})
+
+	t.Run("Coalition", func(t *testing.T) {
+		// the victim's whole stream is slowed, so its own batches hide it
+		results := runner.SweepCoalitionSize(GlobalInflationConfig(base), 4, 1, []int{1, 2})
+		if len(results) != 4*2*2 {
+			t.Fatalf("expected 4 sharings x 2 sizes x honest control, got %d", len(results))
+		}
+		for _, agg := range results {
+			co := agg.Config.Coalition
+			if agg.Config.Targeting.Mode == network.TargetNone {
+				if agg.CorrectDetectionRate > 0 {
+					t.Errorf("%s: an honest operator was accused", agg.Config.Name)
+				}
+				continue
+			}
+			if !co.Sharing.CrossBatches && agg.MeanContradictions > 0 {
+				t.Errorf("%s: contradictions without cross-customer batches", agg.Config.Name)
+			}
+			if co.Sharing.CrossBatches && co.Members > 1 && agg.CorrectDetectionRate != 1 {
+				t.Errorf("%s: a neighbour's batch minima should expose the victim, got %.3f", agg.Config.Name, agg.CorrectDetectionRate)
+			}
+		}
+	})
+
 	t.Run("AggressivePFlag", func(t *testing.T) {
 		cases := [][3]float64{
 			{0.10, 0.30, 1.0}, // p_target < tau_flag → min(1, 0.30/0.10) = 1
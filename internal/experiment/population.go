@@ -0,0 +1,367 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"satnet-simulator/internal/verification"
+)
+
+// Population draws each trial's operator from Members, in proportion to
+// their weights, and audits every trial with the same verifier. Where a
+// scenario answers "how often is this operator caught", a population answers
+// "when the verifier accuses, how often is it right": precision and the full
+// confusion matrix need trials of every kind under one verifier.
+type Population struct {
+	Name string
+
+	// Traffic and verifier, shared by every member.
+	NumTrials    int
+	NumPackets   int
+	BatchSize    int
+	SimDuration  float64
+	Adaptive     Adaptive // stop short of NumTrials once the confusion matrix is known well enough
+	Verification verification.VerificationConfig
+	Streaming    verification.StreamingConfig
+
+	Members []Member
+}
+
+// Member is one kind of operator in a population. Behaviour's traffic and
+// verifier fields are ignored in favour of the population's; its truth is
+// the one it implies, or its own Truth when set.
+type Member struct {
+	Name      string
+	Weight    float64
+	Behaviour Scenario
+}
+
+// UnmarshalJSON starts a member's behaviour from DefaultScenario, so a
+// scenario file only writes what sets the member apart.
+func (m *Member) UnmarshalJSON(b []byte) error {
+	type plain Member
+	p := plain{Behaviour: DefaultScenario()}
+	if err := decodeStrict(b, &p); err != nil {
+		return err
+	}
+	*m = Member(p)
+	return nil
+}
+
+// DefaultPopulation is a third each of honest, incompetent and malicious
+// operators, the malicious third split between the naive liar, the silent
+// dropper and the smart strategy.
+func DefaultPopulation() Population {
+	h := DefaultHonestBaseline()
+	m := DefaultMaliciousBaseline()
+	return Population{
+		Name:         "population",
+		NumTrials:    300,
+		NumPackets:   h.NumPackets,
+		BatchSize:    h.BatchSize,
+		SimDuration:  h.SimDuration,
+		Verification: h.Verification,
+		Members: []Member{
+			{"honest", 3, h.Scenario()},
+			{"incompetent", 3, DefaultIncompetentBaseline().Scenario()},
+			{"naive_liar", 1, NaiveLiarConfig(m, 0.1).Scenario()},
+			{"silent_dropper", 1, SilentDropperConfig(m, 0.1).Scenario()},
+			{"smart", 1, SmartStrategyConfig(m, 0.1).Scenario()},
+		},
+	}
+}
+
+// Validate reports a population no trial could be drawn from.
+func (p Population) Validate() error {
+	if len(p.Members) == 0 {
+		return errors.New("population has no members")
+	}
+	var total float64
+	names := make(map[string]bool)
+	for i, m := range p.Members {
+		if err := p.scenario(i).Validate(); err != nil {
+			return fmt.Errorf("member %s: %w", m.Name, err)
+		}
+		if m.Weight < 0 {
+			return fmt.Errorf("member %s has negative weight %v", m.Name, m.Weight)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("member %s appears twice", m.Name)
+		}
+		names[m.Name] = true
+		total += m.Weight
+	}
+	if total <= 0 {
+		return errors.New("population members all have zero weight")
+	}
+	return nil
+}
+
+// scenario is member i's behaviour with the population's traffic and
+// verifier. Its truth is fixed here, before the name changes.
+func (p Population) scenario(i int) Scenario {
+	m := p.Members[i]
+	sc := m.Behaviour
+	sc.Truth = sc.truth()
+	sc.Name = p.Name + "_" + m.Name
+	sc.NumTrials = p.NumTrials
+	sc.NumPackets = p.NumPackets
+	sc.BatchSize = p.BatchSize
+	sc.SimDuration = p.SimDuration
+	sc.Adaptive = p.Adaptive
+	sc.Verification = p.Verification
+	sc.Streaming = p.Streaming
+	sc.scope = "population"
+	return sc
+}
+
+// draw picks a member in proportion to the weights.
+func (p Population) draw() int {
+	var total float64
+	for _, m := range p.Members {
+		total += m.Weight
+	}
+	x := rand.Float64() * total
+	for i, m := range p.Members {
+		if x < m.Weight {
+			return i
+		}
+		x -= m.Weight
+	}
+	return len(p.Members) - 1
+}
+
+// ClassMetrics scores one class of a confusion matrix against the rest.
+type ClassMetrics struct {
+	Class       Truth
+	Support     int // trials whose truth is Class
+	Predicted   int // trials classed as Class
+	Precision   float64
+	Recall      float64
+	F1          float64
+	PrecisionCI RateCI
+	RecallCI    RateCI
+}
+
+// Confusion is a 3×3 confusion matrix over the hypotheses, rows the truth and
+// columns the verdict read as a hypothesis: TRUSTED and INCONCLUSIVE leave
+// the customer on the network and read as HONEST; an accusation, an SLA
+// breach included, reads as whichever of H1 and H2 the posterior favours.
+type Confusion struct {
+	Classes []Truth
+	Counts  [][]int
+	Rates   [][]float64 // row-normalised: P(read as column | truth is row)
+	RatesCI [][]RateCI
+
+	PerClass         []ClassMetrics
+	Accuracy         float64
+	AccuracyCI       RateCI
+	BalancedAccuracy float64 // mean recall over the classes present
+	MacroF1          float64 // mean F1 over the classes present
+}
+
+// Detection is the confusion matrix collapsed to accused or not, with every
+// dishonest operator a positive.
+type Detection struct {
+	TPR   float64 // dishonest operators accused
+	FNR   float64 // dishonest operators let through
+	TNR   float64 // honest operators let through
+	FPR   float64 // honest operators accused
+	TPRCI RateCI
+	FNRCI RateCI
+	TNRCI RateCI
+	FPRCI RateCI
+}
+
+// MemberOutcome is one row of the finer matrix: a member against every
+// verdict class.
+type MemberOutcome struct {
+	Member string
+	Truth  Truth
+	Trials int
+	VerdictRates
+}
+
+type PopulationAggregate struct {
+	Population Population
+	Trials     []TrialResult
+
+	Confusion Confusion
+	Detection Detection
+	ByMember  []MemberOutcome
+
+	Posteriors PosteriorAnalysis // every threshold at once, and calibration
+
+	TrialStats
+}
+
+// RunPopulation runs pop's trials, drawing each one's operator first, and
+// scores the verifier across all of them.
+func (r *Runner) RunPopulation(pop Population) PopulationAggregate {
+	return cachedRun(r, "population", pop.Name, pop, r.runPopulation)
+}
+
+func (r *Runner) runPopulation(pop Population) PopulationAggregate {
+	if err := pop.Validate(); err != nil {
+		fmt.Printf("warning: %s: %v\n", pop.Name, err)
+		return PopulationAggregate{Population: pop}
+	}
+	if r.Verbose {
+		fmt.Printf(">>> %s: N=%d, pkts=%d, B=%d, %d members, η=%.4f, α=%.4f\n",
+			pop.Name, pop.NumTrials, pop.NumPackets, pop.BatchSize, len(pop.Members),
+			pop.Verification.ErrorTolerance,
+			pop.Verification.ConfidenceThreshold)
+	}
+
+	trials, stopping := runAdaptive(r, pop.Name, pop.Adaptive, pop.NumTrials,
+		func(i int) TrialResult {
+			r.maybeSeedTrial("population", pop.Name, i)
+			m := pop.draw()
+			start := time.Now()
+			t := r.runTrial(pop.scenario(m), i)
+			t.Member = pop.Members[m].Name
+			t.Duration = time.Since(start)
+			return t
+		},
+		func(ts []TrialResult) float64 { return aggregatePopulation(pop, ts).widestCI() })
+	agg := aggregatePopulation(pop, trials)
+	agg.Stopping = stopping
+
+	if r.Verbose {
+		c := agg.Confusion
+		fmt.Printf("    accuracy=%s  balanced=%.1f%%  macro_F1=%.3f  TPR=%s  FPR=%s\n",
+			formatRateWithCI(c.Accuracy, c.AccuracyCI), c.BalancedAccuracy*100, c.MacroF1,
+			formatRateWithCI(agg.Detection.TPR, agg.Detection.TPRCI),
+			formatRateWithCI(agg.Detection.FPR, agg.Detection.FPRCI))
+		a := agg.Posteriors
+		fmt.Printf("    ROC_AUC=%.3f  PR_AUC=%.3f  brier=%.3f  log_score=%.3f\n",
+			a.Dishonest.ROCAUC, a.Dishonest.PRAUC, a.Brier, a.LogScore)
+	}
+	return agg
+}
+
+// readAs is the hypothesis a trial's verdict names; see Confusion.
+func readAs(t TrialResult) Truth {
+	switch t.VerdictClass {
+	case ClassCaughtIncompetent:
+		return TruthIncompetent
+	case ClassCaughtMalicious:
+		return TruthMalicious
+	case ClassSLABreached:
+		if t.PosteriorH2 > t.PosteriorH1 {
+			return TruthMalicious
+		}
+		return TruthIncompetent
+	}
+	return TruthHonest
+}
+
+func aggregatePopulation(pop Population, trials []TrialResult) PopulationAggregate {
+	agg := PopulationAggregate{
+		Population: pop,
+		Trials:     trials,
+		Confusion:  confusion(trials),
+		Detection:  detection(trials),
+		Posteriors: AnalysePosteriors(trials),
+		TrialStats: trialStats(trials, pop.Verification, false),
+	}
+	byMember := make(map[string][]TrialResult)
+	for _, t := range trials {
+		byMember[t.Member] = append(byMember[t.Member], t)
+	}
+	for i, m := range pop.Members {
+		ts := byMember[m.Name]
+		agg.ByMember = append(agg.ByMember, MemberOutcome{
+			Member:       m.Name,
+			Truth:        pop.scenario(i).Truth,
+			Trials:       len(ts),
+			VerdictRates: verdictRates(ts),
+		})
+	}
+	return agg
+}
+
+func confusion(trials []TrialResult) Confusion {
+	classes := []Truth{TruthHonest, TruthIncompetent, TruthMalicious}
+	index := func(t Truth) int {
+		for i, c := range classes {
+			if c == t {
+				return i
+			}
+		}
+		return 0
+	}
+	k := len(classes)
+	c := Confusion{Classes: classes, Counts: make([][]int, k), Rates: make([][]float64, k), RatesCI: make([][]RateCI, k)}
+	for i := range k {
+		c.Counts[i] = make([]int, k)
+	}
+	for _, t := range trials {
+		c.Counts[index(t.Truth)][index(readAs(t))]++
+	}
+
+	var correct, present int
+	var sumRecall, sumF1 float64
+	for i, class := range classes {
+		support, predicted := 0, 0
+		for j := range k {
+			support += c.Counts[i][j]
+			predicted += c.Counts[j][i]
+		}
+		c.Rates[i] = make([]float64, k)
+		c.RatesCI[i] = make([]RateCI, k)
+		for j := range k {
+			c.Rates[i][j], c.RatesCI[i][j] = rateOf(c.Counts[i][j], support)
+		}
+		tp := c.Counts[i][i]
+		correct += tp
+		m := ClassMetrics{Class: class, Support: support, Predicted: predicted}
+		m.Precision, m.PrecisionCI = rateOf(tp, predicted)
+		m.Recall, m.RecallCI = rateOf(tp, support)
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		c.PerClass = append(c.PerClass, m)
+		if support > 0 {
+			present++
+			sumRecall += m.Recall
+			sumF1 += m.F1
+		}
+	}
+	c.Accuracy, c.AccuracyCI = rateOf(correct, len(trials))
+	if present > 0 {
+		c.BalancedAccuracy = sumRecall / float64(present)
+		c.MacroF1 = sumF1 / float64(present)
+	}
+	return c
+}
+
+func detection(trials []TrialResult) Detection {
+	var pos, neg, tp, fp int
+	for _, t := range trials {
+		accused := readAs(t) != TruthHonest
+		if t.Truth == TruthHonest {
+			neg++
+			if accused {
+				fp++
+			}
+			continue
+		}
+		pos++
+		if accused {
+			tp++
+		}
+	}
+	var d Detection
+	d.TPR, d.TPRCI = rateOf(tp, pos)
+	d.FNR, d.FNRCI = rateOf(pos-tp, pos)
+	d.TNR, d.TNRCI = rateOf(neg-fp, neg)
+	d.FPR, d.FPRCI = rateOf(fp, neg)
+	return d
+}
+
+func (r *Runner) SavePopulationAggregates(path string, results []PopulationAggregate) error {
+	return r.saveResults(path, results)
+}
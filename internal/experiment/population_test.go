@@ -0,0 +1,113 @@
+package experiment
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConfusion(t *testing.T) {
+	trial := func(truth Truth, class string, h1, h2 float64) TrialResult {
+		return TrialResult{Truth: truth, VerdictClass: class, PosteriorH1: h1, PosteriorH2: h2}
+	}
+	trials := []TrialResult{
+		trial(TruthHonest, ClassTrusted, 0, 0),
+		trial(TruthHonest, ClassInconclusive, 0, 0),
+		trial(TruthHonest, ClassCaughtIncompetent, 0.9, 0.1),
+		trial(TruthIncompetent, ClassCaughtIncompetent, 0.9, 0.1),
+		trial(TruthIncompetent, ClassSLABreached, 0.7, 0.3),
+		trial(TruthIncompetent, ClassTrusted, 0, 0),
+		trial(TruthMalicious, ClassCaughtMalicious, 0.1, 0.9),
+		trial(TruthMalicious, ClassSLABreached, 0.2, 0.8),
+		trial(TruthMalicious, ClassCaughtIncompetent, 0.6, 0.4),
+		trial(TruthMalicious, ClassTrusted, 0, 0),
+	}
+	c := confusion(trials)
+	want := [][]int{{2, 1, 0}, {1, 2, 0}, {1, 1, 2}}
+	for i := range want {
+		for j := range want[i] {
+			if c.Counts[i][j] != want[i][j] {
+				t.Fatalf("counts %v, want %v", c.Counts, want)
+			}
+		}
+	}
+	close := func(what string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", what, got, want)
+		}
+	}
+	close("accuracy", c.Accuracy, 0.6)
+	close("honest precision", c.PerClass[0].Precision, 0.5)
+	close("incompetent precision", c.PerClass[1].Precision, 0.5)
+	close("malicious precision", c.PerClass[2].Precision, 1)
+	close("malicious recall", c.PerClass[2].Recall, 0.5)
+	close("malicious F1", c.PerClass[2].F1, 2.0/3)
+	close("balanced accuracy", c.BalancedAccuracy, (2.0/3+2.0/3+0.5)/3)
+	close("honest row", c.Rates[0][0]+c.Rates[0][1]+c.Rates[0][2], 1)
+	if ci := c.PerClass[2].RecallCI; ci.Lower > 0.5 || ci.Upper < 0.5 {
+		t.Errorf("malicious recall CI %+v excludes the estimate", ci)
+	}
+
+	d := detection(trials)
+	close("TPR", d.TPR, 5.0/7)
+	close("FNR", d.FNR, 2.0/7)
+	close("TNR", d.TNR, 2.0/3)
+	close("FPR", d.FPR, 1.0/3)
+}
+
+func TestPopulationValidate(t *testing.T) {
+	if err := DefaultPopulation().Validate(); err != nil {
+		t.Fatalf("default population: %v", err)
+	}
+	for name, members := range map[string][]Member{
+		"Empty":     nil,
+		"ZeroTotal": {{Name: "a"}, {Name: "b"}},
+		"Negative":  {{Name: "a", Weight: 2}, {Name: "b", Weight: -1}},
+		"Duplicate": {{Name: "a", Weight: 1}, {Name: "a", Weight: 1}},
+	} {
+		p := DefaultPopulation()
+		p.Members = members
+		if p.Validate() == nil {
+			t.Errorf("%s: population accepted", name)
+		}
+	}
+}
+
+func TestRunPopulation(t *testing.T) {
+	p := DefaultPopulation()
+	p.NumTrials, p.NumPackets, p.SimDuration = 40, 200, 50
+	p.Members[1].Weight = 0 // no incompetent operators
+
+	r := NewRunner()
+	r.Verbose = false
+	r.SetBaseSeed(7)
+	agg := r.RunPopulation(p)
+	if len(agg.Trials) != p.NumTrials {
+		t.Fatalf("%d trials, want %d", len(agg.Trials), p.NumTrials)
+	}
+	truths := make(map[string]Truth)
+	for _, m := range agg.ByMember {
+		truths[m.Member] = m.Truth
+	}
+	total := 0
+	for i, row := range agg.Confusion.Counts {
+		for _, n := range row {
+			total += n
+		}
+		if agg.Confusion.Classes[i] == TruthIncompetent && agg.Confusion.PerClass[i].Support != 0 {
+			t.Errorf("%d incompetent trials from a zero-weight member", agg.Confusion.PerClass[i].Support)
+		}
+	}
+	if total != p.NumTrials {
+		t.Errorf("confusion matrix holds %d trials, want %d", total, p.NumTrials)
+	}
+	for _, tr := range agg.Trials {
+		if tr.Member == "" || tr.Truth != truths[tr.Member] {
+			t.Errorf("trial %d: member %q with truth %s", tr.TrialNum, tr.Member, tr.Truth)
+		}
+	}
+	if truths["naive_liar"] != TruthMalicious || truths["honest"] != TruthHonest {
+		t.Errorf("member truths %v", truths)
+	}
+
+}
@@ -0,0 +1,63 @@
+package experiment
+
+import (
+	"math"
+	"math/rand"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// prober injects the verifier's probe packets into a trial's traffic. The
+// zero value injects nothing.
+type prober struct {
+	log      *verification.ProbeLog
+	perBatch int
+}
+
+// newProber rounds ProbeRate to a whole number of probes per batch, at least
+// one, so every batch has the same size and the streaming verifier knows when
+// one is full.
+func newProber(cfg verification.VerificationConfig, batchSize int) prober {
+	if cfg.ProbeRate <= 0 {
+		return prober{}
+	}
+	return prober{
+		log:      verification.NewProbeLog(nil),
+		perBatch: max(1, int(math.Round(cfg.ProbeRate*float64(batchSize)))),
+	}
+}
+
+// wrap puts the probe log in front of dest so it sees every arrival first.
+func (pr prober) wrap(dest network.Destination) network.Destination {
+	if pr.log == nil {
+		return dest
+	}
+	pr.log.Next = dest
+	return pr.log
+}
+
+// inject sends this batch's probes alongside the customer's packets, numbered
+// on from *nextID so the router cannot tell them apart by ID.
+func (pr prober) inject(sim *engine.Simulation, router *network.Router, dest network.Destination, batchID int, sendTime float64, nextID *int) {
+	for range pr.perBatch {
+		id := *nextID
+		*nextID++
+		sim.Schedule(sendTime, func() {
+			pr.log.Sent(id, sim.Now)
+			router.Forward(sim, network.NewPacket(id, batchID, "Source", sim.Now), dest)
+		})
+	}
+}
+
+// spotter lets the adversary recognise each probe with probability
+// distinguishability.
+func (pr prober) spotter(distinguishability float64) func(network.Packet) bool {
+	if pr.log == nil || distinguishability <= 0 {
+		return nil
+	}
+	return func(pkt network.Packet) bool {
+		return pr.log.IsProbe(pkt.ID) && rand.Float64() < distinguishability
+	}
+}
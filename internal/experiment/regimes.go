@@ -0,0 +1,130 @@
+package experiment
+
+import (
+	"fmt"
+	"math/rand"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// Regime is one stretch of an operator's behaviour in a trial whose
+// behaviour changes. It takes over at Start simulated seconds, or at a time
+// drawn uniformly from [Start, StartMax) when StartMax > Start. The trial's
+// own behaviour fields are the regime before the first switch.
+type Regime struct {
+	Start    float64
+	StartMax float64
+
+	Targeting         network.TargetingConfig
+	PFlag             float64
+	POverFlag         float64
+	PLie              float64
+	AnsweringStrategy verification.AnsweringStrategy
+}
+
+// RegimeOf takes the behaviour of cfg, as built by one of the named-strategy
+// constructors, to switch to at start.
+func RegimeOf(cfg MaliciousBaselineConfig, start, startMax float64) Regime {
+	return Regime{
+		Start:             start,
+		StartMax:          startMax,
+		Targeting:         cfg.Targeting,
+		PFlag:             cfg.PFlag,
+		POverFlag:         cfg.POverFlag,
+		PLie:              cfg.PLie,
+		AnsweringStrategy: cfg.AnsweringStrategy,
+	}
+}
+
+func (reg Regime) startTime() float64 {
+	if reg.StartMax > reg.Start {
+		return reg.Start + rand.Float64()*(reg.StartMax-reg.Start)
+	}
+	return reg.Start
+}
+
+// scheduleRegimes arms every switch and returns when the earliest one
+// happens, or 0 when there are none. Switches take effect in time order,
+// whatever their order in regimes. Congestion stays flagged as base flags it.
+func scheduleRegimes(sim *engine.Simulation, router *network.Router, prover *verification.Prover, base Flagging, regimes []Regime) float64 {
+	first := 0.0
+	for i, reg := range regimes {
+		at := reg.startTime()
+		if i == 0 || at < first {
+			first = at
+		}
+		sim.Schedule(at, func() {
+			router.TargetingCfg = reg.Targeting
+			router.Flagging = Flagging{Reliability: base.Reliability, PFlag: reg.PFlag, POverFlag: reg.POverFlag}.fn()
+			prover.Config.AnsweringStr = reg.AnsweringStrategy
+			prover.Config.LieRate = reg.PLie
+		})
+	}
+	return first
+}
+
+// HonestThenConfig returns an operator that behaves honestly until a switch
+// drawn from [start, startMax) and then like after. It is audited by a
+// continuous streaming verifier, which only stops at an accusation.
+func HonestThenConfig(base, after MaliciousBaselineConfig, start, startMax float64) MaliciousBaselineConfig {
+	cfg := base
+	cfg.Targeting = network.DefaultHonestTargeting()
+	cfg.PFlag = 0.0
+	cfg.POverFlag = 0.0
+	cfg.PLie = 0.0
+	cfg.AnsweringStrategy = verification.AnswerHonest
+	cfg.Regimes = []Regime{RegimeOf(after, start, startMax)}
+	if !cfg.Streaming.Enabled {
+		cfg.Streaming = verification.DefaultStreamingConfig()
+	}
+	cfg.Streaming.Continuous = true
+	return cfg
+}
+
+// regimeStats splits the accusations at the first switch: those before it
+// are false alarms, and those after it are detections, timed from the switch.
+func (s *TrialStats) regimeStats(trials []TrialResult) {
+	var falseAlarms int
+	var delays []float64
+	for _, t := range trials {
+		if !accuses(t.VerdictClass) {
+			continue
+		}
+		if t.DecisionTime < t.SwitchTime {
+			falseAlarms++
+		} else {
+			delays = append(delays, t.DecisionTime-t.SwitchTime)
+		}
+	}
+	n := float64(len(trials))
+	s.FalseAlarmRate = float64(falseAlarms) / n
+	s.DetectedAfterSwitchRate = float64(len(delays)) / n
+	s.MeanDetectionDelay, s.MedianDetectionDelay, s.P90DetectionDelay = timeToVerdictStats(delays)
+}
+
+// SweepRegimeForgetting runs an operator that turns into after at a switch
+// drawn from [start, startMax), audited with no forgetting, then with each
+// forgetting share, then with each window.
+func (r *Runner) SweepRegimeForgetting(base, after MaliciousBaselineConfig, start, startMax float64, forgettings []float64, windows []int) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: regime-switch forgetting sweep (%d values) [%s] ===\n", 1+len(forgettings)+len(windows), base.Name)
+	return runAll(r.RunMalicious, regimeForgettingConfigs(HonestThenConfig(base, after, start, startMax), forgettings, windows))
+}
+
+func regimeForgettingConfigs(sw MaliciousBaselineConfig, forgettings []float64, windows []int) []MaliciousBaselineConfig {
+	forget := Axis{Field: "Verification.Forgetting", Values: []float64{sw.Verification.Forgetting}, Labels: []string{"noforget"}}
+	window := Axis{Field: "Verification.Window", Values: []float64{float64(sw.Verification.Window)}, Labels: unnamed(1)}
+	for _, f := range forgettings {
+		forget.Values = append(forget.Values, f)
+		forget.Labels = append(forget.Labels, fmt.Sprintf("forget%.4f", f))
+		window.Values = append(window.Values, float64(sw.Verification.Window))
+	}
+	for _, w := range windows {
+		forget.Values = append(forget.Values, sw.Verification.Forgetting)
+		forget.Labels = append(forget.Labels, fmt.Sprintf("window%d", w))
+		window.Values = append(window.Values, float64(w))
+	}
+	window.Labels = unnamed(len(window.Values))
+	return mustGridConfigs(sw, Grid{{forget, window}}, nil)
+}
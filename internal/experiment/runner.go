@@ -8,11 +8,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
-	"sort"
-	"strings"
-	"time"
 
-	"satnet-simulator/internal/engine"
 	"satnet-simulator/internal/network"
 	"satnet-simulator/internal/verification"
 )
@@ -27,8 +23,13 @@ type HonestBaselineConfig struct {
 	NumPackets   int
 	BatchSize    int
 	SimDuration  float64
+	Adaptive     Adaptive // stop short of NumTrials once the rates are known well enough
 	DelayModel   network.DelayModelConfig
 	Verification verification.VerificationConfig
+	Streaming    verification.StreamingConfig // audit during the run instead of after it
+
+	DeclareSchedule bool     // fill Verification.DeclaredBaseDelay from each trial's delay model
+	Timeline        Timeline // parameter changes during each trial
 }
 
 func DefaultHonestBaseline() HonestBaselineConfig {
@@ -52,23 +53,9 @@ func DefaultHonestBaseline() HonestBaselineConfig {
 	}
 }
 
-// repeated struct? check verifier
-type HonestTrialResult struct {
-	TrialNum int
-	// use verification result struct here instead?
-	Verdict             string
-	Confidence          float64
-	QueriesUsed         int
-	ContradictionsFound int
-	PosteriorH0         float64
-	PosteriorH1         float64
-	PosteriorH2         float64
-	Duration            time.Duration
-}
-
 type HonestAggregate struct {
 	Config HonestBaselineConfig
-	Trials []HonestTrialResult
+	Trials []TrialResult
 
 	TrustedRate          float64
 	InconclusiveRate     float64
@@ -77,19 +64,47 @@ type HonestAggregate struct {
 	InconclusiveRateCI   RateCI
 	FalseDishonestRateCI RateCI
 
-	MeanQueriesToVerdict   float64
-	MedianQueriesToVerdict int
-	P90QueriesToVerdict    int
-	MinQueriesToVerdict    int
-	MaxQueriesToVerdict    int
+	TrialStats
+}
+
+// Scenario is cfg as a composed scenario: no targeting, no flagging and
+// honest answers. Its trials keep the seeds they had before scenarios.
+func (cfg HonestBaselineConfig) Scenario() Scenario {
+	return Scenario{
+		Name:            cfg.Name,
+		NumTrials:       cfg.NumTrials,
+		NumPackets:      cfg.NumPackets,
+		BatchSize:       cfg.BatchSize,
+		SimDuration:     cfg.SimDuration,
+		Adaptive:        cfg.Adaptive,
+		DelayModel:      cfg.DelayModel,
+		Targeting:       network.DefaultHonestTargeting(),
+		Answering:       Answering{Strategy: verification.AnswerHonest},
+		Verification:    cfg.Verification,
+		Streaming:       cfg.Streaming,
+		DeclareSchedule: cfg.DeclareSchedule,
+		Timeline:        cfg.Timeline,
+		scope:           "honest",
+	}
+}
 
-	MeanPosteriorH0 float64
-	MeanPosteriorH1 float64
-	MeanPosteriorH2 float64
+// Validate reports a config whose scenario would not run; see Scenario.Validate.
+func (cfg HonestBaselineConfig) Validate() error {
+	return cfg.Scenario().Validate()
+}
 
-	MeanContradictions  float64
-	ContradictionRate   float64
-	ContradictionRateCI RateCI
+func honestAggregate(cfg HonestBaselineConfig, agg Aggregate) HonestAggregate {
+	return HonestAggregate{
+		Config:               cfg,
+		Trials:               agg.Trials,
+		TrustedRate:          agg.TrustedRate,
+		InconclusiveRate:     agg.InconclusiveRate,
+		FalseDishonestRate:   agg.AccusedRate,
+		TrustedRateCI:        agg.TrustedRateCI,
+		InconclusiveRateCI:   agg.InconclusiveRateCI,
+		FalseDishonestRateCI: agg.AccusedRateCI,
+		TrialStats:           agg.TrialStats,
+	}
 }
 
 // RateCI stores a two-sided 95% confidence interval for a Bernoulli rate.
@@ -100,7 +115,10 @@ type RateCI struct {
 
 type Runner struct {
 	Verbose              bool
+	Store                *ResultStore // when set, finished runs are kept here and not rerun
 	Results              []HonestAggregate
+	AuditDir             string // when set, every trial writes a signed, hash-chained audit log here
+	Format               string // json, csv or jsonl for every saved result; empty picks by the path's extension
 	baseSeed             int64
 	deterministicSeeding bool
 }
@@ -161,6 +179,12 @@ func (r *Runner) maybeSeedTrial(scope, cfgName string, trialNum int) {
 
 // RunHonest runs N trials under the honest baseline config and aggregates.
 func (r *Runner) RunHonest(cfg HonestBaselineConfig) HonestAggregate {
+	agg := cachedRun(r, "honest", cfg.Name, cfg, r.runHonest)
+	r.Results = append(r.Results, agg)
+	return agg
+}
+
+func (r *Runner) runHonest(cfg HonestBaselineConfig) HonestAggregate {
 	if r.Verbose {
 		fmt.Printf(">>> %s: N=%d, packets=%d, B=%d, η=%.4f, α=%.4f, ε=%.4f\n",
 			cfg.Name, cfg.NumTrials, cfg.NumPackets, cfg.BatchSize,
@@ -169,16 +193,7 @@ func (r *Runner) RunHonest(cfg HonestBaselineConfig) HonestAggregate {
 			cfg.Verification.Epsilon)
 	}
 
-	trials := make([]HonestTrialResult, cfg.NumTrials)
-	for i := 0; i < cfg.NumTrials; i++ {
-		r.maybeSeedTrial("honest", cfg.Name, i)
-		start := time.Now()
-		trials[i] = r.runSingleHonestTrial(cfg, i)
-		trials[i].Duration = time.Since(start)
-	}
-
-	agg := aggregateHonest(cfg, trials)
-	r.Results = append(r.Results, agg)
+	agg := honestAggregate(cfg, r.runTrials(cfg.Scenario()))
 
 	if r.Verbose {
 		fmt.Printf("    trusted=%s  inconclusive=%s  false_dishonest=%s  median_q=%d  p90_q=%d\n",
@@ -195,211 +210,47 @@ func (r *Runner) RunHonest(cfg HonestBaselineConfig) HonestAggregate {
 // SweepHonestEta varies η (ErrorTolerance) under the honest baseline.
 func (r *Runner) SweepHonestEta(base HonestBaselineConfig, etas []float64) []HonestAggregate {
 	fmt.Printf("\n=== Honest baseline: η sweep (%d values) ===\n", len(etas))
-	out := make([]HonestAggregate, 0, len(etas))
-	for _, eta := range etas {
-		cfg := base
-		cfg.Verification.ErrorTolerance = eta
-		cfg.Name = fmt.Sprintf("%s_eta%.4f", base.Name, eta)
-		out = append(out, r.RunHonest(cfg))
-	}
-	return out
+	return sweepField(r.RunHonest, base, "Verification.ErrorTolerance", "eta", "%.4f", etas)
 }
 
 // SweepHonestAlpha varies α (ConfidenceThreshold) under the honest baseline.
 func (r *Runner) SweepHonestAlpha(base HonestBaselineConfig, alphas []float64) []HonestAggregate {
 	fmt.Printf("\n=== Honest baseline: α sweep (%d values) ===\n", len(alphas))
-	out := make([]HonestAggregate, 0, len(alphas))
-	for _, a := range alphas {
-		cfg := base
-		cfg.Verification.ConfidenceThreshold = a
-		cfg.Name = fmt.Sprintf("%s_alpha%.4f", base.Name, a)
-		out = append(out, r.RunHonest(cfg))
-	}
-	return out
+	return sweepField(r.RunHonest, base, "Verification.ConfidenceThreshold", "alpha", "%.4f", alphas)
 }
 
 // SweepHonestBatch varies batch size B.
 func (r *Runner) SweepHonestBatch(base HonestBaselineConfig, batches []int) []HonestAggregate {
 	fmt.Printf("\n=== Honest baseline: batch-size sweep (%d values) ===\n", len(batches))
-	out := make([]HonestAggregate, 0, len(batches))
-	for _, b := range batches {
-		cfg := base
-		cfg.BatchSize = b
-		cfg.Name = fmt.Sprintf("%s_batch%d", base.Name, b)
-		out = append(out, r.RunHonest(cfg))
-	}
-	return out
+	return sweepField(r.RunHonest, base, "BatchSize", "batch", "%d", floats(batches))
 }
 
 // SweepHonestNumPackets varies trial length (total packets).
 func (r *Runner) SweepHonestNumPackets(base HonestBaselineConfig, ns []int) []HonestAggregate {
 	fmt.Printf("\n=== Honest baseline: trial-length sweep (%d values) ===\n", len(ns))
-	out := make([]HonestAggregate, 0, len(ns))
-	for _, n := range ns {
-		cfg := base
-		cfg.NumPackets = n
-		cfg.Name = fmt.Sprintf("%s_pkts%d", base.Name, n)
-		out = append(out, r.RunHonest(cfg))
-	}
-	return out
+	return sweepField(r.RunHonest, base, "NumPackets", "pkts", "%d", floats(ns))
 }
 
 // SweepHonestTransitionRate varies λ (Poisson rate of base-delay transitions).
 // Honest networks should be invariant to λ; this is a sanity check.
 func (r *Runner) SweepHonestTransitionRate(base HonestBaselineConfig, rates []float64) []HonestAggregate {
 	fmt.Printf("\n=== Honest baseline: λ sweep (%d values) ===\n", len(rates))
-	out := make([]HonestAggregate, 0, len(rates))
-	for _, rate := range rates {
-		cfg := base
-		cfg.DelayModel.TransitionRate = rate
-		cfg.Name = fmt.Sprintf("%s_lambda%.3f", base.Name, rate)
-		out = append(out, r.RunHonest(cfg))
-	}
-	return out
+	return sweepField(r.RunHonest, base, "DelayModel.TransitionRate", "lambda", "%.3f", rates)
 }
 
 // SweepHonestEpsilon varies ε (implementation-noise floor). Honest results
 // should shift only marginally with ε; this checks the numerical floor.
 func (r *Runner) SweepHonestEpsilon(base HonestBaselineConfig, epsilons []float64) []HonestAggregate {
 	fmt.Printf("\n=== Honest baseline: ε sweep (%d values) ===\n", len(epsilons))
-	out := make([]HonestAggregate, 0, len(epsilons))
-	for _, e := range epsilons {
-		cfg := base
-		cfg.Verification.Epsilon = e
-		cfg.Name = fmt.Sprintf("%s_eps%.0e", base.Name, e)
-		out = append(out, r.RunHonest(cfg))
-	}
-	return out
-}
-
-type honestDest struct{ Received int }
-
-func (h *honestDest) Receive(sim *engine.Simulation, pkt network.Packet, pathUsed string) {
-	h.Received++
-}
-
-func (r *Runner) runSingleHonestTrial(cfg HonestBaselineConfig, trialNum int) HonestTrialResult {
-	sim := engine.NewSimulation()
-
-	dm := network.NewDelayModelConfig(cfg.DelayModel)
-	dm.Initialise(cfg.SimDuration + 10.0)
-
-	prover := verification.NewProver(verification.AdversaryConfig{
-		AnsweringStr: verification.AnswerHonest,
-	})
-
-	router := network.NewRouter(
-		dm,
-		network.DefaultHonestTargeting(),
-		func(hasIncompetence, wasDelayed bool) bool { return false },
-	)
-
-	router.OnTransmission = func(pkt network.Packet) {
-		prover.RecordTransmission(pkt)
-	}
-
-	dest := &honestDest{}
-
-	batchSize := max(2, cfg.BatchSize)
-	numBatches := max(1, cfg.NumPackets/batchSize)
-
-	pktID := 0
-	for b := range numBatches {
-		sendTime := float64(b) * (cfg.SimDuration / float64(numBatches))
-		batchID := b
-		for j := 0; j < batchSize; j++ {
-			id := pktID
-			pktID++
-			sim.Schedule(sendTime, func() {
-				pkt := network.NewPacket(id, batchID, "Source", sim.Now)
-				router.Forward(sim, pkt, dest)
-			})
-		}
-	}
-	sim.Run(cfg.SimDuration + 10.0)
-
-	verifier := verification.NewVerifier(prover, cfg.Verification)
-	verifier.IngestPackets(prover.Packets)
-	res := verifier.RunVerification()
-
-	return HonestTrialResult{
-		TrialNum:            trialNum,
-		Verdict:             res.Verdict,
-		Confidence:          res.Confidence,
-		QueriesUsed:         res.TotalQueries,
-		ContradictionsFound: res.ContradictionsFound,
-		PosteriorH0:         res.PosteriorH0,
-		PosteriorH1:         res.PosteriorH1,
-		PosteriorH2:         res.PosteriorH2,
-	}
+	return sweepField(r.RunHonest, base, "Verification.Epsilon", "eps", "%.0e", epsilons)
 }
 
-func aggregateHonest(cfg HonestBaselineConfig, trials []HonestTrialResult) HonestAggregate {
-	n := len(trials)
-	agg := HonestAggregate{Config: cfg, Trials: trials}
-	if n == 0 {
-		return agg
-	}
-
-	var trusted, inconclusive, dishonest int
-	var sumH0, sumH1, sumH2 float64
-	var totalContradictions int
-	var withContradictions int
-	queriesToVerdict := make([]int, 0, n)
-
-	for _, t := range trials {
-		switch t.Verdict {
-		case "TRUSTED":
-			trusted++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "INCONCLUSIVE", "INSUFFICIENT_DATA":
-			inconclusive++
-		default:
-			dishonest++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		}
-		sumH0 += t.PosteriorH0
-		sumH1 += t.PosteriorH1
-		sumH2 += t.PosteriorH2
-		totalContradictions += t.ContradictionsFound
-		if t.ContradictionsFound > 0 {
-			withContradictions++
-		}
-	}
-
-	agg.TrustedRate = float64(trusted) / float64(n)
-	agg.InconclusiveRate = float64(inconclusive) / float64(n)
-	agg.FalseDishonestRate = float64(dishonest) / float64(n)
-	agg.TrustedRateCI = wilsonRateCI(trusted, n)
-	agg.InconclusiveRateCI = wilsonRateCI(inconclusive, n)
-	agg.FalseDishonestRateCI = wilsonRateCI(dishonest, n)
-	agg.MeanPosteriorH0 = sumH0 / float64(n)
-	agg.MeanPosteriorH1 = sumH1 / float64(n)
-	agg.MeanPosteriorH2 = sumH2 / float64(n)
-	agg.MeanContradictions = float64(totalContradictions) / float64(n)
-	agg.ContradictionRate = float64(withContradictions) / float64(n)
-	agg.ContradictionRateCI = wilsonRateCI(withContradictions, n)
-
-	if len(queriesToVerdict) > 0 {
-		sort.Ints(queriesToVerdict)
-		var sum int
-		for _, q := range queriesToVerdict {
-			sum += q
-		}
-		agg.MeanQueriesToVerdict = float64(sum) / float64(len(queriesToVerdict))
-		agg.MedianQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)/2]
-		p90 := (len(queriesToVerdict) * 90) / 100
-		if p90 >= len(queriesToVerdict) {
-			p90 = len(queriesToVerdict) - 1
-		}
-		agg.P90QueriesToVerdict = queriesToVerdict[p90]
-		agg.MinQueriesToVerdict = queriesToVerdict[0]
-		agg.MaxQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)-1]
-	}
-	return agg
+func (r *Runner) SaveAggregates(path string, results []HonestAggregate) error {
+	return r.saveResults(path, results)
 }
 
-func (r *Runner) SaveAggregates(path string, results []HonestAggregate) error {
+// saveJSON writes v to path as indented JSON, creating its directory.
+func (r *Runner) saveJSON(path string, v any) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
@@ -410,7 +261,7 @@ func (r *Runner) SaveAggregates(path string, results []HonestAggregate) error {
 	defer f.Close()
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
-	if err := enc.Encode(results); err != nil {
+	if err := enc.Encode(v); err != nil {
 		return err
 	}
 	if r.Verbose {
@@ -429,7 +280,7 @@ func (r *Runner) PrintSummary() {
 			agg.Config.Verification.ErrorTolerance,
 			agg.Config.Verification.ConfidenceThreshold,
 			agg.Config.Verification.Epsilon,
-			agg.Config.BatchSize, agg.Config.NumPackets, agg.Config.NumTrials)
+			agg.Config.BatchSize, agg.Config.NumPackets, agg.TrialsRun)
 		fmt.Printf("  trusted=%s  inconclusive=%s  false_dishonest=%s\n",
 			formatRateWithCI(agg.TrustedRate, agg.TrustedRateCI),
 			formatRateWithCI(agg.InconclusiveRate, agg.InconclusiveRateCI),
@@ -459,11 +310,16 @@ type IncompetentBaselineConfig struct {
 	NumPackets        int
 	BatchSize         int
 	SimDuration       float64
+	Adaptive          Adaptive // stop short of NumTrials once the rates are known well enough
 	DelayModel        network.DelayModelConfig
 	FlagReliability   float64 // P(flag is set | packet experienced congestion)
 	AnsweringStrategy verification.AnsweringStrategy
 	AnswerErrorRate   float64 // only used when AnsweringStrategy == AnswerUnreliable
 	Verification      verification.VerificationConfig
+	Streaming         verification.StreamingConfig // audit during the run instead of after it
+
+	DeclareSchedule bool     // fill Verification.DeclaredBaseDelay from each trial's delay model
+	Timeline        Timeline // parameter changes during each trial
 }
 
 func DefaultIncompetentBaseline() IncompetentBaselineConfig {
@@ -490,53 +346,53 @@ func DefaultIncompetentBaseline() IncompetentBaselineConfig {
 	}
 }
 
-type IncompetentTrialResult struct {
-	TrialNum            int
-	Verdict             string
-	VerdictClass        string // TRUSTED, CAUGHT_INCOMPETENT, CAUGHT_MALICIOUS, SLA_BREACHED, INCONCLUSIVE
-	Confidence          float64
-	QueriesUsed         int
-	ContradictionsFound int
-	PosteriorH0         float64
-	PosteriorH1         float64
-	PosteriorH2         float64
-	Duration            time.Duration
-}
-
 type IncompetentAggregate struct {
 	Config IncompetentBaselineConfig
-	Trials []IncompetentTrialResult
-
-	TrustedRate             float64 // false negative: incompetence not caught
-	CaughtIncompetentRate   float64 // H1 posterior wins
-	CaughtMaliciousRate     float64 // H2 posterior wins (misclassification)
-	SLABreachedRate         float64 // flagging-rate threshold crossed
-	InconclusiveRate        float64
-	CorrectDetectionRate    float64 // CaughtIncompetent + CaughtMalicious + SLABreached
-	TrustedRateCI           RateCI
-	CaughtIncompetentRateCI RateCI
-	CaughtMaliciousRateCI   RateCI
-	SLABreachedRateCI       RateCI
-	InconclusiveRateCI      RateCI
-	CorrectDetectionRateCI  RateCI
-
-	MeanQueriesToVerdict   float64
-	MedianQueriesToVerdict int
-	P90QueriesToVerdict    int
-	MinQueriesToVerdict    int
-	MaxQueriesToVerdict    int
-
-	MeanPosteriorH0 float64
-	MeanPosteriorH1 float64
-	MeanPosteriorH2 float64
-
-	MeanContradictions float64
+	Trials []TrialResult
+
+	VerdictRates
+	CorrectDetectionRate   float64 // CaughtIncompetent + CaughtMalicious + SLABreached
+	CorrectDetectionRateCI RateCI
+
+	TrialStats
+}
+
+// Scenario is cfg as a composed scenario: congestion flagged with
+// FlagReliability and no targeting. Its trials keep the seeds they had
+// before scenarios.
+func (cfg IncompetentBaselineConfig) Scenario() Scenario {
+	return Scenario{
+		Name:            cfg.Name,
+		NumTrials:       cfg.NumTrials,
+		NumPackets:      cfg.NumPackets,
+		BatchSize:       cfg.BatchSize,
+		SimDuration:     cfg.SimDuration,
+		Adaptive:        cfg.Adaptive,
+		DelayModel:      cfg.DelayModel,
+		Targeting:       network.DefaultHonestTargeting(), // incompetence fires via DelayModel.IncompetenceRate
+		Flagging:        Flagging{Reliability: cfg.FlagReliability},
+		Answering:       Answering{Strategy: cfg.AnsweringStrategy, ErrorRate: cfg.AnswerErrorRate},
+		Verification:    cfg.Verification,
+		Streaming:       cfg.Streaming,
+		DeclareSchedule: cfg.DeclareSchedule,
+		Timeline:        cfg.Timeline,
+		scope:           "incompetent",
+	}
+}
+
+// Validate reports a config whose scenario would not run; see Scenario.Validate.
+func (cfg IncompetentBaselineConfig) Validate() error {
+	return cfg.Scenario().Validate()
 }
 
 // ResultsIncompetent is kept separate from r.Results so the honest PrintSummary
 // does not have to discriminate on type; callers retrieve via the returned
 // slice of aggregates.
 func (r *Runner) RunIncompetent(cfg IncompetentBaselineConfig) IncompetentAggregate {
+	return cachedRun(r, "incompetent", cfg.Name, cfg, r.runIncompetent)
+}
+
+func (r *Runner) runIncompetent(cfg IncompetentBaselineConfig) IncompetentAggregate {
 	if r.Verbose {
 		fmt.Printf(">>> %s: N=%d, pkts=%d, B=%d, p_incomp=%.4f, flag_rel=%.3f, ans=%s, ans_err=%.3f, η=%.4f, α=%.4f\n",
 			cfg.Name, cfg.NumTrials, cfg.NumPackets, cfg.BatchSize,
@@ -546,14 +402,15 @@ func (r *Runner) RunIncompetent(cfg IncompetentBaselineConfig) IncompetentAggreg
 			cfg.Verification.ConfidenceThreshold)
 	}
 
-	trials := make([]IncompetentTrialResult, cfg.NumTrials)
-	for i := 0; i < cfg.NumTrials; i++ {
-		r.maybeSeedTrial("incompetent", cfg.Name, i)
-		start := time.Now()
-		trials[i] = r.runSingleIncompetentTrial(cfg, i)
-		trials[i].Duration = time.Since(start)
+	a := r.runTrials(cfg.Scenario())
+	agg := IncompetentAggregate{
+		Config:                 cfg,
+		Trials:                 a.Trials,
+		VerdictRates:           a.VerdictRates,
+		CorrectDetectionRate:   a.AccusedRate,
+		CorrectDetectionRateCI: a.AccusedRateCI,
+		TrialStats:             a.TrialStats,
 	}
-	agg := aggregateIncompetent(cfg, trials)
 
 	if r.Verbose {
 		fmt.Printf("    trusted=%s  H1=%s  H2=%s  SLA=%s  inconclusive=%s  median_q=%d\n",
@@ -567,184 +424,6 @@ func (r *Runner) RunIncompetent(cfg IncompetentBaselineConfig) IncompetentAggreg
 	return agg
 }
 
-// incompetentFlagging returns a flagging function that sets the flag on a
-// congested packet with probability `reliability`. A value of 1.0 is a
-// perfectly-flagging network; 0.0 is the classical incompetent SNP that
-// never flags its own congestion events.
-func incompetentFlagging(reliability float64) network.FlaggingFn {
-	return func(hasIncompetence, wasDelayed bool) bool {
-		if hasIncompetence {
-			return rand.Float64() < reliability
-		}
-		return false
-	}
-}
-
-// adversarialFlagging flags a deliberately-delayed packet with probability pFlag.
-// Untargeted packets are never flagged.
-func adversarialFlagging(pFlag float64) network.FlaggingFn {
-	return func(hasIncompetence, wasDelayed bool) bool {
-		if !wasDelayed {
-			return false
-		}
-		return rand.Float64() < pFlag
-	}
-}
-
-func (r *Runner) runSingleIncompetentTrial(cfg IncompetentBaselineConfig, trialNum int) IncompetentTrialResult {
-	sim := engine.NewSimulation()
-
-	dm := network.NewDelayModelConfig(cfg.DelayModel)
-	dm.Initialise(cfg.SimDuration + 10.0)
-
-	prover := verification.NewProver(verification.AdversaryConfig{
-		AnsweringStr:    cfg.AnsweringStrategy,
-		AnswerErrorRate: cfg.AnswerErrorRate,
-	})
-
-	router := network.NewRouter(
-		dm,
-		network.DefaultHonestTargeting(), // TargetNone; incompetence fires via DelayModel.IncompetenceRate
-		incompetentFlagging(cfg.FlagReliability),
-	)
-	router.OnTransmission = func(pkt network.Packet) {
-		prover.RecordTransmission(pkt)
-	}
-
-	dest := &honestDest{}
-
-	batchSize := cfg.BatchSize
-	if batchSize < 2 {
-		batchSize = 2
-	}
-	numBatches := cfg.NumPackets / batchSize
-	if numBatches < 1 {
-		numBatches = 1
-	}
-
-	pktID := 0
-	for b := 0; b < numBatches; b++ {
-		sendTime := float64(b) * (cfg.SimDuration / float64(numBatches))
-		batchID := b
-		for j := 0; j < batchSize; j++ {
-			id := pktID
-			pktID++
-			sim.Schedule(sendTime, func() {
-				pkt := network.NewPacket(id, batchID, "Source", sim.Now)
-				router.Forward(sim, pkt, dest)
-			})
-		}
-	}
-	sim.Run(cfg.SimDuration + 10.0)
-
-	verifier := verification.NewVerifier(prover, cfg.Verification)
-	verifier.IngestPackets(prover.Packets)
-	res := verifier.RunVerification()
-
-	return IncompetentTrialResult{
-		TrialNum:            trialNum,
-		Verdict:             res.Verdict,
-		VerdictClass:        classifyIncompetentVerdict(res),
-		Confidence:          res.Confidence,
-		QueriesUsed:         res.TotalQueries,
-		ContradictionsFound: res.ContradictionsFound,
-		PosteriorH0:         res.PosteriorH0,
-		PosteriorH1:         res.PosteriorH1,
-		PosteriorH2:         res.PosteriorH2,
-	}
-}
-
-func classifyIncompetentVerdict(res verification.VerificationResult) string {
-	if strings.Contains(res.Verdict, "SLA_BREACHED") {
-		return "SLA_BREACHED"
-	}
-	switch res.Verdict {
-	case "TRUSTED":
-		return "TRUSTED"
-	case "INCONCLUSIVE", "INSUFFICIENT_DATA":
-		return "INCONCLUSIVE"
-	case "DISHONEST":
-		if res.PosteriorH1 >= res.PosteriorH2 {
-			return "CAUGHT_INCOMPETENT"
-		}
-		return "CAUGHT_MALICIOUS"
-	}
-	return "UNKNOWN"
-}
-
-func aggregateIncompetent(cfg IncompetentBaselineConfig, trials []IncompetentTrialResult) IncompetentAggregate {
-	n := len(trials)
-	agg := IncompetentAggregate{Config: cfg, Trials: trials}
-	if n == 0 {
-		return agg
-	}
-
-	var trusted, caughtIncomp, caughtMal, slaBreach, inconclusive int
-	var sumH0, sumH1, sumH2 float64
-	var totalContradictions int
-	queriesToVerdict := make([]int, 0, n)
-
-	for _, t := range trials {
-		switch t.VerdictClass {
-		case "TRUSTED":
-			trusted++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "CAUGHT_INCOMPETENT":
-			caughtIncomp++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "CAUGHT_MALICIOUS":
-			caughtMal++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "SLA_BREACHED":
-			slaBreach++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "INCONCLUSIVE":
-			inconclusive++
-		}
-		sumH0 += t.PosteriorH0
-		sumH1 += t.PosteriorH1
-		sumH2 += t.PosteriorH2
-		totalContradictions += t.ContradictionsFound
-	}
-	correctDetections := caughtIncomp + caughtMal + slaBreach
-
-	fn := float64(n)
-	agg.TrustedRate = float64(trusted) / fn
-	agg.CaughtIncompetentRate = float64(caughtIncomp) / fn
-	agg.CaughtMaliciousRate = float64(caughtMal) / fn
-	agg.SLABreachedRate = float64(slaBreach) / fn
-	agg.InconclusiveRate = float64(inconclusive) / fn
-	agg.CorrectDetectionRate = float64(correctDetections) / fn
-	agg.TrustedRateCI = wilsonRateCI(trusted, n)
-	agg.CaughtIncompetentRateCI = wilsonRateCI(caughtIncomp, n)
-	agg.CaughtMaliciousRateCI = wilsonRateCI(caughtMal, n)
-	agg.SLABreachedRateCI = wilsonRateCI(slaBreach, n)
-	agg.InconclusiveRateCI = wilsonRateCI(inconclusive, n)
-	agg.CorrectDetectionRateCI = wilsonRateCI(correctDetections, n)
-	agg.MeanPosteriorH0 = sumH0 / fn
-	agg.MeanPosteriorH1 = sumH1 / fn
-	agg.MeanPosteriorH2 = sumH2 / fn
-	agg.MeanContradictions = float64(totalContradictions) / fn
-
-	if len(queriesToVerdict) > 0 {
-		sort.Ints(queriesToVerdict)
-		var sum int
-		for _, q := range queriesToVerdict {
-			sum += q
-		}
-		agg.MeanQueriesToVerdict = float64(sum) / float64(len(queriesToVerdict))
-		agg.MedianQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)/2]
-		p90 := (len(queriesToVerdict) * 90) / 100
-		if p90 >= len(queriesToVerdict) {
-			p90 = len(queriesToVerdict) - 1
-		}
-		agg.P90QueriesToVerdict = queriesToVerdict[p90]
-		agg.MinQueriesToVerdict = queriesToVerdict[0]
-		agg.MaxQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)-1]
-	}
-	return agg
-}
-
 // --- Sweeps ---
 
 // SweepIncompetenceRate varies p_incomp (DelayModel.IncompetenceRate).
@@ -752,14 +431,7 @@ func aggregateIncompetent(cfg IncompetentBaselineConfig, trials []IncompetentTri
 // rates test how fast genuine unreliability is caught.
 func (r *Runner) SweepIncompetenceRate(base IncompetentBaselineConfig, rates []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: p_incomp sweep (%d values) ===\n", len(rates))
-	out := make([]IncompetentAggregate, 0, len(rates))
-	for _, p := range rates {
-		cfg := base
-		cfg.DelayModel.IncompetenceRate = p
-		cfg.Name = fmt.Sprintf("%s_pincomp%.4f", base.Name, p)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "DelayModel.IncompetenceRate", "pincomp", "%.4f", rates)
 }
 
 // SweepFlagReliability varies P(flag | congestion). At 1.0 the SNP is
@@ -767,14 +439,7 @@ func (r *Runner) SweepIncompetenceRate(base IncompetentBaselineConfig, rates []f
 // as a hidden-delay admission when queried.
 func (r *Runner) SweepFlagReliability(base IncompetentBaselineConfig, reliabilities []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: flag-reliability sweep (%d values) ===\n", len(reliabilities))
-	out := make([]IncompetentAggregate, 0, len(reliabilities))
-	for _, rel := range reliabilities {
-		cfg := base
-		cfg.FlagReliability = rel
-		cfg.Name = fmt.Sprintf("%s_flagrel%.3f", base.Name, rel)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "FlagReliability", "flagrel", "%.3f", reliabilities)
 }
 
 // SweepIncompetentPhaseMap performs a 2D sweep over p_incomp and flag
@@ -782,17 +447,10 @@ func (r *Runner) SweepFlagReliability(base IncompetentBaselineConfig, reliabilit
 // flag-reliability, and each aggregate retains both axis values in Config.
 func (r *Runner) SweepIncompetentPhaseMap(base IncompetentBaselineConfig, rates, reliabilities []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: phase map p_incomp x flag-reliability (%d x %d) ===\n", len(rates), len(reliabilities))
-	out := make([]IncompetentAggregate, 0, len(rates)*len(reliabilities))
-	for _, p := range rates {
-		for _, rel := range reliabilities {
-			cfg := base
-			cfg.DelayModel.IncompetenceRate = p
-			cfg.FlagReliability = rel
-			cfg.Name = fmt.Sprintf("%s_pincomp%.4f_flagrel%.3f", base.Name, p, rel)
-			out = append(out, r.RunIncompetent(cfg))
-		}
-	}
-	return out
+	return runAll(r.RunIncompetent, mustGridConfigs(base, Grid{
+		{{Field: "DelayModel.IncompetenceRate", Values: rates, Label: "pincomp", Format: "%.4f"}},
+		{{Field: "FlagReliability", Values: reliabilities, Label: "flagrel", Format: "%.3f"}},
+	}, nil))
 }
 
 // SweepAnswerErrorRate varies how often the incompetent prover (using
@@ -802,15 +460,8 @@ func (r *Runner) SweepIncompetentPhaseMap(base IncompetentBaselineConfig, rates,
 // CAUGHT_MALICIOUS.
 func (r *Runner) SweepAnswerErrorRate(base IncompetentBaselineConfig, rates []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: answer-error-rate sweep (%d values) ===\n", len(rates))
-	out := make([]IncompetentAggregate, 0, len(rates))
-	for _, ae := range rates {
-		cfg := base
-		cfg.AnsweringStrategy = verification.AnswerUnreliable
-		cfg.AnswerErrorRate = ae
-		cfg.Name = fmt.Sprintf("%s_anserr%.3f", base.Name, ae)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	base.AnsweringStrategy = verification.AnswerUnreliable
+	return sweepField(r.RunIncompetent, base, "AnswerErrorRate", "anserr", "%.3f", rates)
 }
 
 // SweepIncompetentEta varies the verifier's error-tolerance parameter while
@@ -819,28 +470,14 @@ func (r *Runner) SweepAnswerErrorRate(base IncompetentBaselineConfig, rates []fl
 // incompetence entirely.
 func (r *Runner) SweepIncompetentEta(base IncompetentBaselineConfig, etas []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: η sweep (%d values) ===\n", len(etas))
-	out := make([]IncompetentAggregate, 0, len(etas))
-	for _, e := range etas {
-		cfg := base
-		cfg.Verification.ErrorTolerance = e
-		cfg.Name = fmt.Sprintf("%s_eta%.4f", base.Name, e)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "Verification.ErrorTolerance", "eta", "%.4f", etas)
 }
 
 // SweepIncompetentAlpha varies the confidence threshold α against a fixed
 // incompetent network.
 func (r *Runner) SweepIncompetentAlpha(base IncompetentBaselineConfig, alphas []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: α sweep (%d values) ===\n", len(alphas))
-	out := make([]IncompetentAggregate, 0, len(alphas))
-	for _, a := range alphas {
-		cfg := base
-		cfg.Verification.ConfidenceThreshold = a
-		cfg.Name = fmt.Sprintf("%s_alpha%.6f", base.Name, a)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "Verification.ConfidenceThreshold", "alpha", "%.6f", alphas)
 }
 
 // SweepIncompetentNumPackets varies the number of packets per trial (batch
@@ -848,14 +485,7 @@ func (r *Runner) SweepIncompetentAlpha(base IncompetentBaselineConfig, alphas []
 // happens to query a congested packet.
 func (r *Runner) SweepIncompetentNumPackets(base IncompetentBaselineConfig, ns []int) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: NumPackets sweep (%d values) ===\n", len(ns))
-	out := make([]IncompetentAggregate, 0, len(ns))
-	for _, n := range ns {
-		cfg := base
-		cfg.NumPackets = n
-		cfg.Name = fmt.Sprintf("%s_pkts%d", base.Name, n)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "NumPackets", "pkts", "%d", floats(ns))
 }
 
 // SweepIncompetentBatchSize varies B while keeping total NumPackets fixed.
@@ -864,14 +494,7 @@ func (r *Runner) SweepIncompetentNumPackets(base IncompetentBaselineConfig, ns [
 // hitting a congested packet.
 func (r *Runner) SweepIncompetentBatchSize(base IncompetentBaselineConfig, batches []int) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: batch-size sweep (%d values) ===\n", len(batches))
-	out := make([]IncompetentAggregate, 0, len(batches))
-	for _, b := range batches {
-		cfg := base
-		cfg.BatchSize = b
-		cfg.Name = fmt.Sprintf("%s_batch%d", base.Name, b)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "BatchSize", "batch", "%d", floats(batches))
 }
 
 // SweepIncompetentQueriesPerBatch varies verifier audit aggressiveness via
@@ -879,14 +502,7 @@ func (r *Runner) SweepIncompetentBatchSize(base IncompetentBaselineConfig, batch
 // accelerate detection when incompetence signals are sparse.
 func (r *Runner) SweepIncompetentQueriesPerBatch(base IncompetentBaselineConfig, qpbs []int) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: queries-per-batch sweep (%d values) ===\n", len(qpbs))
-	out := make([]IncompetentAggregate, 0, len(qpbs))
-	for _, qpb := range qpbs {
-		cfg := base
-		cfg.Verification.QueriesPerBatch = qpb
-		cfg.Name = fmt.Sprintf("%s_qpb%d", base.Name, qpb)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "Verification.QueriesPerBatch", "qpb", "%d", floats(qpbs))
 }
 
 // SweepIncompetenceMagnitude varies µ (the log-normal mean of the
@@ -895,14 +511,7 @@ func (r *Runner) SweepIncompetentQueriesPerBatch(base IncompetentBaselineConfig,
 // makes that design property empirical rather than asserted.
 func (r *Runner) SweepIncompetenceMagnitude(base IncompetentBaselineConfig, mus []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: incompetence-magnitude µ sweep (%d values) ===\n", len(mus))
-	out := make([]IncompetentAggregate, 0, len(mus))
-	for _, mu := range mus {
-		cfg := base
-		cfg.DelayModel.IncompetenceMu = mu
-		cfg.Name = fmt.Sprintf("%s_mu%.3f", base.Name, mu)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "DelayModel.IncompetenceMu", "mu", "%.3f", mus)
 }
 
 // SweepIncompetentFlagThreshold varies τ_flag, the verifier's SLA flagging
@@ -910,32 +519,9 @@ func (r *Runner) SweepIncompetenceMagnitude(base IncompetentBaselineConfig, mus
 // before the Bayesian posterior does.
 func (r *Runner) SweepIncompetentFlagThreshold(base IncompetentBaselineConfig, taus []float64) []IncompetentAggregate {
 	fmt.Printf("\n=== Incompetent: τ_flag sweep (%d values) ===\n", len(taus))
-	out := make([]IncompetentAggregate, 0, len(taus))
-	for _, tau := range taus {
-		cfg := base
-		cfg.Verification.FlaggingRateThreshold = tau
-		cfg.Name = fmt.Sprintf("%s_tauflag%.4f", base.Name, tau)
-		out = append(out, r.RunIncompetent(cfg))
-	}
-	return out
+	return sweepField(r.RunIncompetent, base, "Verification.FlaggingRateThreshold", "tauflag", "%.4f", taus)
 }
 
 func (r *Runner) SaveIncompetentAggregates(path string, results []IncompetentAggregate) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
-	}
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(results); err != nil {
-		return err
-	}
-	if r.Verbose {
-		fmt.Printf("    wrote %s\n", path)
-	}
-	return nil
+	return r.saveResults(path, results)
 }
@@ -1,16 +1,11 @@
 package experiment
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
-	"os"
-	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
-	"time"
 
-	"satnet-simulator/internal/engine"
 	"satnet-simulator/internal/network"
 	"satnet-simulator/internal/verification"
 )
@@ -35,16 +30,45 @@ type MaliciousBaselineConfig struct {
 	NumPackets  int
 	BatchSize   int
 	SimDuration float64
+	Adaptive    Adaptive // stop short of NumTrials once the rates are known well enough
 
 	DelayModel network.DelayModelConfig // TargetedMin/Max carry d_mal
 
 	Targeting network.TargetingConfig
 
-	PFlag float64 // p_flag
-	PLie  float64 // p_lie
+	PFlag       float64 // p_flag
+	POverFlag   float64 // P(flag | packet was not delayed): padding the flagged set
+	PLie        float64 // p_lie
+	PEquivocate float64 // P(answer departs from the committed claim); needs Verification.RequireCommitments
+
+	// Stalling instead of lying. The batch verifier only notices with a
+	// Streaming.QueryDeadline to miss.
+	ResponsePolicy verification.ResponsePolicy
+	PStall         float64 // P(ResponsePolicy applies to a query about a targeted packet, or any packet with StallAll)
+	StallDelay     float64
+	StallAll       bool // stall on queries about any packet, not only targeted ones
 
 	AnsweringStrategy verification.AnsweringStrategy
 	Verification      verification.VerificationConfig
+	Streaming         verification.StreamingConfig // audit during the run instead of after it
+
+	// The operator publishes its base-delay schedule: each trial fills
+	// Verification.DeclaredBaseDelay from its own delay model.
+	DeclareSchedule bool
+
+	// P(the adversary recognises a verifier probe and lets it through
+	// untouched); only matters with Verification.ProbeRate set.
+	ProbeDistinguishability float64
+
+	// Later changes of behaviour; the fields above are the regime before the
+	// first switch. Switches are only meaningful to a streaming verifier.
+	Regimes []Regime
+
+	Timeline Timeline // parameter changes during each trial
+
+	// Several customers on the same operator; the zero value is the usual
+	// lone customer.
+	Coalition CoalitionSetup
 }
 
 func DefaultMaliciousBaseline() MaliciousBaselineConfig {
@@ -61,8 +85,8 @@ func DefaultMaliciousBaseline() MaliciousBaselineConfig {
 			IncompetenceRate:  0.0,
 			IncompetenceMu:    0.0,
 			IncompetenceSigma: 0.0,
-			TargetedMin:     0.050,
-			TargetedMax:     0.050,
+			TargetedMin:       0.050,
+			TargetedMax:       0.050,
 		},
 		Targeting:         network.DefaultAdversarialTargeting(0.10),
 		PFlag:             0.0,
@@ -82,51 +106,53 @@ func AggressivePFlag(pTarget, tauFlag float64) float64 {
 	return math.Min(1.0, tauFlag/pTarget)
 }
 
-// ============================================================================
-// Trial result types
-// ============================================================================
+// Scenario is cfg as a composed scenario. Its trials keep the seeds they
+// had before scenarios.
+func (cfg MaliciousBaselineConfig) Scenario() Scenario {
+	return Scenario{
+		Name:        cfg.Name,
+		NumTrials:   cfg.NumTrials,
+		NumPackets:  cfg.NumPackets,
+		BatchSize:   cfg.BatchSize,
+		SimDuration: cfg.SimDuration,
+		Adaptive:    cfg.Adaptive,
+		DelayModel:  cfg.DelayModel,
+		Targeting:   cfg.Targeting,
+		Flagging:    Flagging{PFlag: cfg.PFlag, POverFlag: cfg.POverFlag},
+		Answering: Answering{
+			Strategy:       cfg.AnsweringStrategy,
+			PLie:           cfg.PLie,
+			PEquivocate:    cfg.PEquivocate,
+			ResponsePolicy: cfg.ResponsePolicy,
+			PStall:         cfg.PStall,
+			StallDelay:     cfg.StallDelay,
+			StallAll:       cfg.StallAll,
+		},
+		Verification:            cfg.Verification,
+		Streaming:               cfg.Streaming,
+		DeclareSchedule:         cfg.DeclareSchedule,
+		ProbeDistinguishability: cfg.ProbeDistinguishability,
+		Regimes:                 cfg.Regimes,
+		Timeline:                cfg.Timeline,
+		Coalition:               cfg.Coalition,
+		scope:                   "malicious",
+	}
+}
 
-type MaliciousTrialResult struct {
-	TrialNum            int
-	Verdict             string
-	VerdictClass        string
-	Confidence          float64
-	QueriesUsed         int
-	ContradictionsFound int
-	PosteriorH0         float64
-	PosteriorH1         float64
-	PosteriorH2         float64
-	Duration            time.Duration
+// Validate reports a config whose scenario would not run; see Scenario.Validate.
+func (cfg MaliciousBaselineConfig) Validate() error {
+	return cfg.Scenario().Validate()
 }
 
 type MaliciousAggregate struct {
 	Config MaliciousBaselineConfig
-	Trials []MaliciousTrialResult
-
-	// Detection-rate breakdown
-	MissedRate                float64 // false negative: malicious slipped through as TRUSTED
-	CaughtMaliciousRate       float64 // H2 posterior wins — correct classification
-	MisclassifiedIncompRate   float64 // H1 posterior wins — wrong sub-class
-	SLABreachedRate           float64 // caught via flagging-rate axis
-	InconclusiveRate          float64
-	CorrectDetectionRate      float64 // caught by any mechanism
-	MissedRateCI              RateCI
-	CaughtMaliciousRateCI     RateCI
-	MisclassifiedIncompRateCI RateCI
-	SLABreachedRateCI         RateCI
-	InconclusiveRateCI        RateCI
-	CorrectDetectionRateCI    RateCI
-
-	MeanQueriesToVerdict   float64
-	MedianQueriesToVerdict int
-	P90QueriesToVerdict    int
-	MinQueriesToVerdict    int
-	MaxQueriesToVerdict    int
-
-	MeanPosteriorH0    float64
-	MeanPosteriorH1    float64
-	MeanPosteriorH2    float64
-	MeanContradictions float64
+	Trials []TrialResult
+
+	VerdictRates                   // TrustedRate is the malicious operator slipping through
+	CorrectDetectionRate   float64 // caught by any mechanism
+	CorrectDetectionRateCI RateCI
+
+	TrialStats
 }
 
 // ============================================================================
@@ -134,6 +160,10 @@ type MaliciousAggregate struct {
 // ============================================================================
 
 func (r *Runner) RunMalicious(cfg MaliciousBaselineConfig) MaliciousAggregate {
+	return cachedRun(r, "malicious", cfg.Name, cfg, r.runMalicious)
+}
+
+func (r *Runner) runMalicious(cfg MaliciousBaselineConfig) MaliciousAggregate {
 	if r.Verbose {
 		fmt.Printf(">>> %s: N=%d, pkts=%d, B=%d, p_target=%.4f, p_flag=%.3f, p_lie=%.3f, d_mal=[%.3f,%.3f], η=%.4f, α=%.4f\n",
 			cfg.Name, cfg.NumTrials, cfg.NumPackets, cfg.BatchSize,
@@ -143,20 +173,20 @@ func (r *Runner) RunMalicious(cfg MaliciousBaselineConfig) MaliciousAggregate {
 			cfg.Verification.ConfidenceThreshold)
 	}
 
-	trials := make([]MaliciousTrialResult, cfg.NumTrials)
-	for i := 0; i < cfg.NumTrials; i++ {
-		r.maybeSeedTrial("malicious", cfg.Name, i)
-		start := time.Now()
-		trials[i] = r.runSingleMaliciousTrial(cfg, i)
-		trials[i].Duration = time.Since(start)
+	a := r.runTrials(cfg.Scenario())
+	agg := MaliciousAggregate{
+		Config:                 cfg,
+		Trials:                 a.Trials,
+		VerdictRates:           a.VerdictRates,
+		CorrectDetectionRate:   a.AccusedRate,
+		CorrectDetectionRateCI: a.AccusedRateCI,
+		TrialStats:             a.TrialStats,
 	}
-
-	agg := aggregateMalicious(cfg, trials)
 	if r.Verbose {
 		fmt.Printf("    missed=%s  caught_H2=%s  caught_H1=%s  SLA=%s  inconclusive=%s  median_q=%d\n",
-			formatRateWithCI(agg.MissedRate, agg.MissedRateCI),
+			formatRateWithCI(agg.TrustedRate, agg.TrustedRateCI),
 			formatRateWithCI(agg.CaughtMaliciousRate, agg.CaughtMaliciousRateCI),
-			formatRateWithCI(agg.MisclassifiedIncompRate, agg.MisclassifiedIncompRateCI),
+			formatRateWithCI(agg.CaughtIncompetentRate, agg.CaughtIncompetentRateCI),
 			formatRateWithCI(agg.SLABreachedRate, agg.SLABreachedRateCI),
 			formatRateWithCI(agg.InconclusiveRate, agg.InconclusiveRateCI),
 			agg.MedianQueriesToVerdict)
@@ -164,174 +194,8 @@ func (r *Runner) RunMalicious(cfg MaliciousBaselineConfig) MaliciousAggregate {
 	return agg
 }
 
-func (r *Runner) runSingleMaliciousTrial(cfg MaliciousBaselineConfig, trialNum int) MaliciousTrialResult {
-	sim := engine.NewSimulation()
-
-	dm := network.NewDelayModelConfig(cfg.DelayModel)
-	dm.Initialise(cfg.SimDuration + 10.0)
-
-	prover := verification.NewProver(verification.AdversaryConfig{
-		AnsweringStr: cfg.AnsweringStrategy,
-		LieRate:      cfg.PLie,
-	})
-
-	router := network.NewRouter(dm, cfg.Targeting, adversarialFlagging(cfg.PFlag))
-	router.OnTransmission = func(pkt network.Packet) {
-		prover.RecordTransmission(pkt)
-	}
-
-	dest := &honestDest{}
-
-	batchSize := cfg.BatchSize
-	if batchSize < 2 {
-		batchSize = 2
-	}
-	numBatches := cfg.NumPackets / batchSize
-	if numBatches < 1 {
-		numBatches = 1
-	}
-
-	pktID := 0
-	for b := 0; b < numBatches; b++ {
-		sendTime := float64(b) * (cfg.SimDuration / float64(numBatches))
-		batchID := b
-		for j := 0; j < batchSize; j++ {
-			id := pktID
-			pktID++
-			sim.Schedule(sendTime, func() {
-				pkt := network.NewPacket(id, batchID, "Source", sim.Now)
-				router.Forward(sim, pkt, dest)
-			})
-		}
-	}
-	sim.Run(cfg.SimDuration + 10.0)
-
-	verifier := verification.NewVerifier(prover, cfg.Verification)
-	verifier.IngestPackets(prover.Packets)
-	res := verifier.RunVerification()
-
-	return MaliciousTrialResult{
-		TrialNum:            trialNum,
-		Verdict:             res.Verdict,
-		VerdictClass:        classifyMaliciousVerdict(res),
-		Confidence:          res.Confidence,
-		QueriesUsed:         res.TotalQueries,
-		ContradictionsFound: res.ContradictionsFound,
-		PosteriorH0:         res.PosteriorH0,
-		PosteriorH1:         res.PosteriorH1,
-		PosteriorH2:         res.PosteriorH2,
-	}
-}
-
-func classifyMaliciousVerdict(res verification.VerificationResult) string {
-	if strings.Contains(res.Verdict, "SLA_BREACHED") {
-		return "SLA_BREACHED"
-	}
-	switch res.Verdict {
-	case "TRUSTED":
-		return "MISSED"
-	case "INCONCLUSIVE", "INSUFFICIENT_DATA":
-		return "INCONCLUSIVE"
-	case "DISHONEST":
-		if res.PosteriorH2 >= res.PosteriorH1 {
-			return "CAUGHT_MALICIOUS"
-		}
-		return "MISCLASSIFIED_INCOMPETENT"
-	}
-	return "UNKNOWN"
-}
-
-func aggregateMalicious(cfg MaliciousBaselineConfig, trials []MaliciousTrialResult) MaliciousAggregate {
-	n := len(trials)
-	agg := MaliciousAggregate{Config: cfg, Trials: trials}
-	if n == 0 {
-		return agg
-	}
-
-	var missed, caughtMal, misclassIncomp, slaBreach, inconclusive int
-	var sumH0, sumH1, sumH2 float64
-	var totalContradictions int
-	queriesToVerdict := make([]int, 0, n)
-
-	for _, t := range trials {
-		switch t.VerdictClass {
-		case "MISSED":
-			missed++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "CAUGHT_MALICIOUS":
-			caughtMal++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "MISCLASSIFIED_INCOMPETENT":
-			misclassIncomp++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "SLA_BREACHED":
-			slaBreach++
-			queriesToVerdict = append(queriesToVerdict, t.QueriesUsed)
-		case "INCONCLUSIVE":
-			inconclusive++
-		}
-		sumH0 += t.PosteriorH0
-		sumH1 += t.PosteriorH1
-		sumH2 += t.PosteriorH2
-		totalContradictions += t.ContradictionsFound
-	}
-	correctDetections := caughtMal + misclassIncomp + slaBreach
-
-	fn := float64(n)
-	agg.MissedRate = float64(missed) / fn
-	agg.CaughtMaliciousRate = float64(caughtMal) / fn
-	agg.MisclassifiedIncompRate = float64(misclassIncomp) / fn
-	agg.SLABreachedRate = float64(slaBreach) / fn
-	agg.InconclusiveRate = float64(inconclusive) / fn
-	agg.CorrectDetectionRate = float64(correctDetections) / fn
-	agg.MissedRateCI = wilsonRateCI(missed, n)
-	agg.CaughtMaliciousRateCI = wilsonRateCI(caughtMal, n)
-	agg.MisclassifiedIncompRateCI = wilsonRateCI(misclassIncomp, n)
-	agg.SLABreachedRateCI = wilsonRateCI(slaBreach, n)
-	agg.InconclusiveRateCI = wilsonRateCI(inconclusive, n)
-	agg.CorrectDetectionRateCI = wilsonRateCI(correctDetections, n)
-	agg.MeanPosteriorH0 = sumH0 / fn
-	agg.MeanPosteriorH1 = sumH1 / fn
-	agg.MeanPosteriorH2 = sumH2 / fn
-	agg.MeanContradictions = float64(totalContradictions) / fn
-
-	if len(queriesToVerdict) > 0 {
-		sort.Ints(queriesToVerdict)
-		var sum int
-		for _, q := range queriesToVerdict {
-			sum += q
-		}
-		agg.MeanQueriesToVerdict = float64(sum) / float64(len(queriesToVerdict))
-		agg.MedianQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)/2]
-		p90 := (len(queriesToVerdict) * 90) / 100
-		if p90 >= len(queriesToVerdict) {
-			p90 = len(queriesToVerdict) - 1
-		}
-		agg.P90QueriesToVerdict = queriesToVerdict[p90]
-		agg.MinQueriesToVerdict = queriesToVerdict[0]
-		agg.MaxQueriesToVerdict = queriesToVerdict[len(queriesToVerdict)-1]
-	}
-	return agg
-}
-
 func (r *Runner) SaveMaliciousAggregates(path string, results []MaliciousAggregate) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return err
-	}
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(results); err != nil {
-		return err
-	}
-	if r.Verbose {
-		fmt.Printf("    wrote %s\n", path)
-	}
-	return nil
+	return r.saveResults(path, results)
 }
 
 // ============================================================================
@@ -383,6 +247,103 @@ func ParametricConfig(base MaliciousBaselineConfig, pTarget, pFlag, pLie float64
 	return cfg
 }
 
+// StallerConfig returns an adversary that never lies: p_flag=0, p_lie=0, and
+// every query about a targeted packet is met with policy instead of an answer.
+// It turns on the streaming verifier when base has none, whose default
+// deadline gives the staller something to miss.
+func StallerConfig(base MaliciousBaselineConfig, pTarget float64, policy verification.ResponsePolicy) MaliciousBaselineConfig {
+	cfg := base
+	cfg.Targeting = network.DefaultAdversarialTargeting(pTarget)
+	cfg.PFlag = 0.0
+	cfg.PLie = 0.0
+	cfg.AnsweringStrategy = verification.AnswerParametric
+	cfg.ResponsePolicy = policy
+	cfg.PStall = 1.0
+	if !cfg.Streaming.Enabled {
+		cfg.Streaming = verification.DefaultStreamingConfig()
+	}
+	return cfg
+}
+
+// OverFlaggerConfig returns a naive liar that also flags a pOverFlag share of
+// the packets it did not delay, so the flags it owes the SLA land on packets
+// that cost it nothing to admit.
+func OverFlaggerConfig(base MaliciousBaselineConfig, pTarget, pOverFlag float64) MaliciousBaselineConfig {
+	cfg := NaiveLiarConfig(base, pTarget)
+	cfg.POverFlag = pOverFlag
+	return cfg
+}
+
+// SafeLiarConfig returns an adversary that delays pTarget of the packets at
+// random, flags a pFlag share of them, and lies only where no contradiction
+// is possible: about a targeted packet nothing in its batch beat
+// (AnswerSafeLies). Every other delay it admits.
+func SafeLiarConfig(base MaliciousBaselineConfig, pTarget, pFlag float64) MaliciousBaselineConfig {
+	cfg := base
+	cfg.Targeting = network.DefaultAdversarialTargeting(pTarget)
+	cfg.PFlag = pFlag
+	cfg.PLie = 0.0
+	cfg.AnsweringStrategy = verification.AnswerSafeLies
+	return cfg
+}
+
+// WholeBatchLiarConfig returns a safe liar that delays every packet of every
+// batch (TargetQuota with the quota at the batch size), so the batch minimum
+// is always one of its own and every claim it makes is safe.
+func WholeBatchLiarConfig(base MaliciousBaselineConfig) MaliciousBaselineConfig {
+	cfg := SafeLiarConfig(base, 0, 0)
+	cfg.Targeting = network.DefaultQuotaTargeting(base.BatchSize, base.BatchSize)
+	return cfg
+}
+
+// RandomAnswererConfig returns an adversary that targets pTarget of the
+// packets, flags none of them and coin-flips every answer (AnswerRandom).
+// Half its answers about delayed packets are lies; the other half deny that
+// packets were minimal, including the ones that were.
+func RandomAnswererConfig(base MaliciousBaselineConfig, pTarget float64) MaliciousBaselineConfig {
+	cfg := base
+	cfg.Targeting = network.DefaultAdversarialTargeting(pTarget)
+	cfg.PFlag = 0.0
+	cfg.AnsweringStrategy = verification.AnswerRandom
+	return cfg
+}
+
+// GlobalInflationConfig returns an adversary that adds the same delay to
+// every packet and claims every one is minimal. No batch minimum can refute
+// it; only an absolute reference can.
+func GlobalInflationConfig(base MaliciousBaselineConfig) MaliciousBaselineConfig {
+	cfg := base
+	cfg.Targeting = network.DefaultAllTargeting()
+	cfg.PFlag = 0.0
+	cfg.PLie = 1.0
+	cfg.AnsweringStrategy = verification.AnswerParametric
+	return cfg
+}
+
+// declaredSchedule is the declaration an honest operator would publish: the
+// exact base delay of every path segment.
+func declaredSchedule(dm *network.DelayModel) []verification.DelayRange {
+	starts, delays := dm.BaseDelaySchedule()
+	out := make([]verification.DelayRange, len(starts))
+	for i := range starts {
+		out[i] = verification.DelayRange{From: starts[i], Min: delays[i], Max: delays[i]}
+		if i+1 < len(starts) {
+			out[i].To = starts[i+1]
+		}
+	}
+	return out
+}
+
+// LondonNewYork is a transatlantic path through a 550 km shell. Its
+// speed-of-light bound is just under the default BaseDelayMin.
+func LondonNewYork() verification.GroundPath {
+	return verification.GroundPath{
+		From:            verification.GroundStation{Name: "London", Lat: 51.5074, Lon: -0.1278},
+		To:              verification.GroundStation{Name: "New York", Lat: 40.7128, Lon: -74.0060},
+		ShellAltitudeKm: 550,
+	}
+}
+
 // ============================================================================
 // Sweeps
 // ============================================================================
@@ -391,60 +352,46 @@ func ParametricConfig(base MaliciousBaselineConfig, pTarget, pFlag, pLie float64
 // take a fraction (Random). Other targeting modes use SweepMaliciousTargetingModes.
 func (r *Runner) SweepMaliciousPTarget(base MaliciousBaselineConfig, pTargets []float64) []MaliciousAggregate {
 	fmt.Printf("\n=== Malicious: p_target sweep (%d values) [%s] ===\n", len(pTargets), base.Name)
-	out := make([]MaliciousAggregate, 0, len(pTargets))
-	for _, p := range pTargets {
-		cfg := base
-		cfg.Targeting = network.DefaultAdversarialTargeting(p)
-		cfg.Name = fmt.Sprintf("%s_ptarget%.4f", base.Name, p)
-		out = append(out, r.RunMalicious(cfg))
-	}
-	return out
+	return runAll(r.RunMalicious, maliciousPTargetConfigs(base, pTargets))
+}
+
+func maliciousPTargetConfigs(base MaliciousBaselineConfig, pTargets []float64) []MaliciousBaselineConfig {
+	base.Targeting = network.DefaultAdversarialTargeting(0)
+	return mustGridConfigs(base, Grid{{pTargetAxis(pTargets)}}, nil)
+}
+
+func pTargetAxis(pTargets []float64) Axis {
+	return Axis{Field: "Targeting.TargetFraction", Values: pTargets, Label: "ptarget", Format: "%.4f"}
 }
 
 // SweepMaliciousPFlag varies p_flag at a fixed p_target.
 func (r *Runner) SweepMaliciousPFlag(base MaliciousBaselineConfig, pFlags []float64) []MaliciousAggregate {
 	fmt.Printf("\n=== Malicious: p_flag sweep (%d values) [%s] ===\n", len(pFlags), base.Name)
-	out := make([]MaliciousAggregate, 0, len(pFlags))
-	for _, pf := range pFlags {
-		cfg := base
-		cfg.PFlag = pf
-		cfg.Name = fmt.Sprintf("%s_pflag%.4f", base.Name, pf)
-		out = append(out, r.RunMalicious(cfg))
-	}
-	return out
+	return sweepField(r.RunMalicious, base, "PFlag", "pflag", "%.4f", pFlags)
 }
 
 // SweepMaliciousPLie varies p_lie at fixed p_target and p_flag.
 func (r *Runner) SweepMaliciousPLie(base MaliciousBaselineConfig, pLies []float64) []MaliciousAggregate {
 	fmt.Printf("\n=== Malicious: p_lie sweep (%d values) [%s] ===\n", len(pLies), base.Name)
-	out := make([]MaliciousAggregate, 0, len(pLies))
-	for _, pl := range pLies {
-		cfg := base
-		cfg.PLie = pl
-		cfg.Name = fmt.Sprintf("%s_plie%.4f", base.Name, pl)
-		out = append(out, r.RunMalicious(cfg))
-	}
-	return out
+	return sweepField(r.RunMalicious, base, "PLie", "plie", "%.4f", pLies)
 }
 
 // SweepMaliciousPhaseMap runs a 2D sweep over p_target and p_lie with p_flag
 // set to the aggressive optimum (τ_flag / p_target) at each point.
 func (r *Runner) SweepMaliciousPhaseMap(base MaliciousBaselineConfig, pTargets, pLies []float64) []MaliciousAggregate {
-	tauFlag := base.Verification.FlaggingRateThreshold
 	fmt.Printf("\n=== Malicious: phase map p_target x p_lie (%d x %d, aggressive p_flag) [%s] ===\n",
 		len(pTargets), len(pLies), base.Name)
-	out := make([]MaliciousAggregate, 0, len(pTargets)*len(pLies))
-	for _, pt := range pTargets {
-		for _, pl := range pLies {
-			cfg := base
-			cfg.Targeting = network.DefaultAdversarialTargeting(pt)
-			cfg.PFlag = AggressivePFlag(pt, tauFlag)
-			cfg.PLie = pl
-			cfg.Name = fmt.Sprintf("%s_ptarget%.4f_plie%.4f", base.Name, pt, pl)
-			out = append(out, r.RunMalicious(cfg))
-		}
-	}
-	return out
+	return runAll(r.RunMalicious, maliciousPhaseMapConfigs(base, pTargets, pLies))
+}
+
+func maliciousPhaseMapConfigs(base MaliciousBaselineConfig, pTargets, pLies []float64) []MaliciousBaselineConfig {
+	base.Targeting = network.DefaultAdversarialTargeting(0)
+	return mustGridConfigs(base, Grid{
+		{pTargetAxis(pTargets)},
+		{{Field: "PLie", Values: pLies, Label: "plie", Format: "%.4f"}},
+	}, []Derived{
+		{Field: "PFlag", Func: "AggressivePFlag", Args: []string{"Targeting.TargetFraction", "Verification.FlaggingRateThreshold"}},
+	})
 }
 
 // SweepMaliciousTargetingModes compares all four non-trivial targeting modes
@@ -453,7 +400,10 @@ func (r *Runner) SweepMaliciousPhaseMap(base MaliciousBaselineConfig, pTargets,
 // only variable.
 func (r *Runner) SweepMaliciousTargetingModes(base MaliciousBaselineConfig) []MaliciousAggregate {
 	fmt.Printf("\n=== Malicious: targeting mode comparison [%s] ===\n", base.Name)
+	return runAll(r.RunMalicious, maliciousTargetingModeConfigs(base))
+}
 
+func maliciousTargetingModeConfigs(base MaliciousBaselineConfig) []MaliciousBaselineConfig {
 	batchSize := base.BatchSize
 	if batchSize < 2 {
 		batchSize = 2
@@ -467,25 +417,153 @@ func (r *Runner) SweepMaliciousTargetingModes(base MaliciousBaselineConfig) []Ma
 		quota = 1
 	}
 
-	modes := []struct {
-		name      string
-		targeting network.TargetingConfig
-	}{
-		{"random", network.DefaultAdversarialTargeting(approxRate)},
-		{"periodic", network.DefaultPeriodicTargeting(period)},
-		{"quota", network.DefaultQuotaTargeting(quota, batchSize)},
-		{"all", network.DefaultAllTargeting()},
+	// Each mode reads only its own parameters, so they are zipped with it
+	// and the rest left at zero.
+	base.Targeting = network.TargetingConfig{}
+	base.PFlag = 0.0
+	base.PLie = 1.0
+	base.AnsweringStrategy = verification.AnswerParametric
+	return mustGridConfigs(base, Grid{{
+		{Field: "Targeting.Mode", Names: []string{"RANDOM", "PERIODIC", "QUOTA", "ALL"},
+			Labels: []string{"mode_random", "mode_periodic", "mode_quota", "mode_all"}},
+		{Field: "Targeting.TargetFraction", Values: []float64{approxRate, 0, 0, 0}, Labels: unnamed(4)},
+		{Field: "Targeting.Period", Values: floats([]int{0, period, 0, 0}), Labels: unnamed(4)},
+		{Field: "Targeting.Quota", Values: floats([]int{0, 0, quota, 0}), Labels: unnamed(4)},
+		{Field: "Targeting.BatchSize", Values: floats([]int{0, 0, batchSize, 0}), Labels: unnamed(4)},
+	}}, nil)
+}
+
+// SweepMaliciousTimeoutPolicies runs every stalling policy against every
+// verifier timeout policy, so the cost of giving stalls the benefit of the
+// doubt can be read off directly.
+func (r *Runner) SweepMaliciousTimeoutPolicies(base MaliciousBaselineConfig, pTarget float64) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: stalling vs timeout policy [%s] ===\n", base.Name)
+	return runAll(r.RunMalicious, maliciousTimeoutPolicyConfigs(base, pTarget))
+}
+
+func maliciousTimeoutPolicyConfigs(base MaliciousBaselineConfig, pTarget float64) []MaliciousBaselineConfig {
+	stalls := []verification.ResponsePolicy{
+		verification.RespondLate,
+		verification.RespondRefuse,
+		verification.RespondSilent,
 	}
+	timeouts := []verification.TimeoutPolicy{
+		verification.TimeoutIgnore,
+		verification.TimeoutEvidence,
+		verification.TimeoutContradiction,
+	}
+	stallAxis := Axis{Field: "ResponsePolicy"}
+	for _, sp := range stalls {
+		stallAxis.Names = append(stallAxis.Names, string(sp))
+		stallAxis.Labels = append(stallAxis.Labels, strings.ToLower(string(sp)))
+	}
+	timeoutAxis := Axis{Field: "Verification.TimeoutPolicy"}
+	for _, tp := range timeouts {
+		timeoutAxis.Names = append(timeoutAxis.Names, string(tp))
+		timeoutAxis.Labels = append(timeoutAxis.Labels, timeoutPolicyName(tp))
+	}
+	return mustGridConfigs(StallerConfig(base, pTarget, stalls[0]), Grid{{stallAxis}, {timeoutAxis}}, nil)
+}
 
-	out := make([]MaliciousAggregate, 0, len(modes))
-	for _, m := range modes {
-		cfg := base
-		cfg.Targeting = m.targeting
-		cfg.PFlag = 0.0
-		cfg.PLie = 1.0
-		cfg.AnsweringStrategy = verification.AnswerParametric
-		cfg.Name = fmt.Sprintf("%s_mode_%s", base.Name, m.name)
-		out = append(out, r.RunMalicious(cfg))
+func timeoutPolicyName(tp verification.TimeoutPolicy) string {
+	if tp == verification.TimeoutIgnore {
+		return "timeout_ignore"
 	}
-	return out
+	return strings.ToLower(string(tp))
+}
+
+// SweepMaliciousDelayBounds runs a global inflation attack against a verifier
+// with no absolute reference, with the physical bound alone, and with the
+// operator's declared schedule. The physical bound has to tolerate the whole
+// spread of honest base delays above it, so it only catches what pushes a
+// batch minimum past BaseDelayMax; the schedule catches any inflation larger
+// than InflationTolerance.
+func (r *Runner) SweepMaliciousDelayBounds(base MaliciousBaselineConfig) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: global inflation vs delay bounds [%s] ===\n", base.Name)
+	return runAll(r.RunMalicious, maliciousDelayBoundConfigs(base))
+}
+
+func maliciousDelayBoundConfigs(base MaliciousBaselineConfig) []MaliciousBaselineConfig {
+	cfg := GlobalInflationConfig(base)
+	path := LondonNewYork()
+	physicalTol := base.DelayModel.BaseDelayMax - path.MinDelay() + 1e-3
+	tol := cfg.Verification.InflationTolerance
+	declare := strconv.FormatBool(cfg.DeclareSchedule)
+	return mustGridConfigs(cfg, Grid{{
+		{Field: "Verification.Path", Names: []string{mustJSON(cfg.Verification.Path), mustJSON(path), mustJSON(cfg.Verification.Path)},
+			Labels: []string{"no_bound", "physical", "declared"}},
+		{Field: "Verification.InflationTolerance", Values: []float64{tol, physicalTol, tol}, Labels: unnamed(3)},
+		{Field: "DeclareSchedule", Names: []string{declare, declare, "true"}, Labels: unnamed(3)},
+	}}, nil)
+}
+
+// SweepMaliciousProbeDistinguishability runs the base adversary against a
+// verifier probing at probeRate, with the adversary recognising each probe
+// with the given probabilities. The first run has no probes at all, as the
+// reference probing has to beat.
+func (r *Runner) SweepMaliciousProbeDistinguishability(base MaliciousBaselineConfig, probeRate float64, ds []float64) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: probe distinguishability sweep (%d values) [%s] ===\n", len(ds), base.Name)
+	return runAll(r.RunMalicious, maliciousProbeConfigs(base, probeRate, ds))
+}
+
+func maliciousProbeConfigs(base MaliciousBaselineConfig, probeRate float64, ds []float64) []MaliciousBaselineConfig {
+	rates := []float64{0}
+	dists := []float64{base.ProbeDistinguishability}
+	labels := []string{"noprobes"}
+	for _, d := range ds {
+		rates = append(rates, probeRate)
+		dists = append(dists, d)
+		labels = append(labels, fmt.Sprintf("distinguish%.2f", d))
+	}
+	return mustGridConfigs(base, Grid{{
+		{Field: "ProbeDistinguishability", Values: dists, Labels: labels},
+		{Field: "Verification.ProbeRate", Values: rates, Labels: unnamed(len(rates))},
+	}}, nil)
+}
+
+// SweepMaliciousOverFlagging runs over-flaggers at each pOverFlag, with the
+// false-flag check off and then on.
+func (r *Runner) SweepMaliciousOverFlagging(base MaliciousBaselineConfig, pTarget float64, pOverFlags []float64) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: over-flagging sweep (%d values) [%s] ===\n", len(pOverFlags), base.Name)
+	return runAll(r.RunMalicious, maliciousOverFlagConfigs(base, pTarget, pOverFlags))
+}
+
+func maliciousOverFlagConfigs(base MaliciousBaselineConfig, pTarget float64, pOverFlags []float64) []MaliciousBaselineConfig {
+	return mustGridConfigs(OverFlaggerConfig(base, pTarget, 0), Grid{
+		{{Field: "POverFlag", Values: pOverFlags, Label: "overflag", Format: "%.4f"}},
+		{checkAxis("Verification.CheckFalseFlags", "check")},
+	}, nil)
+}
+
+// SweepMaliciousMinimumQueries runs random answerers at each pTarget, with the
+// verifier sampling only at random and then also querying every audited
+// batch's minimum.
+func (r *Runner) SweepMaliciousMinimumQueries(base MaliciousBaselineConfig, pTargets []float64) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: batch-minimum queries sweep (%d values) [%s] ===\n", len(pTargets), base.Name)
+	return runAll(r.RunMalicious, maliciousMinimumQueryConfigs(base, pTargets))
+}
+
+func maliciousMinimumQueryConfigs(base MaliciousBaselineConfig, pTargets []float64) []MaliciousBaselineConfig {
+	return mustGridConfigs(RandomAnswererConfig(base, 0), Grid{
+		{{Field: "Targeting.TargetFraction", Values: pTargets, Label: "random_pt", Format: "%.4f"}},
+		{checkAxis("Verification.QueryMinimum", "querymin")},
+	}, nil)
+}
+
+// checkAxis turns a bool field off and then on.
+func checkAxis(field, label string) Axis {
+	return Axis{Field: field, Names: []string{"false", "true"}, Label: label, Format: "%t"}
+}
+
+// SweepMaliciousSafeLiars measures how much delay the safe liars get away
+// with: the random-packet safe liar at each targeting rate, then the
+// whole-batch one, which delays everything. It is the verifier's blind spot
+// under the contradiction check alone, and the benchmark for checks that
+// close it.
+func (r *Runner) SweepMaliciousSafeLiars(base MaliciousBaselineConfig, pTargets []float64) []MaliciousAggregate {
+	fmt.Printf("\n=== Malicious: safe-liar sweep (%d values) [%s] ===\n", len(pTargets), base.Name)
+	out := sweepField(r.RunMalicious, SafeLiarConfig(base, 0, 0), "Targeting.TargetFraction", "safe_pt", "%.4f", pTargets)
+	cfg := WholeBatchLiarConfig(base)
+	cfg.Name = base.Name + "_wholebatch"
+	return append(out, r.RunMalicious(cfg))
 }
@@ -0,0 +1,338 @@
+package experiment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// PathSegmentConfig is one operator's stretch of a multi-operator path: its
+// own delay model, targeting and flagging, and its own prover.
+type PathSegmentConfig struct {
+	Name              string
+	DelayModel        network.DelayModelConfig
+	Targeting         network.TargetingConfig
+	PFlag             float64
+	AnsweringStrategy verification.AnsweringStrategy
+	PLie              float64
+	HopShift          verification.HopShift
+}
+
+// PathBaselineConfig sends traffic through Segments in order. The segment
+// that targets packets is the one the verifier should blame.
+type PathBaselineConfig struct {
+	Name        string
+	NumTrials   int
+	NumPackets  int
+	BatchSize   int
+	SimDuration float64
+	Adaptive    Adaptive // stop short of NumTrials once the attribution rates are known well enough
+
+	Segments     []PathSegmentConfig
+	Verification verification.PathVerificationConfig
+}
+
+func honestSegment(name string, baseMin, baseMax float64) PathSegmentConfig {
+	return PathSegmentConfig{
+		Name: name,
+		DelayModel: network.DelayModelConfig{
+			BaseDelayMin:   baseMin,
+			BaseDelayMax:   baseMax,
+			TransitionRate: 0.05,
+		},
+		Targeting:         network.DefaultHonestTargeting(),
+		AnsweringStrategy: verification.AnswerHonest,
+	}
+}
+
+// DefaultPathBaseline is a ground segment, a satellite operator and a
+// terrestrial backhaul, all honest.
+func DefaultPathBaseline() PathBaselineConfig {
+	return PathBaselineConfig{
+		Name:        "path_baseline",
+		NumTrials:   200,
+		NumPackets:  2000,
+		BatchSize:   10,
+		SimDuration: 200.0,
+		Segments: []PathSegmentConfig{
+			honestSegment("ground", 0.002, 0.005),
+			honestSegment("satellite", 0.020, 0.080),
+			honestSegment("backhaul", 0.005, 0.015),
+		},
+		Verification: verification.DefaultPathVerificationConfig(),
+	}
+}
+
+// LyingSegmentConfig makes segment seg a naive liar: it delays pTarget of
+// the packets by 50 ms, flags none of them, and hides each delay by moving
+// the timestamp shift names.
+func LyingSegmentConfig(base PathBaselineConfig, seg int, pTarget float64, shift verification.HopShift) PathBaselineConfig {
+	cfg := base
+	cfg.Segments = slices.Clone(base.Segments)
+	s := &cfg.Segments[seg]
+	s.Targeting = network.DefaultAdversarialTargeting(pTarget)
+	s.DelayModel.TargetedMin = 0.050
+	s.DelayModel.TargetedMax = 0.050
+	s.PFlag = 0.0
+	s.PLie = 1.0
+	s.AnsweringStrategy = verification.AnswerParametric
+	s.HopShift = shift
+	return cfg
+}
+
+// liar is the index of the segment that delays packets, or -1.
+func (cfg PathBaselineConfig) liar() int {
+	return slices.IndexFunc(cfg.Segments, func(s PathSegmentConfig) bool {
+		return targets(s.Targeting)
+	})
+}
+
+// Validate reports a path with more than one segment delaying packets:
+// trials are scored against a single liar, so blaming the second would count
+// as wrong.
+func (cfg PathBaselineConfig) Validate() error {
+	var liars []string
+	for _, s := range cfg.Segments {
+		if targets(s.Targeting) {
+			liars = append(liars, s.Name)
+		}
+	}
+	if len(liars) > 1 {
+		return fmt.Errorf("segments %s all delay packets; a path is scored against one liar", strings.Join(liars, ", "))
+	}
+	return nil
+}
+
+type PathTrialResult struct {
+	TrialNum       int
+	Liar           int // ground truth; -1 on an honest path
+	Blamed         int
+	Ambiguous      bool
+	Blame          []float64
+	Queries        int
+	BrokenHandoffs int
+	Duration       time.Duration
+}
+
+type PathAggregate struct {
+	Config PathBaselineConfig
+	Trials []PathTrialResult
+
+	CorrectAttributionRate float64 // the liar alone got the most blame
+	WrongAttributionRate   float64 // another segment did, or an honest path was blamed
+	AmbiguousRate          float64 // the most blame was tied
+	UnblamedRate           float64 // no segment was blamed at all
+	CorrectAttributionCI   RateCI
+	WrongAttributionCI     RateCI
+
+	MeanBrokenHandoffs float64
+	MeanBlame          []float64 // per segment
+
+	Stopping
+}
+
+func (r *Runner) RunPath(cfg PathBaselineConfig) PathAggregate {
+	return cachedRun(r, "path", cfg.Name, cfg, r.runPath)
+}
+
+func (r *Runner) runPath(cfg PathBaselineConfig) PathAggregate {
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("warning: %s: %v\n", cfg.Name, err)
+		return PathAggregate{Config: cfg}
+	}
+	if r.Verbose {
+		fmt.Printf(">>> %s: N=%d, pkts=%d, B=%d, segments=%d, liar=%d, receipts=%t\n",
+			cfg.Name, cfg.NumTrials, cfg.NumPackets, cfg.BatchSize,
+			len(cfg.Segments), cfg.liar(), cfg.Verification.Receipts)
+	}
+
+	trials, stopping := runAdaptive(r, cfg.Name, cfg.Adaptive, cfg.NumTrials,
+		func(i int) PathTrialResult {
+			r.maybeSeedTrial("path", cfg.Name, i)
+			start := time.Now()
+			t := r.runSinglePathTrial(cfg, i)
+			t.Duration = time.Since(start)
+			return t
+		},
+		func(ts []PathTrialResult) float64 { return aggregatePath(cfg, ts).widestCI() })
+
+	agg := aggregatePath(cfg, trials)
+	agg.Stopping = stopping
+	if r.Verbose {
+		fmt.Printf("    correct=%s  wrong=%s  ambiguous=%.3f  unblamed=%.3f\n",
+			formatRateWithCI(agg.CorrectAttributionRate, agg.CorrectAttributionCI),
+			formatRateWithCI(agg.WrongAttributionRate, agg.WrongAttributionCI),
+			agg.AmbiguousRate, agg.UnblamedRate)
+	}
+	return agg
+}
+
+// pathHop forwards each arrival into the next segment, noting the handoff
+// time as the countersigned receipt both operators would hold.
+type pathHop struct {
+	router   *network.Router
+	next     network.Destination
+	receipts map[int][]float64
+}
+
+func (h *pathHop) Receive(sim *engine.Simulation, pkt network.Packet, pathUsed string) {
+	h.receipts[pkt.ID] = append(h.receipts[pkt.ID], sim.Now)
+	h.router.Forward(sim, network.NewPacket(pkt.ID, pkt.BatchID, pkt.Src, sim.Now), h.next)
+}
+
+// pathSink is the customer's end of the path.
+type pathSink struct {
+	arrived map[int]float64
+}
+
+func (s *pathSink) Receive(sim *engine.Simulation, pkt network.Packet, pathUsed string) {
+	s.arrived[pkt.ID] = sim.Now
+}
+
+func (r *Runner) runSinglePathTrial(cfg PathBaselineConfig, trialNum int) PathTrialResult {
+	sim := engine.NewSimulation()
+
+	k := len(cfg.Segments)
+	routers := make([]*network.Router, k)
+	provers := make([]*verification.Prover, k)
+	for s, seg := range cfg.Segments {
+		dm := network.NewDelayModelConfig(seg.DelayModel)
+		dm.Initialise(cfg.SimDuration + 10.0)
+		prover := verification.NewProver(verification.AdversaryConfig{
+			AnsweringStr: seg.AnsweringStrategy,
+			LieRate:      seg.PLie,
+			HopShift:     seg.HopShift,
+		})
+		router := network.NewRouter(dm, seg.Targeting, Flagging{PFlag: seg.PFlag}.fn())
+		router.OnTransmission = prover.RecordTransmission
+		routers[s], provers[s] = router, prover
+	}
+
+	sink := &pathSink{arrived: make(map[int]float64)}
+	receipts := make(map[int][]float64)
+	var entry network.Destination = sink
+	for s := k - 1; s > 0; s-- {
+		entry = &pathHop{router: routers[s], next: entry, receipts: receipts}
+	}
+
+	batchSize := max(2, cfg.BatchSize)
+	numBatches := max(1, cfg.NumPackets/batchSize)
+	sent := make(map[int]pathSend, cfg.NumPackets)
+	pktID := 0
+	for b := range numBatches {
+		sendTime := float64(b) * (cfg.SimDuration / float64(numBatches))
+		batchID := b
+		for range batchSize {
+			id := pktID
+			pktID++
+			sent[id] = pathSend{BatchID: batchID, Sent: sendTime}
+			sim.Schedule(sendTime, func() {
+				routers[0].Forward(sim, network.NewPacket(id, batchID, "Source", sim.Now), entry)
+			})
+		}
+	}
+	sim.Run(cfg.SimDuration + 10.0)
+
+	packets := make([]verification.PathPacket, 0, len(sink.arrived))
+	for id := range pktID {
+		arrived, ok := sink.arrived[id]
+		if !ok {
+			continue
+		}
+		packets = append(packets, verification.PathPacket{
+			ID: id, BatchID: sent[id].BatchID, Sent: sent[id].Sent, Arrived: arrived,
+			Receipts: receipts[id],
+		})
+	}
+	v := &verification.PathVerifier{Operators: provers, Packets: packets, Config: cfg.Verification}
+	res := v.Run()
+
+	return PathTrialResult{
+		TrialNum:       trialNum,
+		Liar:           cfg.liar(),
+		Blamed:         res.Blamed,
+		Ambiguous:      res.Ambiguous,
+		Blame:          res.Blame,
+		Queries:        res.Queries,
+		BrokenHandoffs: res.BrokenHandoffs,
+	}
+}
+
+// pathSend is when and in which batch a packet entered the path.
+type pathSend struct {
+	BatchID int
+	Sent    float64
+}
+
+func aggregatePath(cfg PathBaselineConfig, trials []PathTrialResult) PathAggregate {
+	agg := PathAggregate{Config: cfg, Trials: trials, MeanBlame: make([]float64, len(cfg.Segments))}
+	n := len(trials)
+	if n == 0 {
+		return agg
+	}
+	var correct, wrong, ambiguous, unblamed, broken int
+	for _, t := range trials {
+		switch {
+		case t.Ambiguous:
+			ambiguous++
+		case t.Blamed < 0:
+			unblamed++
+		case t.Blamed == t.Liar:
+			correct++
+		default:
+			wrong++
+		}
+		broken += t.BrokenHandoffs
+		for s, b := range t.Blame {
+			agg.MeanBlame[s] += b / float64(n)
+		}
+	}
+	fn := float64(n)
+	agg.CorrectAttributionRate = float64(correct) / fn
+	agg.WrongAttributionRate = float64(wrong) / fn
+	agg.AmbiguousRate = float64(ambiguous) / fn
+	agg.UnblamedRate = float64(unblamed) / fn
+	agg.CorrectAttributionCI = wilsonRateCI(correct, n)
+	agg.WrongAttributionCI = wilsonRateCI(wrong, n)
+	agg.MeanBrokenHandoffs = float64(broken) / fn
+	return agg
+}
+
+func (r *Runner) SavePathAggregates(path string, results []PathAggregate) error {
+	return r.saveResults(path, results)
+}
+
+// SweepPathBlame makes each segment in turn the liar, for each way of hiding
+// the delay, without and then with handoff receipts. An all-honest path comes
+// first, where any blame is a false accusation.
+func (r *Runner) SweepPathBlame(base PathBaselineConfig, pTarget float64) []PathAggregate {
+	shifts := []verification.HopShift{verification.ShiftExit, verification.ShiftEntry, verification.ShiftEither}
+	fmt.Printf("\n=== Path: blame attribution sweep (%d segments) [%s] ===\n", len(base.Segments), base.Name)
+	out := []PathAggregate{}
+	honest := base
+	honest.Name = fmt.Sprintf("%s_honest", base.Name)
+	out = append(out, r.RunPath(honest))
+	for _, receipts := range []bool{false, true} {
+		for seg := range base.Segments {
+			for _, shift := range shifts {
+				cfg := LyingSegmentConfig(base, seg, pTarget, shift)
+				cfg.Verification.Receipts = receipts
+				cfg.Name = fmt.Sprintf("%s_liar%s_shift%s_receipts%t", base.Name, base.Segments[seg].Name, hopShiftName(shift), receipts)
+				out = append(out, r.RunPath(cfg))
+			}
+		}
+	}
+	return out
+}
+
+func hopShiftName(s verification.HopShift) string {
+	if s == verification.ShiftExit {
+		return "EXIT"
+	}
+	return string(s)
+}
@@ -0,0 +1,713 @@
+package experiment
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ScenarioFile is an experiment written down as data instead of code: a baseline
+// and the sweeps to run from it, with where each one's results go. In JSON:
+//
+//	{
+//	  "name": "incompetent_headline",
+//	  "kind": "incompetent",
+//	  "seeds": [0, 2, 3],
+//	  "base": {"NumTrials": 200, "Verification": {"ConfidenceThreshold": 0.999}},
+//	  "runs": [
+//	    {"id": "p_incomp", "sweep": "IncompetenceRate",
+//	     "values": {"logspace": [1e-4, 0.3, 40]},
+//	     "output": "results/incompetent/seed_{seed}/incompetence_rate_sweep.json"}
+//	  ]
+//	}
+//
+// "base" and each run's "set" are written onto the kind's default config by
+// Go field name, so any field of the config can be set; unknown names are an
+// error. The sweeps and presets each kind accepts are listed by ScenarioKinds.
+type ScenarioFile struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Kind        string          `json:"kind"`               // scenario, population, honest, incompetent, malicious or path
+	Seeds       []int64         `json:"seeds,omitempty"`    // base seeds to repeat every run for; 0 is the -seed one
+	Timeline    string          `json:"timeline,omitempty"` // timeline file applied to every config
+	Base        json.RawMessage `json:"base,omitempty"`
+	Runs        []ScenarioRun   `json:"runs"`
+}
+
+// ScenarioRun is one sweep from the scenario's base. Its config is the base,
+// then the preset, then "set".
+type ScenarioRun struct {
+	ID          string `json:"id"`
+	Description string `json:"description,omitempty"`
+	Name        string `json:"name,omitempty"` // config name, which also seeds the trials; empty keeps the base's
+	Variant
+	Sweep   string    `json:"sweep"`
+	Values  Values    `json:"values,omitempty"`
+	Values2 Values    `json:"values2,omitempty"` // second axis of a phase map
+	Ints    []int     `json:"ints,omitempty"`
+	Params  []float64 `json:"params,omitempty"` // the sweep's fixed arguments, in order
+	After   *Variant  `json:"after,omitempty"`  // behaviour after a regime switch
+	Grid    Grid      `json:"grid,omitempty"`
+	Derived []Derived `json:"derived,omitempty"`
+	Output  string    `json:"output"` // "{seed}" is replaced by the seed
+}
+
+// Variant derives a config from the scenario's base.
+type Variant struct {
+	Preset *Preset         `json:"preset,omitempty"`
+	Set    json.RawMessage `json:"set,omitempty"`
+}
+
+// Preset is one of the named strategy constructors, such as NaiveLiarConfig.
+type Preset struct {
+	Name string    `json:"name"`
+	Args []float64 `json:"args,omitempty"`
+}
+
+// Values is a list of numbers, written out or as a generator:
+//
+//	[0.1, 0.2, 0.5]
+//	{"linspace": [0, 1, 25]}
+//	{"logspace": [1e-3, 0.5, 30]}
+//	{"alphaLogspace": [0.5, 1e-10, 20]}
+//
+// alphaLogspace is 1 minus a logspace, for confidence thresholds crowding
+// towards 1.
+type Values []float64
+
+func (v *Values) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		var xs []float64
+		if err := json.Unmarshal(b, &xs); err != nil {
+			return err
+		}
+		*v = xs
+		return nil
+	}
+	var gen map[string][]float64
+	if err := json.Unmarshal(b, &gen); err != nil {
+		return fmt.Errorf("values must be a list or a generator: %w", err)
+	}
+	if len(gen) != 1 {
+		return errors.New("a values generator has exactly one of linspace, logspace or alphaLogspace")
+	}
+	for name, args := range gen {
+		if len(args) != 3 || args[2] < 1 || args[2] != math.Trunc(args[2]) {
+			return fmt.Errorf("%s takes [from, to, count]", name)
+		}
+		lo, hi, n := args[0], args[1], int(args[2])
+		switch name {
+		case "linspace":
+			*v = linspace(lo, hi, n)
+		case "logspace":
+			if lo <= 0 || hi <= 0 {
+				return errors.New("logspace bounds must be positive")
+			}
+			*v = logspace(lo, hi, n)
+		case "alphaLogspace":
+			if lo <= 0 || hi <= 0 {
+				return errors.New("alphaLogspace bounds must be positive")
+			}
+			*v = alphaLogspace(lo, hi, n)
+		default:
+			return fmt.Errorf("unknown values generator %q", name)
+		}
+	}
+	return nil
+}
+
+func logspace(lo, hi float64, n int) []float64 {
+	if n < 2 {
+		return []float64{lo}
+	}
+	out := make([]float64, n)
+	logLo, logHi := math.Log(lo), math.Log(hi)
+	for i := range n {
+		t := float64(i) / float64(n-1)
+		out[i] = math.Exp(logLo + t*(logHi-logLo))
+	}
+	return out
+}
+
+func linspace(lo, hi float64, n int) []float64 {
+	if n < 2 {
+		return []float64{lo}
+	}
+	out := make([]float64, n)
+	for i := range n {
+		t := float64(i) / float64(n-1)
+		out[i] = lo + t*(hi-lo)
+	}
+	return out
+}
+
+func alphaLogspace(oneMinusHi, oneMinusLo float64, n int) []float64 {
+	raw := logspace(oneMinusHi, oneMinusLo, n)
+	alphas := make([]float64, len(raw))
+	for i, v := range raw {
+		alphas[i] = 1 - v
+	}
+	return alphas
+}
+
+// ParseScenario reads and validates a scenario. Timeline paths are not
+// opened until the scenario runs or is validated with CheckFiles.
+func ParseScenario(data []byte) (ScenarioFile, error) {
+	var sc ScenarioFile
+	if err := decodeStrict(data, &sc); err != nil {
+		return ScenarioFile{}, err
+	}
+	if err := sc.Validate(); err != nil {
+		return ScenarioFile{}, err
+	}
+	return sc, nil
+}
+
+// LoadScenario reads a scenario file, or failing that the bundled scenario
+// of that name.
+func LoadScenario(name string) (ScenarioFile, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = bundledScenarios.ReadFile("scenarios/" + strings.TrimSuffix(name, ".json") + ".json")
+		if err != nil {
+			return ScenarioFile{}, fmt.Errorf("%s: no such file or bundled scenario", name)
+		}
+	} else if err != nil {
+		return ScenarioFile{}, err
+	}
+	sc, err := ParseScenario(data)
+	if err != nil {
+		return ScenarioFile{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return sc, nil
+}
+
+//go:embed scenarios/*.json
+var bundledScenarios embed.FS
+
+// BundledScenarios are the experiments that ship with the simulator, by name.
+func BundledScenarios() ([]ScenarioFile, error) {
+	entries, err := bundledScenarios.ReadDir("scenarios")
+	if err != nil {
+		return nil, err
+	}
+	var out []ScenarioFile
+	for _, e := range entries {
+		data, err := bundledScenarios.ReadFile(path.Join("scenarios", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sc, err := ParseScenario(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		out = append(out, sc)
+	}
+	return out, nil
+}
+
+func decodeStrict(data []byte, v any) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Validate checks everything short of running: that the kind, sweeps and
+// presets exist, that every run has the inputs its sweep takes and no others,
+// and that base, presets and "set" all apply to the kind's config.
+func (sc ScenarioFile) Validate() error {
+	if sc.Name == "" {
+		return errors.New("scenario has no name")
+	}
+	kind, ok := scenarioKinds[sc.Kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q", sc.Kind)
+	}
+	if len(sc.Runs) == 0 {
+		return errors.New("scenario has no runs")
+	}
+	ids := make(map[string]bool)
+	for i, run := range sc.Runs {
+		if run.ID == "" {
+			return fmt.Errorf("run %d has no id", i)
+		}
+		if ids[run.ID] {
+			return fmt.Errorf("run id %q is used twice", run.ID)
+		}
+		ids[run.ID] = true
+		if run.Output == "" {
+			return fmt.Errorf("run %s has no output", run.ID)
+		}
+		if err := kind.check(sc, run); err != nil {
+			return fmt.Errorf("run %s: %w", run.ID, err)
+		}
+	}
+	return nil
+}
+
+// CheckFiles checks what Validate cannot: that the timeline loads.
+func (sc ScenarioFile) CheckFiles() error {
+	if sc.Timeline == "" {
+		return nil
+	}
+	_, err := LoadTimeline(sc.Timeline)
+	return err
+}
+
+// RunScenario runs every run of sc, or only those named in only, for each of
+// its seeds, and saves each run's aggregates to its output. A timeline with
+// events replaces the scenario's own. It keeps going past a failed save and
+// returns the first error.
+func (r *Runner) RunScenario(sc ScenarioFile, baseSeed int64, only []string, timeline Timeline) error {
+	kind := scenarioKinds[sc.Kind]
+	if len(timeline.Events) == 0 && sc.Timeline != "" {
+		tl, err := LoadTimeline(sc.Timeline)
+		if err != nil {
+			return err
+		}
+		timeline = tl
+	}
+	for _, id := range only {
+		if !slices.ContainsFunc(sc.Runs, func(run ScenarioRun) bool { return run.ID == id }) {
+			return fmt.Errorf("%s has no run %q", sc.Name, id)
+		}
+	}
+
+	seeds := sc.Seeds
+	if len(seeds) == 0 {
+		seeds = []int64{0}
+	}
+	var firstErr error
+	for _, seed := range seeds {
+		if seed == 0 {
+			seed = baseSeed
+		}
+		r.SetBaseSeed(seed)
+		fmt.Println("\n================================================================================")
+		fmt.Printf("     %s (%s, seed %d)\n", sc.Name, sc.Kind, seed)
+		fmt.Println("================================================================================")
+		for _, run := range sc.Runs {
+			if len(only) > 0 && !slices.Contains(only, run.ID) {
+				continue
+			}
+			out := strings.ReplaceAll(run.Output, "{seed}", strconv.FormatInt(seed, 10))
+			if err := kind.run(r, sc, run, timeline, out); err != nil {
+				fmt.Printf("warning: could not save %s: %v\n", out, err)
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	}
+	r.SetBaseSeed(baseSeed)
+	return firstErr
+}
+
+// ScenarioKind describes what a scenario of one kind can ask for.
+type ScenarioKind struct {
+	Name    string
+	Sweeps  []string // each with the inputs it takes
+	Presets []string // each with its arguments
+}
+
+func ScenarioKinds() []ScenarioKind {
+	var out []ScenarioKind
+	for _, name := range slices.Sorted(maps.Keys(scenarioKinds)) {
+		k := ScenarioKind{Name: name}
+		k.Sweeps, k.Presets = scenarioKinds[name].describe()
+		out = append(out, k)
+	}
+	return out
+}
+
+type scenarioKind interface {
+	check(sc ScenarioFile, run ScenarioRun) error
+	run(r *Runner, sc ScenarioFile, run ScenarioRun, timeline Timeline, out string) error
+	describe() (sweeps, presets []string)
+}
+
+// kindOf is a scenario kind with config type C and aggregate type A.
+type kindOf[C, A any] struct {
+	defaults func() C
+	name     func(*C) *string
+	timeline func(*C) *Timeline // nil when the config has no timeline
+	save     func(r *Runner, path string, out []A) error
+	sweeps   map[string]scenarioSweep[C, A]
+	presets  map[string]scenarioPreset[C]
+}
+
+// sweepInputs names what a sweep reads from the run; "" and nil mean it
+// takes no such input.
+type sweepInputs struct {
+	values, values2, ints string
+	params                []string
+	after                 bool
+	grid                  bool // grid and derived, checked by GridConfigs
+}
+
+type scenarioSweep[C, A any] struct {
+	inputs   sweepInputs
+	validate func(cfg C, run ScenarioRun) error // nil when the inputs say it all
+	run      func(r *Runner, cfg C, run ScenarioRun, after C) []A
+}
+
+type scenarioPreset[C any] struct {
+	args  []string
+	apply func(cfg C, args []float64) C
+}
+
+func (k kindOf[C, A]) config(sc ScenarioFile, v Variant) (C, error) {
+	cfg := k.defaults()
+	if err := decodeStrict(sc.Base, &cfg); err != nil {
+		return cfg, fmt.Errorf("base: %w", err)
+	}
+	if v.Preset != nil {
+		p, ok := k.presets[v.Preset.Name]
+		if !ok {
+			return cfg, fmt.Errorf("unknown preset %q", v.Preset.Name)
+		}
+		if len(v.Preset.Args) != len(p.args) {
+			return cfg, fmt.Errorf("preset %s takes %d args (%s)", v.Preset.Name, len(p.args), strings.Join(p.args, ", "))
+		}
+		cfg = p.apply(cfg, v.Preset.Args)
+	}
+	if err := decodeStrict(v.Set, &cfg); err != nil {
+		return cfg, fmt.Errorf("set: %w", err)
+	}
+	return cfg, validate(cfg)
+}
+
+// validate runs cfg's Validate method, if its type has one.
+func validate(cfg any) error {
+	if v, ok := cfg.(interface{ Validate() error }); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+func (k kindOf[C, A]) check(sc ScenarioFile, run ScenarioRun) error {
+	sw, ok := k.sweeps[run.Sweep]
+	if !ok {
+		return fmt.Errorf("unknown sweep %q", run.Sweep)
+	}
+	in := sw.inputs
+	for _, c := range []struct {
+		field string
+		want  bool
+		given bool
+	}{
+		{"values", in.values != "", len(run.Values) > 0},
+		{"values2", in.values2 != "", len(run.Values2) > 0},
+		{"ints", in.ints != "", len(run.Ints) > 0},
+		{"after", in.after, run.After != nil},
+		{"grid", in.grid, len(run.Grid) > 0},
+	} {
+		if c.want && !c.given {
+			return fmt.Errorf("%s needs %s", run.Sweep, c.field)
+		}
+		if !c.want && c.given {
+			return fmt.Errorf("%s takes no %s", run.Sweep, c.field)
+		}
+	}
+	if !in.grid && len(run.Derived) > 0 {
+		return fmt.Errorf("%s takes no derived", run.Sweep)
+	}
+	if len(run.Params) != len(in.params) {
+		return fmt.Errorf("%s takes %d params (%s)", run.Sweep, len(in.params), strings.Join(in.params, ", "))
+	}
+	cfg, err := k.config(sc, run.Variant)
+	if err != nil {
+		return err
+	}
+	if run.After != nil {
+		if _, err := k.config(sc, *run.After); err != nil {
+			return fmt.Errorf("after: %w", err)
+		}
+	}
+	if sw.validate != nil {
+		return sw.validate(cfg, run)
+	}
+	return nil
+}
+
+func (k kindOf[C, A]) run(r *Runner, sc ScenarioFile, run ScenarioRun, timeline Timeline, out string) error {
+	cfg, err := k.config(sc, run.Variant)
+	if err != nil {
+		return err
+	}
+	var after C
+	if run.After != nil {
+		if after, err = k.config(sc, *run.After); err != nil {
+			return err
+		}
+	}
+	for _, c := range []*C{&cfg, &after} {
+		if run.Name != "" {
+			*k.name(c) = run.Name
+		}
+		if k.timeline != nil && len(timeline.Events) > 0 {
+			*k.timeline(c) = timeline
+		}
+	}
+	sw := k.sweeps[run.Sweep]
+	if sw.validate != nil {
+		if err := sw.validate(cfg, run); err != nil {
+			return err
+		}
+	}
+	return k.save(r, out, sw.run(r, cfg, run, after))
+}
+
+func (k kindOf[C, A]) describe() (sweeps, presets []string) {
+	for _, name := range slices.Sorted(maps.Keys(k.sweeps)) {
+		in := k.sweeps[name].inputs
+		var parts []string
+		for _, p := range []struct{ field, what string }{
+			{"values", in.values}, {"values2", in.values2}, {"ints", in.ints},
+		} {
+			if p.what != "" {
+				parts = append(parts, p.field+"="+p.what)
+			}
+		}
+		if len(in.params) > 0 {
+			parts = append(parts, "params=["+strings.Join(in.params, ", ")+"]")
+		}
+		if in.after {
+			parts = append(parts, "after")
+		}
+		if in.grid {
+			parts = append(parts, "grid [derived]")
+		}
+		sweeps = append(sweeps, strings.TrimSpace(name+" "+strings.Join(parts, " ")))
+	}
+	for _, name := range slices.Sorted(maps.Keys(k.presets)) {
+		presets = append(presets, name+"("+strings.Join(k.presets[name].args, ", ")+")")
+	}
+	return sweeps, presets
+}
+
+func single[C, A any](f func(*Runner, C) A) scenarioSweep[C, A] {
+	return scenarioSweep[C, A]{run: func(r *Runner, cfg C, _ ScenarioRun, _ C) []A {
+		return []A{f(r, cfg)}
+	}}
+}
+
+// fixed is a sweep whose points are built in, like the targeting modes.
+func fixed[C, A any](f func(*Runner, C) []A) scenarioSweep[C, A] {
+	return scenarioSweep[C, A]{run: func(r *Runner, cfg C, _ ScenarioRun, _ C) []A {
+		return f(r, cfg)
+	}}
+}
+
+// grid runs one config per point of the run's grid; see GridConfigs.
+func grid[C, A any](f func(*Runner, C) A) scenarioSweep[C, A] {
+	return scenarioSweep[C, A]{
+		inputs: sweepInputs{grid: true},
+		validate: func(cfg C, run ScenarioRun) error {
+			cfgs, err := GridConfigs(cfg, run.Grid, run.Derived)
+			if err != nil {
+				return err
+			}
+			for _, c := range cfgs {
+				if err := validate(c); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		run: func(r *Runner, cfg C, run ScenarioRun, _ C) []A {
+			cfgs, _ := GridConfigs(cfg, run.Grid, run.Derived)
+			return runAll(func(c C) A { return f(r, c) }, cfgs)
+		},
+	}
+}
+
+func overValues[C, A any](what string, f func(*Runner, C, []float64) []A) scenarioSweep[C, A] {
+	return scenarioSweep[C, A]{inputs: sweepInputs{values: what}, run: func(r *Runner, cfg C, run ScenarioRun, _ C) []A {
+		return f(r, cfg, run.Values)
+	}}
+}
+
+func overInts[C, A any](what string, f func(*Runner, C, []int) []A) scenarioSweep[C, A] {
+	return scenarioSweep[C, A]{inputs: sweepInputs{ints: what}, run: func(r *Runner, cfg C, run ScenarioRun, _ C) []A {
+		return f(r, cfg, run.Ints)
+	}}
+}
+
+func overGrid[C, A any](what, what2 string, f func(*Runner, C, []float64, []float64) []A) scenarioSweep[C, A] {
+	return scenarioSweep[C, A]{inputs: sweepInputs{values: what, values2: what2}, run: func(r *Runner, cfg C, run ScenarioRun, _ C) []A {
+		return f(r, cfg, run.Values, run.Values2)
+	}}
+}
+
+var scenarioKinds = map[string]scenarioKind{
+	// scenario composes any behaviour, mixed ones included, and scores it
+	// against the truth it implies.
+	"scenario": kindOf[Scenario, Aggregate]{
+		defaults: DefaultScenario,
+		name:     func(c *Scenario) *string { return &c.Name },
+		timeline: func(c *Scenario) *Timeline { return &c.Timeline },
+		save:     (*Runner).SaveScenarioAggregates,
+		sweeps: map[string]scenarioSweep[Scenario, Aggregate]{
+			"Single": single((*Runner).Run),
+			"Grid":   grid((*Runner).Run),
+		},
+	},
+
+	// population draws each trial's operator from weighted members and
+	// scores the verifier on all of them at once.
+	"population": kindOf[Population, PopulationAggregate]{
+		defaults: DefaultPopulation,
+		name:     func(c *Population) *string { return &c.Name },
+		save:     (*Runner).SavePopulationAggregates,
+		sweeps: map[string]scenarioSweep[Population, PopulationAggregate]{
+			"Single": single((*Runner).RunPopulation),
+			"Grid":   grid((*Runner).RunPopulation),
+		},
+	},
+
+	"honest": kindOf[HonestBaselineConfig, HonestAggregate]{
+		defaults: DefaultHonestBaseline,
+		name:     func(c *HonestBaselineConfig) *string { return &c.Name },
+		timeline: func(c *HonestBaselineConfig) *Timeline { return &c.Timeline },
+		save:     (*Runner).SaveAggregates,
+		sweeps: map[string]scenarioSweep[HonestBaselineConfig, HonestAggregate]{
+			"Single":         single((*Runner).RunHonest),
+			"Grid":           grid((*Runner).RunHonest),
+			"Eta":            overValues("η", (*Runner).SweepHonestEta),
+			"Alpha":          overValues("α", (*Runner).SweepHonestAlpha),
+			"Epsilon":        overValues("ε", (*Runner).SweepHonestEpsilon),
+			"TransitionRate": overValues("λ", (*Runner).SweepHonestTransitionRate),
+			"Batch":          overInts("B", (*Runner).SweepHonestBatch),
+			"NumPackets":     overInts("N", (*Runner).SweepHonestNumPackets),
+		},
+	},
+
+	"incompetent": kindOf[IncompetentBaselineConfig, IncompetentAggregate]{
+		defaults: DefaultIncompetentBaseline,
+		name:     func(c *IncompetentBaselineConfig) *string { return &c.Name },
+		timeline: func(c *IncompetentBaselineConfig) *Timeline { return &c.Timeline },
+		save:     (*Runner).SaveIncompetentAggregates,
+		sweeps: map[string]scenarioSweep[IncompetentBaselineConfig, IncompetentAggregate]{
+			"Single":           single((*Runner).RunIncompetent),
+			"Grid":             grid((*Runner).RunIncompetent),
+			"IncompetenceRate": overValues("p_incomp", (*Runner).SweepIncompetenceRate),
+			"FlagReliability":  overValues("flag reliability", (*Runner).SweepFlagReliability),
+			"PhaseMap":         overGrid("p_incomp", "flag reliability", (*Runner).SweepIncompetentPhaseMap),
+			"AnswerErrorRate":  overValues("answer error rate", (*Runner).SweepAnswerErrorRate),
+			"Eta":              overValues("η", (*Runner).SweepIncompetentEta),
+			"Alpha":            overValues("α", (*Runner).SweepIncompetentAlpha),
+			"Magnitude":        overValues("µ", (*Runner).SweepIncompetenceMagnitude),
+			"FlagThreshold":    overValues("τ_flag", (*Runner).SweepIncompetentFlagThreshold),
+			"NumPackets":       overInts("N", (*Runner).SweepIncompetentNumPackets),
+			"BatchSize":        overInts("B", (*Runner).SweepIncompetentBatchSize),
+			"QueriesPerBatch":  overInts("queries per batch", (*Runner).SweepIncompetentQueriesPerBatch),
+		},
+	},
+
+	"malicious": kindOf[MaliciousBaselineConfig, MaliciousAggregate]{
+		defaults: DefaultMaliciousBaseline,
+		name:     func(c *MaliciousBaselineConfig) *string { return &c.Name },
+		timeline: func(c *MaliciousBaselineConfig) *Timeline { return &c.Timeline },
+		save:     (*Runner).SaveMaliciousAggregates,
+		sweeps: map[string]scenarioSweep[MaliciousBaselineConfig, MaliciousAggregate]{
+			"Single":         single((*Runner).RunMalicious),
+			"Grid":           grid((*Runner).RunMalicious),
+			"PTarget":        overValues("p_target", (*Runner).SweepMaliciousPTarget),
+			"PFlag":          overValues("p_flag", (*Runner).SweepMaliciousPFlag),
+			"PLie":           overValues("p_lie", (*Runner).SweepMaliciousPLie),
+			"PhaseMap":       overGrid("p_target", "p_lie", (*Runner).SweepMaliciousPhaseMap),
+			"MinimumQueries": overValues("p_target", (*Runner).SweepMaliciousMinimumQueries),
+			"SafeLiars":      overValues("p_target", (*Runner).SweepMaliciousSafeLiars),
+			"TargetingModes": fixed((*Runner).SweepMaliciousTargetingModes),
+			"DelayBounds":    fixed((*Runner).SweepMaliciousDelayBounds),
+			"TimeoutPolicies": {
+				inputs: sweepInputs{params: []string{"p_target"}},
+				run: func(r *Runner, cfg MaliciousBaselineConfig, run ScenarioRun, _ MaliciousBaselineConfig) []MaliciousAggregate {
+					return r.SweepMaliciousTimeoutPolicies(cfg, run.Params[0])
+				},
+			},
+			"ProbeDistinguishability": {
+				inputs: sweepInputs{values: "distinguishability", params: []string{"probe rate"}},
+				run: func(r *Runner, cfg MaliciousBaselineConfig, run ScenarioRun, _ MaliciousBaselineConfig) []MaliciousAggregate {
+					return r.SweepMaliciousProbeDistinguishability(cfg, run.Params[0], run.Values)
+				},
+			},
+			"OverFlagging": {
+				inputs: sweepInputs{values: "p_overflag", params: []string{"p_target"}},
+				run: func(r *Runner, cfg MaliciousBaselineConfig, run ScenarioRun, _ MaliciousBaselineConfig) []MaliciousAggregate {
+					return r.SweepMaliciousOverFlagging(cfg, run.Params[0], run.Values)
+				},
+			},
+			"RegimeForgetting": {
+				inputs: sweepInputs{values: "forgetting", ints: "window", params: []string{"start", "start max"}, after: true},
+				run: func(r *Runner, cfg MaliciousBaselineConfig, run ScenarioRun, after MaliciousBaselineConfig) []MaliciousAggregate {
+					return r.SweepRegimeForgetting(cfg, after, run.Params[0], run.Params[1], run.Values, run.Ints)
+				},
+			},
+			"CoalitionSize": {
+				inputs: sweepInputs{ints: "members", params: []string{"clients", "victims"}},
+				run: func(r *Runner, cfg MaliciousBaselineConfig, run ScenarioRun, _ MaliciousBaselineConfig) []MaliciousAggregate {
+					return r.SweepCoalitionSize(cfg, int(run.Params[0]), int(run.Params[1]), run.Ints)
+				},
+			},
+		},
+		presets: map[string]scenarioPreset[MaliciousBaselineConfig]{
+			"NaiveLiar": {[]string{"p_target"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig { return NaiveLiarConfig(c, a[0]) }},
+			"SilentDropper": {[]string{"p_target"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig {
+				return SilentDropperConfig(c, a[0])
+			}},
+			"SmartStrategy": {[]string{"p_target"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig {
+				return SmartStrategyConfig(c, a[0])
+			}},
+			"Parametric": {[]string{"p_target", "p_flag", "p_lie"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig {
+				return ParametricConfig(c, a[0], a[1], a[2])
+			}},
+			"OverFlagger": {[]string{"p_target", "p_overflag"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig {
+				return OverFlaggerConfig(c, a[0], a[1])
+			}},
+			"SafeLiar": {[]string{"p_target", "p_flag"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig {
+				return SafeLiarConfig(c, a[0], a[1])
+			}},
+			"WholeBatchLiar": {nil, func(c MaliciousBaselineConfig, _ []float64) MaliciousBaselineConfig { return WholeBatchLiarConfig(c) }},
+			"RandomAnswerer": {[]string{"p_target"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig {
+				return RandomAnswererConfig(c, a[0])
+			}},
+			// Aggressive spends the SLA's flag budget exactly: p_flag is
+			// AggressivePFlag at the config's τ_flag.
+			"Aggressive": {[]string{"p_target", "p_lie"}, func(c MaliciousBaselineConfig, a []float64) MaliciousBaselineConfig {
+				return ParametricConfig(c, a[0], AggressivePFlag(a[0], c.Verification.FlaggingRateThreshold), a[1])
+			}},
+			"GlobalInflation": {nil, func(c MaliciousBaselineConfig, _ []float64) MaliciousBaselineConfig { return GlobalInflationConfig(c) }},
+		},
+	},
+
+	"path": kindOf[PathBaselineConfig, PathAggregate]{
+		defaults: DefaultPathBaseline,
+		name:     func(c *PathBaselineConfig) *string { return &c.Name },
+		save:     (*Runner).SavePathAggregates,
+		sweeps: map[string]scenarioSweep[PathBaselineConfig, PathAggregate]{
+			"Single": single((*Runner).RunPath),
+			"Grid":   grid((*Runner).RunPath),
+			"PathBlame": {
+				inputs: sweepInputs{params: []string{"p_target"}},
+				run: func(r *Runner, cfg PathBaselineConfig, run ScenarioRun, _ PathBaselineConfig) []PathAggregate {
+					return r.SweepPathBlame(cfg, run.Params[0])
+				},
+			},
+		},
+	},
+}
@@ -0,0 +1,171 @@
+package experiment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+func TestBundledScenariosValidate(t *testing.T) {
+	scenarios, err := BundledScenarios()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) == 0 {
+		t.Fatal("no bundled scenarios")
+	}
+	outputs := make(map[string]string)
+	for _, sc := range scenarios {
+		for _, run := range sc.Runs {
+			if prev, ok := outputs[run.Output]; ok {
+				t.Errorf("%s/%s writes %s, as does %s", sc.Name, run.ID, run.Output, prev)
+			}
+			outputs[run.Output] = sc.Name + "/" + run.ID
+		}
+	}
+}
+
+// The bundled scenarios replaced hand-built configs; spot-check that they
+// still build the same ones.
+func TestScenarioConfigsMatchHandBuilt(t *testing.T) {
+	sc, err := LoadScenario("malicious_diagnostics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kind := scenarioKinds["malicious"].(kindOf[MaliciousBaselineConfig, MaliciousAggregate])
+	runs := make(map[string]ScenarioRun)
+	for _, run := range sc.Runs {
+		runs[run.ID] = run
+	}
+
+	base := DefaultMaliciousBaseline()
+	base.NumTrials = 200
+	base.NumPackets = 10000
+	base.BatchSize = 10
+	base.SimDuration = 1000.0
+	base.Verification.ConfidenceThreshold = 0.999
+
+	want := base
+	want.DelayModel.TargetedMin = 0.050
+	want.DelayModel.TargetedMax = 0.050
+	want.Streaming = verification.DefaultStreamingConfig()
+	want.Streaming.Channel.LossRate = 0.01
+	got, err := kind.config(sc, runs["stalling"].Variant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Streaming != want.Streaming || got.DelayModel != want.DelayModel || got.Verification.ConfidenceThreshold != 0.999 {
+		t.Errorf("stalling config:\n got %+v\nwant %+v", got.Streaming, want.Streaming)
+	}
+
+	got, err = kind.config(sc, runs["coalition_naive"].Variant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NaiveLiarConfig(base, 0.5); got.Targeting.TargetFraction != want.Targeting.TargetFraction || got.PLie != want.PLie {
+		t.Errorf("coalition_naive: p_target %v p_lie %v, want %v %v", got.Targeting.TargetFraction, got.PLie, want.Targeting.TargetFraction, want.PLie)
+	}
+
+	head, err := LoadScenario("malicious_headline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, run := range head.Runs {
+		if run.ID != "aggressive_x5" {
+			continue
+		}
+		got, err := kind.config(head, run.Variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tau := got.Verification.FlaggingRateThreshold
+		if want := AggressivePFlag(5*tau, tau); got.PFlag != want {
+			t.Errorf("aggressive_x5 p_flag = %v, want %v", got.PFlag, want)
+		}
+	}
+}
+
+func TestValuesGenerators(t *testing.T) {
+	sc, err := ParseScenario([]byte(`{"name": "v", "kind": "honest", "runs": [
+		{"id": "a", "sweep": "Alpha", "values": {"alphaLogspace": [0.5, 1e-10, 20]}, "output": "a.json"},
+		{"id": "b", "sweep": "Eta", "values": {"linspace": [0, 0.4, 5]}, "output": "b.json"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sc.Runs[0].Values, Values(alphaLogspace(0.5, 1e-10, 20)); len(got) != len(want) || got[0] != want[0] || got[19] != want[19] {
+		t.Errorf("alphaLogspace = %v", got)
+	}
+	if got := sc.Runs[1].Values; len(got) != 5 || got[4] != 0.4 {
+		t.Errorf("linspace = %v", got)
+	}
+}
+
+func TestScenarioValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name, runs, wantErr string
+	}{
+		{"UnknownSweep", `{"id": "a", "sweep": "Nope", "output": "a.json"}`, "unknown sweep"},
+		{"MissingValues", `{"id": "a", "sweep": "PTarget", "output": "a.json"}`, "needs values"},
+		{"UnusedInts", `{"id": "a", "sweep": "PTarget", "values": [0.1], "ints": [1], "output": "a.json"}`, "takes no ints"},
+		{"WrongParams", `{"id": "a", "sweep": "TimeoutPolicies", "output": "a.json"}`, "takes 1 params"},
+		{"UnknownPreset", `{"id": "a", "sweep": "Single", "preset": {"name": "Nope"}, "output": "a.json"}`, "unknown preset"},
+		{"PresetArgs", `{"id": "a", "sweep": "Single", "preset": {"name": "NaiveLiar"}, "output": "a.json"}`, "takes 1 args"},
+		{"UnknownField", `{"id": "a", "sweep": "Single", "set": {"PTargt": 0.1}, "output": "a.json"}`, "unknown field"},
+		{"BadMode", `{"id": "a", "sweep": "Single", "set": {"Targeting": {"Mode": "SOMETIMES"}}, "output": "a.json"}`, "SOMETIMES"},
+		{"GridField", `{"id": "a", "sweep": "Grid", "grid": [{"field": "Nope", "values": [1]}], "output": "a.json"}`, "no field Nope"},
+		{"DerivedWithoutGrid", `{"id": "a", "sweep": "Single", "derived": [{"field": "PFlag", "func": "Copy", "args": ["PLie"]}], "output": "a.json"}`, "takes no derived"},
+		{"NoOutput", `{"id": "a", "sweep": "Single"}`, "no output"},
+		{"DuplicateID", `{"id": "a", "sweep": "Single", "output": "a.json"}, {"id": "a", "sweep": "Single", "output": "b.json"}`, "used twice"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(`{"name": "v", "kind": "malicious", "runs": [` + tc.runs + `]}`))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("got %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	if _, err := ParseScenario([]byte(`{"name": "v", "kind": "nope", "runs": []}`)); err == nil {
+		t.Error("an unknown kind should not validate")
+	}
+	ok := `{"id": "a", "sweep": "Single", "set": {"Targeting": {"Mode": "RANDOM", "TargetFraction": 0.1}}, "output": "a.json"}`
+	sc, err := ParseScenario([]byte(`{"name": "v", "kind": "malicious", "runs": [` + ok + `]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := scenarioKinds["malicious"].(kindOf[MaliciousBaselineConfig, MaliciousAggregate]).config(sc, sc.Runs[0].Variant)
+	if cfg.Targeting.Mode != network.TargetRandom {
+		t.Errorf("mode %q decoded as %v", "RANDOM", cfg.Targeting.Mode)
+	}
+}
+
+func TestRunScenario(t *testing.T) {
+	dir := t.TempDir()
+	sc, err := ParseScenario([]byte(`{
+		"name": "tiny", "kind": "malicious", "seeds": [0, 7],
+		"base": {"NumTrials": 2, "NumPackets": 100, "SimDuration": 20},
+		"runs": [
+			{"id": "naive", "name": "tiny_naive", "preset": {"name": "NaiveLiar", "args": [0.2]},
+			 "sweep": "PTarget", "values": [0.1, 0.3], "output": "` + filepath.ToSlash(dir) + `/seed_{seed}/naive.json"},
+			{"id": "skipped", "sweep": "Single", "output": "` + filepath.ToSlash(dir) + `/skipped.json"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner := NewRunner()
+	runner.Verbose = false
+	if err := runner.RunScenario(sc, 42, []string{"naive"}, Timeline{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"seed_42/naive.json", "seed_7/naive.json"} {
+		if _, err := os.Stat(filepath.Join(dir, p)); err != nil {
+			t.Errorf("%s was not written: %v", p, err)
+		}
+	}
+	if err := runner.RunScenario(sc, 42, []string{"nope"}, Timeline{}); err == nil {
+		t.Error("an unknown run id should be an error")
+	}
+}
@@ -0,0 +1,303 @@
+package experiment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Axis is one swept config field, named by its Go field path from the config
+// root, such as "Verification.ErrorTolerance" or "Targeting.Mode". Numeric
+// fields take Values; string and enum fields take Names, which are written the
+// way a scenario's "set" would write them. A name that is not a string the
+// field accepts is read as JSON, so bool and struct fields take names such as
+// "true" or a whole object. Integer fields reject non-integral values.
+type Axis struct {
+	Field  string   `json:"field"`
+	Values Values   `json:"values,omitempty"`
+	Names  []string `json:"names,omitempty"`
+	// Each point's config is named base.Name + "_" + Label + the field's
+	// value in Format. Label defaults to the field's lower-cased name and
+	// Format to %v. Labels, when given, name the points instead: point i
+	// adds "_" + Labels[i], or nothing where Labels[i] is empty.
+	Label  string   `json:"label,omitempty"`
+	Format string   `json:"format,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+func (a Axis) len() int {
+	if len(a.Names) > 0 {
+		return len(a.Names)
+	}
+	return len(a.Values)
+}
+
+func (a Axis) value(i int) any {
+	if len(a.Names) > 0 {
+		return a.Names[i]
+	}
+	return a.Values[i]
+}
+
+func (a Axis) label() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return strings.ToLower(a.Field[strings.LastIndex(a.Field, ".")+1:])
+}
+
+// suffix is what point i with field value v adds to the config name.
+func (a Axis) suffix(i int, v any) string {
+	if len(a.Labels) > 0 {
+		if a.Labels[i] == "" {
+			return ""
+		}
+		return "_" + a.Labels[i]
+	}
+	format := a.Format
+	if format == "" {
+		format = "%v"
+	}
+	return fmt.Sprintf("_%s"+format, a.label(), v)
+}
+
+// unnamed labels n points of an axis that is zipped with one that names them.
+func unnamed(n int) []string {
+	return make([]string, n)
+}
+
+// Dim is one dimension of a grid: axes that step together, so their i-th
+// values make up the i-th point. In JSON a dimension of one axis may be
+// written as the axis alone.
+type Dim []Axis
+
+func (d *Dim) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '{' {
+		var a Axis
+		if err := decodeStrict(b, &a); err != nil {
+			return err
+		}
+		*d = Dim{a}
+		return nil
+	}
+	var axes []Axis
+	if err := decodeStrict(b, &axes); err != nil {
+		return err
+	}
+	*d = axes
+	return nil
+}
+
+// Grid is the cartesian product of its dimensions, first dimension outermost.
+type Grid []Dim
+
+// Derived sets a field from others after each point's axes are applied, so
+// it follows them: p_flag from p_target and τ_flag, say. Args are field paths
+// to numeric fields.
+type Derived struct {
+	Field string   `json:"field"`
+	Func  string   `json:"func"`
+	Args  []string `json:"args"`
+}
+
+type derivation struct {
+	args []string
+	f    func([]float64) float64
+}
+
+// Derivations are the functions a Derived field may use, by name.
+var derivations = map[string]derivation{
+	"AggressivePFlag": {[]string{"p_target", "τ_flag"}, func(a []float64) float64 { return AggressivePFlag(a[0], a[1]) }},
+	"Copy":            {[]string{"x"}, func(a []float64) float64 { return a[0] }},
+}
+
+// Derivations lists the derivation functions with their arguments.
+func Derivations() []string {
+	var out []string
+	for _, name := range slices.Sorted(maps.Keys(derivations)) {
+		out = append(out, name+"("+strings.Join(derivations[name].args, ", ")+")")
+	}
+	return out
+}
+
+// GridConfigs expands a grid over base into one config per point, in
+// row-major order, each with its axes and then the derived fields applied.
+// base must be a config struct with a string Name field.
+func GridConfigs[C any](base C, grid Grid, derived []Derived) ([]C, error) {
+	n := 1
+	for i, dim := range grid {
+		if len(dim) == 0 {
+			return nil, fmt.Errorf("grid dimension %d has no axes", i)
+		}
+		for _, a := range dim {
+			if len(a.Values) > 0 && len(a.Names) > 0 {
+				return nil, fmt.Errorf("%s: values and names both given", a.Field)
+			}
+			if a.len() != dim[0].len() {
+				return nil, fmt.Errorf("%s has %d values but %s, zipped with it, has %d", a.Field, a.len(), dim[0].Field, dim[0].len())
+			}
+			if len(a.Labels) > 0 && len(a.Labels) != a.len() {
+				return nil, fmt.Errorf("%s has %d values but %d labels", a.Field, a.len(), len(a.Labels))
+			}
+		}
+		if dim[0].len() == 0 {
+			return nil, fmt.Errorf("%s has no values", dim[0].Field)
+		}
+		n *= dim[0].len()
+	}
+	for _, d := range derived {
+		fn, ok := derivations[d.Func]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown derivation %q", d.Field, d.Func)
+		}
+		if len(d.Args) != len(fn.args) {
+			return nil, fmt.Errorf("%s: %s takes %d args (%s)", d.Field, d.Func, len(fn.args), strings.Join(fn.args, ", "))
+		}
+	}
+
+	out := make([]C, 0, n)
+	idx := make([]int, len(grid))
+	for range n {
+		cfg := base
+		v := reflect.ValueOf(&cfg).Elem()
+		name, err := fieldByPath(v, "Name")
+		if err != nil || name.Kind() != reflect.String {
+			return nil, fmt.Errorf("%T has no Name to label points with", cfg)
+		}
+		var suffix strings.Builder
+		for k, dim := range grid {
+			for _, a := range dim {
+				f, err := setField(v, a.Field, a.value(idx[k]))
+				if err != nil {
+					return nil, err
+				}
+				suffix.WriteString(a.suffix(idx[k], f.Interface()))
+			}
+		}
+		for _, d := range derived {
+			args := make([]float64, len(d.Args))
+			for i, path := range d.Args {
+				if args[i], err = numericField(v, path); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := setField(v, d.Field, derivations[d.Func].f(args)); err != nil {
+				return nil, err
+			}
+		}
+		name.SetString(name.String() + suffix.String())
+		out = append(out, cfg)
+
+		for k := len(grid) - 1; k >= 0; k-- {
+			if idx[k]++; idx[k] < grid[k][0].len() {
+				break
+			}
+			idx[k] = 0
+		}
+	}
+	return out, nil
+}
+
+// fieldByPath finds the field at a dotted path through nested structs.
+func fieldByPath(v reflect.Value, path string) (reflect.Value, error) {
+	for _, part := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, fmt.Errorf("%s: %s is not a struct", path, v.Type())
+		}
+		f, ok := v.Type().FieldByName(part)
+		if !ok || !f.IsExported() {
+			return reflect.Value{}, fmt.Errorf("%s: no field %s in %s", path, part, v.Type())
+		}
+		v = v.FieldByIndex(f.Index)
+	}
+	return v, nil
+}
+
+// setField writes x to the field at path the way encoding/json would, so
+// enums accept their names and integers refuse fractions. A string the field
+// does not accept as one is tried as JSON text. The field is cleared first,
+// so a struct takes only the fields x gives.
+func setField(v reflect.Value, path string, x any) (reflect.Value, error) {
+	f, err := fieldByPath(v, path)
+	if err != nil {
+		return f, err
+	}
+	b, err := json.Marshal(x)
+	if err != nil {
+		return f, fmt.Errorf("%s: %w", path, err)
+	}
+	f.SetZero()
+	err = json.Unmarshal(b, f.Addr().Interface())
+	if s, ok := x.(string); ok && err != nil && json.Valid([]byte(s)) {
+		f.SetZero()
+		if json.Unmarshal([]byte(s), f.Addr().Interface()) == nil {
+			return f, nil
+		}
+	}
+	if err != nil {
+		return f, fmt.Errorf("%s = %s: %w", path, b, err)
+	}
+	return f, nil
+}
+
+func numericField(v reflect.Value, path string) (float64, error) {
+	f, err := fieldByPath(v, path)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case f.CanFloat():
+		return f.Float(), nil
+	case f.CanInt():
+		return float64(f.Int()), nil
+	}
+	return 0, errors.New(path + ": not a number")
+}
+
+func runAll[C, A any](run func(C) A, cfgs []C) []A {
+	out := make([]A, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		out = append(out, run(cfg))
+	}
+	return out
+}
+
+// sweepField runs one config per value of a single field, named as
+// base.Name + "_" + label + the value in format. It backs the one-field
+// Sweep* methods, whose fields are fixed, so a bad path is a bug.
+func sweepField[C, A any](run func(C) A, base C, field, label, format string, values []float64) []A {
+	return runAll(run, mustGridConfigs(base, Grid{{{Field: field, Values: values, Label: label, Format: format}}}, nil))
+}
+
+// mustGridConfigs is GridConfigs for the built-in sweeps, whose grids are
+// fixed, so an error is a bug.
+func mustGridConfigs[C any](base C, grid Grid, derived []Derived) []C {
+	cfgs, err := GridConfigs(base, grid, derived)
+	if err != nil {
+		panic(err)
+	}
+	return cfgs
+}
+
+// mustJSON writes v for a Names entry of a struct field.
+func mustJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+func floats(ns []int) []float64 {
+	out := make([]float64, len(ns))
+	for i, n := range ns {
+		out[i] = float64(n)
+	}
+	return out
+}
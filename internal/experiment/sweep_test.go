@@ -0,0 +1,288 @@
+package experiment
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// The Sweep* methods name their configs as they did before the grid engine,
+// and names seed the trials, so these must not drift.
+func TestGridNamesMatchHandWrittenSweeps(t *testing.T) {
+	base := DefaultIncompetentBaseline()
+	rates, rels := []float64{1e-4, 0.05}, []float64{0, 0.5, 1}
+	cfgs, err := GridConfigs(base, Grid{
+		{{Field: "DelayModel.IncompetenceRate", Values: rates, Label: "pincomp", Format: "%.4f"}},
+		{{Field: "FlagReliability", Values: rels, Label: "flagrel", Format: "%.3f"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := 0
+	for _, p := range rates {
+		for _, rel := range rels {
+			c := cfgs[i]
+			if want := fmt.Sprintf("%s_pincomp%.4f_flagrel%.3f", base.Name, p, rel); c.Name != want {
+				t.Errorf("point %d named %s, want %s", i, c.Name, want)
+			}
+			if c.DelayModel.IncompetenceRate != p || c.FlagReliability != rel {
+				t.Errorf("point %d = (%v, %v), want (%v, %v)", i, c.DelayModel.IncompetenceRate, c.FlagReliability, p, rel)
+			}
+			i++
+		}
+	}
+
+	qpbs, err := GridConfigs(base, Grid{{{Field: "Verification.QueriesPerBatch", Values: floats([]int{1, 8}), Label: "qpb", Format: "%d"}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qpbs[1].Name != base.Name+"_qpb8" || qpbs[1].Verification.QueriesPerBatch != 8 {
+		t.Errorf("int axis: %s with %d queries per batch", qpbs[1].Name, qpbs[1].Verification.QueriesPerBatch)
+	}
+}
+
+// The malicious sweeps built on the grid give the configs, names included,
+// that their hand-written loops did.
+func TestMaliciousSweepsMatchHandWrittenLoops(t *testing.T) {
+	base := DefaultMaliciousBaseline()
+	base.Targeting = network.DefaultQuotaTargeting(3, 20)
+	pts, pls := []float64{0.05, 0.5}, []float64{0, 0.25, 1}
+	tau := base.Verification.FlaggingRateThreshold
+
+	var want []MaliciousBaselineConfig
+	for _, pt := range pts {
+		cfg := base
+		cfg.Targeting = network.DefaultAdversarialTargeting(pt)
+		cfg.Name = fmt.Sprintf("%s_ptarget%.4f", base.Name, pt)
+		want = append(want, cfg)
+	}
+	for _, pt := range pts {
+		for _, pl := range pls {
+			cfg := base
+			cfg.Targeting = network.DefaultAdversarialTargeting(pt)
+			cfg.PFlag = AggressivePFlag(pt, tau)
+			cfg.PLie = pl
+			cfg.Name = fmt.Sprintf("%s_ptarget%.4f_plie%.4f", base.Name, pt, pl)
+			want = append(want, cfg)
+		}
+	}
+	for _, m := range []struct {
+		name      string
+		targeting network.TargetingConfig
+	}{
+		{"random", network.DefaultAdversarialTargeting(0.10)},
+		{"periodic", network.DefaultPeriodicTargeting(10)},
+		{"quota", network.DefaultQuotaTargeting(1, base.BatchSize)},
+		{"all", network.DefaultAllTargeting()},
+	} {
+		cfg := base
+		cfg.Targeting = m.targeting
+		cfg.PFlag = 0.0
+		cfg.PLie = 1.0
+		cfg.AnsweringStrategy = verification.AnswerParametric
+		cfg.Name = fmt.Sprintf("%s_mode_%s", base.Name, m.name)
+		want = append(want, cfg)
+	}
+
+	got := maliciousPTargetConfigs(base, pts)
+	got = append(got, maliciousPhaseMapConfigs(base, pts, pls)...)
+	got = append(got, maliciousTargetingModeConfigs(base)...)
+	if len(got) != len(want) {
+		t.Fatalf("%d configs, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("config %d:\n got %s %+v pflag=%v plie=%v\nwant %s %+v pflag=%v plie=%v", i,
+				got[i].Name, got[i].Targeting, got[i].PFlag, got[i].PLie,
+				want[i].Name, want[i].Targeting, want[i].PFlag, want[i].PLie)
+		}
+	}
+}
+
+func TestGridZipAndDerived(t *testing.T) {
+	base := DefaultMaliciousBaseline()
+	tau := base.Verification.FlaggingRateThreshold
+	cfgs, err := GridConfigs(base, Grid{
+		{
+			{Field: "Targeting.TargetFraction", Values: []float64{0.6, 1.5}},
+			{Field: "DelayModel.TargetedMin", Values: []float64{0.01, 0.02}, Label: "dmin"},
+		},
+		{{Field: "Targeting.Mode", Names: []string{"RANDOM", "QUOTA"}, Label: "mode", Format: "%s"}},
+	}, []Derived{
+		{Field: "PFlag", Func: "AggressivePFlag", Args: []string{"Targeting.TargetFraction", "Verification.FlaggingRateThreshold"}},
+		{Field: "DelayModel.TargetedMax", Func: "Copy", Args: []string{"DelayModel.TargetedMin"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfgs) != 4 {
+		t.Fatalf("2 zipped x 2 modes gave %d points", len(cfgs))
+	}
+	last := cfgs[3]
+	if last.Targeting.TargetFraction != 1.5 || last.DelayModel.TargetedMin != 0.02 || last.Targeting.Mode != network.TargetQuota {
+		t.Errorf("last point: %+v", last.Targeting)
+	}
+	if want := AggressivePFlag(1.5, tau); last.PFlag != want || last.DelayModel.TargetedMax != 0.02 {
+		t.Errorf("derived p_flag %v, d_max %v; want %v, 0.02", last.PFlag, last.DelayModel.TargetedMax, want)
+	}
+	if want := base.Name + "_targetfraction1.5_dmin0.02_modeQUOTA"; last.Name != want {
+		t.Errorf("named %s, want %s", last.Name, want)
+	}
+}
+
+func TestGridErrors(t *testing.T) {
+	base := DefaultMaliciousBaseline()
+	for _, tc := range []struct {
+		name    string
+		grid    Grid
+		derived []Derived
+		wantErr string
+	}{
+		{"UnknownField", Grid{{{Field: "Verification.Nope", Values: []float64{1}}}}, nil, "no field Nope"},
+		{"ThroughScalar", Grid{{{Field: "PFlag.X", Values: []float64{1}}}}, nil, "not a struct"},
+		{"FractionalInt", Grid{{{Field: "BatchSize", Values: []float64{2.5}}}}, nil, "BatchSize"},
+		{"BadEnum", Grid{{{Field: "Targeting.Mode", Names: []string{"SOMETIMES"}}}}, nil, "SOMETIMES"},
+		{"UnevenZip", Grid{{{Field: "PFlag", Values: []float64{0, 1}}, {Field: "PLie", Values: []float64{0}}}}, nil, "zipped"},
+		{"NoValues", Grid{{{Field: "PFlag"}}}, nil, "no values"},
+		{"UnknownDerivation", nil, []Derived{{Field: "PFlag", Func: "Nope"}}, "unknown derivation"},
+		{"DerivationArgs", nil, []Derived{{Field: "PFlag", Func: "Copy"}}, "takes 1 args"},
+		{"NonNumericArg", nil, []Derived{{Field: "PFlag", Func: "Copy", Args: []string{"Name"}}}, "not a number"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := GridConfigs(base, tc.grid, tc.derived)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("got %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGridScenarioJSON(t *testing.T) {
+	sc, err := ParseScenario([]byte(`{"name": "g", "kind": "malicious", "runs": [{
+		"id": "phase", "sweep": "Grid",
+		"grid": [
+			{"field": "Targeting.TargetFraction", "values": {"logspace": [1e-3, 0.5, 4]}, "label": "ptarget", "format": "%.4f"},
+			[{"field": "PLie", "values": [0, 1]}, {"field": "PEquivocate", "values": [0.5, 0]}]
+		],
+		"derived": [{"field": "PFlag", "func": "AggressivePFlag", "args": ["Targeting.TargetFraction", "Verification.FlaggingRateThreshold"]}],
+		"output": "phase.json"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := sc.Runs[0].Grid
+	if len(g) != 2 || len(g[0]) != 1 || len(g[1]) != 2 || len(g[0][0].Values) != 4 {
+		t.Fatalf("grid decoded as %+v", g)
+	}
+	cfgs, err := GridConfigs(DefaultMaliciousBaseline(), g, sc.Runs[0].Derived)
+	if err != nil || len(cfgs) != 8 {
+		t.Fatalf("%d configs, err %v", len(cfgs), err)
+	}
+}
+
+// The sweeps that build each point differently still come out of one grid,
+// so check every point against the loop it replaced. Order is free; names
+// and fields are not.
+func TestSweepsWithLabelsMatchHandWrittenLoops(t *testing.T) {
+	base := DefaultMaliciousBaseline()
+	pts, fs, ws, ks := []float64{0.05, 0.5}, []float64{0.01, 0.1}, []int{50}, []int{1, 3}
+
+	var want []MaliciousBaselineConfig
+	add := func(cfg MaliciousBaselineConfig, name string, args ...any) {
+		cfg.Name = base.Name + fmt.Sprintf(name, args...)
+		want = append(want, cfg)
+	}
+	for _, sp := range []verification.ResponsePolicy{verification.RespondLate, verification.RespondRefuse, verification.RespondSilent} {
+		for _, tp := range []verification.TimeoutPolicy{verification.TimeoutIgnore, verification.TimeoutEvidence, verification.TimeoutContradiction} {
+			cfg := StallerConfig(base, 0.1, sp)
+			cfg.Verification.TimeoutPolicy = tp
+			add(cfg, "_%s_%s", strings.ToLower(string(sp)), timeoutPolicyName(tp))
+		}
+	}
+	inflation := GlobalInflationConfig(base)
+	add(inflation, "_no_bound")
+	physical := inflation
+	physical.Verification.Path = LondonNewYork()
+	physical.Verification.InflationTolerance = base.DelayModel.BaseDelayMax - LondonNewYork().MinDelay() + 1e-3
+	add(physical, "_physical")
+	declared := inflation
+	declared.DeclareSchedule = true
+	add(declared, "_declared")
+	noProbes := base
+	noProbes.Verification.ProbeRate = 0
+	add(noProbes, "_noprobes")
+	for _, d := range fs {
+		cfg := base
+		cfg.Verification.ProbeRate = 0.2
+		cfg.ProbeDistinguishability = d
+		add(cfg, "_distinguish%.2f", d)
+	}
+	for _, check := range []bool{false, true} {
+		for _, po := range fs {
+			cfg := OverFlaggerConfig(base, 0.1, po)
+			cfg.Verification.CheckFalseFlags = check
+			add(cfg, "_overflag%.4f_check%t", po, check)
+		}
+		for _, pt := range pts {
+			cfg := RandomAnswererConfig(base, pt)
+			cfg.Verification.QueryMinimum = check
+			add(cfg, "_random_pt%.4f_querymin%t", pt, check)
+		}
+	}
+	sw := HonestThenConfig(base, NaiveLiarConfig(base, 0.1), 100, 200)
+	add(sw, "_noforget")
+	for _, f := range fs {
+		cfg := sw
+		cfg.Verification.Forgetting = f
+		add(cfg, "_forget%.4f", f)
+	}
+	for _, w := range ws {
+		cfg := sw
+		cfg.Verification.Window = w
+		add(cfg, "_window%d", w)
+	}
+	for _, sharing := range []verification.CoalitionConfig{
+		{Pool: verification.PoolQueries},
+		{Pool: verification.PoolLikelihoods},
+		{Pool: verification.PoolQueries, CrossBatches: true},
+		{Pool: verification.PoolLikelihoods, CrossBatches: true},
+	} {
+		pool := "QUERIES"
+		if sharing.Pool != verification.PoolQueries {
+			pool = string(sharing.Pool)
+		}
+		for _, k := range ks {
+			cfg := CoalitionConfig(base, 6, 2, k, sharing)
+			add(cfg, "_members%d_pool%s_cross%t", k, pool, sharing.CrossBatches)
+			cfg.Targeting = network.DefaultHonestTargeting()
+			add(cfg, "_members%d_pool%s_cross%t_honest", k, pool, sharing.CrossBatches)
+		}
+	}
+
+	var got []MaliciousBaselineConfig
+	got = append(got, maliciousTimeoutPolicyConfigs(base, 0.1)...)
+	got = append(got, maliciousDelayBoundConfigs(base)...)
+	got = append(got, maliciousProbeConfigs(base, 0.2, fs)...)
+	got = append(got, maliciousOverFlagConfigs(base, 0.1, fs)...)
+	got = append(got, maliciousMinimumQueryConfigs(base, pts)...)
+	got = append(got, regimeForgettingConfigs(sw, fs, ws)...)
+	got = append(got, coalitionSizeConfigs(base, 6, 2, ks)...)
+	if len(got) != len(want) {
+		t.Fatalf("%d configs, want %d", len(got), len(want))
+	}
+	byName := map[string]MaliciousBaselineConfig{}
+	for _, c := range got {
+		byName[c.Name] = c
+	}
+	for _, w := range want {
+		g, ok := byName[w.Name]
+		if !ok {
+			t.Errorf("no config named %s", w.Name)
+		} else if !reflect.DeepEqual(g, w) {
+			t.Errorf("%s:\n got %+v\nwant %+v", w.Name, g, w)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package experiment
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// Timeline scripts parameter changes during a trial, so a scenario can change
+// mid-run without new code. In JSON:
+//
+//	{"events": [
+//	  {"at": 300, "set": {"IncompetenceRate": 0.3}},
+//	  {"at": 500, "until": 600, "outage": true},
+//	  {"at": 700, "set": {"TargetingMode": "QUOTA", "Quota": 10, "BatchSize": 10}}
+//	]}
+//
+// Times are simulated seconds from the start of the trial. The names "set"
+// accepts are the keys of timelineParams.
+type Timeline struct {
+	Events []TimelineEvent `json:"events"`
+}
+
+type TimelineEvent struct {
+	At     float64        `json:"at"`
+	Until  float64        `json:"until,omitempty"` // end of an outage
+	Outage bool           `json:"outage,omitempty"`
+	Set    map[string]any `json:"set,omitempty"`
+}
+
+// LoadTimeline reads and validates a timeline from a JSON file.
+func LoadTimeline(path string) (Timeline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Timeline{}, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	var tl Timeline
+	if err := dec.Decode(&tl); err != nil {
+		return Timeline{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := tl.Validate(); err != nil {
+		return Timeline{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return tl, nil
+}
+
+func (tl Timeline) Validate() error {
+	for i, ev := range tl.Events {
+		if _, _, err := ev.compile(); err != nil {
+			return fmt.Errorf("event %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// timelineTarget is what a trial's timeline acts on.
+type timelineTarget struct {
+	dm     *network.DelayModel
+	router *network.Router
+	prover *verification.Prover
+
+	// the router's flagging is rebuilt from this whenever part of it is set
+	flagging Flagging
+
+	outages int // outages in progress; they may overlap
+}
+
+// schedule arms every event against t. Events that do not compile are
+// reported and skipped; LoadTimeline has normally caught them already.
+func (tl Timeline) schedule(sim *engine.Simulation, t *timelineTarget) {
+	for i, ev := range tl.Events {
+		start, end, err := ev.compile()
+		if err != nil {
+			fmt.Printf("warning: timeline event %d skipped: %v\n", i, err)
+			continue
+		}
+		sim.Schedule(ev.At, func() { start(t) })
+		if end != nil {
+			sim.Schedule(ev.Until, func() { end(t) })
+		}
+	}
+}
+
+// compile checks the event and returns what it does at At and, for an
+// outage, at Until.
+func (ev TimelineEvent) compile() (start, end func(*timelineTarget), err error) {
+	if ev.At < 0 {
+		return nil, nil, fmt.Errorf("at %.3f is before the trial starts", ev.At)
+	}
+	if ev.Outage && ev.Until <= ev.At {
+		return nil, nil, fmt.Errorf("outage from %.3f must end after it starts, not at %.3f", ev.At, ev.Until)
+	}
+	if !ev.Outage && ev.Until != 0 {
+		return nil, nil, fmt.Errorf("until is only meaningful for an outage")
+	}
+	if !ev.Outage && len(ev.Set) == 0 {
+		return nil, nil, fmt.Errorf("event at %.3f does nothing", ev.At)
+	}
+
+	// apply in name order so the RNG is drawn the same way every run
+	names := make([]string, 0, len(ev.Set))
+	for name := range ev.Set {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var sets []func(*timelineTarget)
+	for _, name := range names {
+		param, ok := timelineParams[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown parameter %q", name)
+		}
+		set, err := param(ev.Set[name])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sets = append(sets, set)
+	}
+
+	start = func(t *timelineTarget) {
+		for _, set := range sets {
+			set(t)
+		}
+		if ev.Outage {
+			t.outages++
+			t.router.Down = true
+		}
+	}
+	if ev.Outage {
+		end = func(t *timelineTarget) {
+			t.outages--
+			t.router.Down = t.outages > 0
+		}
+	}
+	return start, end, nil
+}
+
+// A timelineParam checks a value from JSON and returns the change it makes.
+type timelineParam func(v any) (func(*timelineTarget), error)
+
+var timelineParams = map[string]timelineParam{
+	"IncompetenceRate":  unitParam(delayParam(func(c *network.DelayModelConfig, x float64) { c.IncompetenceRate = x })),
+	"IncompetenceMu":    delayParam(func(c *network.DelayModelConfig, x float64) { c.IncompetenceMu = x }),
+	"IncompetenceSigma": delayParam(func(c *network.DelayModelConfig, x float64) { c.IncompetenceSigma = x }),
+	"TargetedMin":       delayParam(func(c *network.DelayModelConfig, x float64) { c.TargetedMin = x }),
+	"TargetedMax":       delayParam(func(c *network.DelayModelConfig, x float64) { c.TargetedMax = x }),
+
+	"TargetingMode": func(v any) (func(*timelineTarget), error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("want a mode name, got %v", v)
+		}
+		mode, err := network.ParseTargetingMode(s)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *timelineTarget) { t.router.TargetingCfg.Mode = mode }, nil
+	},
+	"TargetFraction": unitParam(floatParam(func(t *timelineTarget, x float64) { t.router.TargetingCfg.TargetFraction = x })),
+	"Period":         intParam(func(t *timelineTarget, n int) { t.router.TargetingCfg.Period = n }),
+	"Quota":          intParam(func(t *timelineTarget, n int) { t.router.TargetingCfg.Quota = n }),
+	"BatchSize":      intParam(func(t *timelineTarget, n int) { t.router.TargetingCfg.BatchSize = n }),
+
+	"FlagReliability": unitParam(floatParam(func(t *timelineTarget, x float64) {
+		t.flagging.Reliability = x
+		t.router.Flagging = t.flagging.fn()
+	})),
+	"PFlag": unitParam(floatParam(func(t *timelineTarget, x float64) {
+		t.flagging.PFlag = x
+		t.router.Flagging = t.flagging.fn()
+	})),
+	"POverFlag": unitParam(floatParam(func(t *timelineTarget, x float64) {
+		t.flagging.POverFlag = x
+		t.router.Flagging = t.flagging.fn()
+	})),
+
+	"AnsweringStrategy": func(v any) (func(*timelineTarget), error) {
+		s, ok := v.(string)
+		if !ok || !verification.AnsweringStrategy(s).Valid() {
+			return nil, fmt.Errorf("unknown answering strategy %v", v)
+		}
+		return func(t *timelineTarget) { t.prover.Config.AnsweringStr = verification.AnsweringStrategy(s) }, nil
+	},
+	"PLie":            unitParam(floatParam(func(t *timelineTarget, x float64) { t.prover.Config.LieRate = x })),
+	"AnswerErrorRate": unitParam(floatParam(func(t *timelineTarget, x float64) { t.prover.Config.AnswerErrorRate = x })),
+	"PEquivocate":     unitParam(floatParam(func(t *timelineTarget, x float64) { t.prover.Config.EquivocationRate = x })),
+}
+
+// number accepts what encoding/json decodes numbers to, and the ints a
+// timeline built in Go is likely to hold.
+func number(v any) (float64, error) {
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case int:
+		return float64(x), nil
+	}
+	return 0, fmt.Errorf("want a number, got %v", v)
+}
+
+func floatParam(set func(t *timelineTarget, x float64)) timelineParam {
+	return func(v any) (func(*timelineTarget), error) {
+		x, err := number(v)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *timelineTarget) { set(t, x) }, nil
+	}
+}
+
+func intParam(set func(t *timelineTarget, n int)) timelineParam {
+	return func(v any) (func(*timelineTarget), error) {
+		x, err := number(v)
+		if err != nil {
+			return nil, err
+		}
+		if x != math.Trunc(x) || x < 0 {
+			return nil, fmt.Errorf("want a whole number of at least 0, got %v", x)
+		}
+		return func(t *timelineTarget) { set(t, int(x)) }, nil
+	}
+}
+
+// unitParam is p for a probability or share, which must lie in [0, 1].
+func unitParam(p timelineParam) timelineParam {
+	return func(v any) (func(*timelineTarget), error) {
+		if x, err := number(v); err == nil && !(x >= 0 && x <= 1) {
+			return nil, fmt.Errorf("want a value in [0, 1], got %v", x)
+		}
+		return p(v)
+	}
+}
+
+func delayParam(set func(c *network.DelayModelConfig, x float64)) timelineParam {
+	return floatParam(func(t *timelineTarget, x float64) {
+		c := t.dm.Config()
+		set(&c, x)
+		t.dm.SetConfig(c)
+	})
+}
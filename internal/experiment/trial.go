@@ -0,0 +1,375 @@
+package experiment
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+// Scenario composes a trial from independent parts: a delay model, traffic,
+// targeting, flagging, answering and a verifier. None of them implies the
+// others, so an operator can be incompetent and malicious at once: congested
+// by DelayModel.IncompetenceRate and delaying packets on purpose by
+// Targeting. The Honest-, Incompetent- and MaliciousBaselineConfig types are
+// corners of it; see their Scenario methods.
+type Scenario struct {
+	Name string
+
+	// Traffic: NumPackets sent BatchSize at a time, evenly over SimDuration.
+	NumTrials   int
+	NumPackets  int
+	BatchSize   int
+	SimDuration float64
+	Adaptive    Adaptive // stop short of NumTrials once the rates are known well enough
+
+	DelayModel network.DelayModelConfig // congestion, and d_mal in TargetedMin/Max
+	Targeting  network.TargetingConfig
+	Flagging   Flagging
+	Answering  Answering
+
+	Verification verification.VerificationConfig
+	Streaming    verification.StreamingConfig // audit during the run instead of after it
+
+	// The operator publishes its base-delay schedule: each trial fills
+	// Verification.DeclaredBaseDelay from its own delay model.
+	DeclareSchedule bool
+
+	// P(the adversary recognises a verifier probe and lets it through
+	// untouched); only matters with Verification.ProbeRate set.
+	ProbeDistinguishability float64
+
+	Regimes   []Regime       // later changes of behaviour; see Regime
+	Timeline  Timeline       // parameter changes during each trial
+	Coalition CoalitionSetup // several customers on the same operator
+
+	// Truth is what results are scored against. Empty infers it from the
+	// behaviour; set it when a timeline changes the operator's nature.
+	Truth Truth
+
+	// scope keeps the seeds and audit-log paths of the baseline configs
+	// this scenario was made from; empty is "scenario".
+	scope string
+}
+
+// Flagging is when the operator sets a packet's flag: the congestion it owns
+// up to, the deliberate delay it admits, and the padding it adds.
+type Flagging struct {
+	Reliability float64 // P(flag | packet was congested)
+	PFlag       float64 // p_flag: P(flag | packet was deliberately delayed)
+	POverFlag   float64 // P(flag | packet was neither)
+}
+
+// fn draws exactly as the per-baseline flagging functions it replaced did, so
+// their trials replay unchanged.
+func (f Flagging) fn() network.FlaggingFn {
+	return func(hasIncompetence, wasDelayed bool) bool {
+		switch {
+		case wasDelayed:
+			return rand.Float64() < f.PFlag
+		case hasIncompetence:
+			return rand.Float64() < f.Reliability
+		}
+		return f.POverFlag > 0 && rand.Float64() < f.POverFlag
+	}
+}
+
+// Answering is how the operator answers the verifier's queries.
+type Answering struct {
+	Strategy    verification.AnsweringStrategy
+	PLie        float64 // p_lie
+	ErrorRate   float64 // only used with AnswerUnreliable
+	PEquivocate float64 // P(answer departs from the committed claim); needs Verification.RequireCommitments
+
+	// Stalling instead of lying. The batch verifier only notices with a
+	// Streaming.QueryDeadline to miss.
+	ResponsePolicy verification.ResponsePolicy
+	PStall         float64 // P(ResponsePolicy applies to a query about a targeted packet, or any packet with StallAll)
+	StallDelay     float64
+	StallAll       bool // stall on queries about any packet, not only targeted ones
+}
+
+func (a Answering) adversary() verification.AdversaryConfig {
+	return verification.AdversaryConfig{
+		AnsweringStr:      a.Strategy,
+		AnswerErrorRate:   a.ErrorRate,
+		LieRate:           a.PLie,
+		EquivocationRate:  a.PEquivocate,
+		ResponsePolicy:    a.ResponsePolicy,
+		StallRate:         a.PStall,
+		StallTargetedOnly: !a.StallAll,
+		StallDelay:        a.StallDelay,
+	}
+}
+
+// Truth is the hypothesis a scenario's operator really falls under.
+type Truth string
+
+const (
+	TruthHonest      Truth = "HONEST"
+	TruthIncompetent Truth = "INCOMPETENT"
+	TruthMalicious   Truth = "MALICIOUS"
+)
+
+func (t Truth) hypothesis() int {
+	switch t {
+	case TruthIncompetent:
+		return verification.HypIncompetent
+	case TruthMalicious:
+		return verification.HypMalicious
+	}
+	return verification.HypHonest
+}
+
+// truth is sc.Truth, or failing that what the behaviour amounts to: any
+// deliberate delay, at the start or after a regime switch, is malicious
+// whatever else the operator does; congestion or unreliable answers without
+// it are incompetent.
+func (sc Scenario) truth() Truth {
+	if sc.Truth != "" {
+		return sc.Truth
+	}
+	if targets(sc.Targeting) {
+		return TruthMalicious
+	}
+	for _, reg := range sc.Regimes {
+		if targets(reg.Targeting) {
+			return TruthMalicious
+		}
+	}
+	if sc.DelayModel.IncompetenceRate > 0 ||
+		(sc.Answering.Strategy == verification.AnswerUnreliable && sc.Answering.ErrorRate > 0) {
+		return TruthIncompetent
+	}
+	return TruthHonest
+}
+
+// targets reports whether t delays anything at all.
+func targets(t network.TargetingConfig) bool {
+	switch t.Mode {
+	case network.TargetNone:
+		return false
+	case network.TargetRandom:
+		return t.TargetFraction > 0
+	case network.TargetPeriodic:
+		return t.Period > 0
+	case network.TargetQuota:
+		return t.Quota > 0
+	}
+	return true
+}
+
+// Validate reports a scenario whose trials could not mean what it asks for.
+func (sc Scenario) Validate() error {
+	if len(sc.Regimes) > 0 && !sc.Streaming.Enabled {
+		// the batch verifier decides at time 0, before any switch, so every
+		// accusation would count as a false alarm
+		return errors.New("regimes need a streaming verifier")
+	}
+	switch sc.Truth {
+	case "", TruthHonest, TruthIncompetent, TruthMalicious:
+	default:
+		return fmt.Errorf("unknown truth %q", sc.Truth)
+	}
+	if err := sc.Timeline.Validate(); err != nil {
+		return fmt.Errorf("timeline: %w", err)
+	}
+	return sc.Verification.Validate()
+}
+
+func (sc Scenario) seedScope() string {
+	if sc.scope == "" {
+		return "scenario"
+	}
+	return sc.scope
+}
+
+// DefaultScenario is an honest operator on the baseline network; every
+// behaviour is off until set.
+func DefaultScenario() Scenario {
+	h := DefaultHonestBaseline().Scenario()
+	h.Name = "scenario"
+	h.scope = ""
+	return h
+}
+
+// TrialResult is one trial of any scenario, scored against its truth.
+type TrialResult struct {
+	TrialNum            int
+	Truth               Truth
+	Member              string // the population member drawn; empty outside RunPopulation
+	Verdict             string
+	VerdictClass        string // TRUSTED, CAUGHT_INCOMPETENT, CAUGHT_MALICIOUS, SLA_BREACHED or INCONCLUSIVE
+	Confidence          float64
+	QueriesUsed         int
+	ContradictionsFound int
+	ProofFailures       int
+	Unanswered          int
+	BoundViolations     int
+	ProbesQueried       int
+	ProbesDelayed       int
+	FalseFlags          int
+	TargetedPackets     int      // packets the operator deliberately delayed
+	TargetedShare       float64  // TargetedPackets over packets delivered
+	Evidence            []string // kinds of evidence against the prover; see verification.EvidenceTypes
+	PosteriorH0         float64
+	PosteriorH1         float64
+	PosteriorH2         float64
+	LieRate             verification.RateEstimate
+	HiddenDelayRate     verification.RateEstimate
+	ExpectedCost        float64 // posterior expected loss of the verdict plus query costs
+	RealisedCost        float64 // loss given the true hypothesis plus query costs
+	DecisionTime        float64 // simulated time of the verdict (streaming only)
+	SwitchTime          float64 // when the first regime switch happened; 0 without Regimes
+	Duration            time.Duration
+}
+
+// Run runs N trials of sc and aggregates them.
+func (r *Runner) Run(sc Scenario) Aggregate {
+	kind := "scenario"
+	if sc.scope != "" {
+		kind += "_" + sc.scope // seeds differ by scope, which the config's JSON leaves out
+	}
+	return cachedRun(r, kind, sc.Name, sc, r.run)
+}
+
+func (r *Runner) run(sc Scenario) Aggregate {
+	if r.Verbose {
+		fmt.Printf(">>> %s: N=%d, pkts=%d, B=%d, truth=%s, p_incomp=%.4f, targeting=%s, p_target=%.4f, η=%.4f, α=%.4f\n",
+			sc.Name, sc.NumTrials, sc.NumPackets, sc.BatchSize, sc.truth(),
+			sc.DelayModel.IncompetenceRate, sc.Targeting.Mode, sc.Targeting.TargetFraction,
+			sc.Verification.ErrorTolerance,
+			sc.Verification.ConfidenceThreshold)
+	}
+	agg := r.runTrials(sc)
+	if r.Verbose {
+		fmt.Printf("    trusted=%s  H1=%s  H2=%s  SLA=%s  inconclusive=%s  correct=%s  median_q=%d\n",
+			formatRateWithCI(agg.TrustedRate, agg.TrustedRateCI),
+			formatRateWithCI(agg.CaughtIncompetentRate, agg.CaughtIncompetentRateCI),
+			formatRateWithCI(agg.CaughtMaliciousRate, agg.CaughtMaliciousRateCI),
+			formatRateWithCI(agg.SLABreachedRate, agg.SLABreachedRateCI),
+			formatRateWithCI(agg.InconclusiveRate, agg.InconclusiveRateCI),
+			formatRateWithCI(agg.CorrectRate, agg.CorrectRateCI),
+			agg.MedianQueriesToVerdict)
+	}
+	return agg
+}
+
+// runTrials runs and aggregates sc's trials without reporting them; the
+// baseline runners print their own lines.
+func (r *Runner) runTrials(sc Scenario) Aggregate {
+	if err := sc.Validate(); err != nil {
+		fmt.Printf("warning: %s: %v\n", sc.Name, err)
+		return Aggregate{Scenario: sc, Truth: sc.truth()}
+	}
+	trials, stopping := runAdaptive(r, sc.Name, sc.Adaptive, sc.NumTrials,
+		func(i int) TrialResult {
+			r.maybeSeedTrial(sc.seedScope(), sc.Name, i)
+			start := time.Now()
+			t := r.runTrial(sc, i)
+			t.Duration = time.Since(start)
+			return t
+		},
+		func(ts []TrialResult) float64 { return aggregate(sc, ts).widestCI() })
+	agg := aggregate(sc, trials)
+	agg.Stopping = stopping
+	return agg
+}
+
+type honestDest struct{ Received int }
+
+func (h *honestDest) Receive(sim *engine.Simulation, pkt network.Packet, pathUsed string) {
+	h.Received++
+}
+
+func (r *Runner) runTrial(sc Scenario, trialNum int) TrialResult {
+	if sc.Coalition.Clients > 1 {
+		return r.runCoalitionTrial(sc, trialNum)
+	}
+	sim := engine.NewSimulation()
+
+	dm := network.NewDelayModelConfig(sc.DelayModel)
+	dm.Initialise(sc.SimDuration + 10.0)
+
+	prover := verification.NewProver(sc.Answering.adversary())
+	router := network.NewRouter(dm, sc.Targeting, sc.Flagging.fn())
+	router.OnTransmission = prover.RecordTransmission
+
+	batchSize := max(2, sc.BatchSize)
+	vcfg := sc.Verification
+	if sc.DeclareSchedule {
+		vcfg.DeclaredBaseDelay = declaredSchedule(dm)
+	}
+	var roots *verification.PublishedRoots
+	if vcfg.RequireCommitments && !sc.Streaming.Enabled {
+		roots = verification.NewPublishedRoots(prover)
+	}
+	dest, probes, finish := r.prepareVerifier(sim, prover, vcfg, sc.Streaming, roots, batchSize, sc.seedScope(), sc.Name, trialNum)
+	router.SpotProbe = probes.spotter(sc.ProbeDistinguishability)
+	switchTime := scheduleRegimes(sim, router, prover, sc.Flagging, sc.Regimes)
+	sc.Timeline.schedule(sim, &timelineTarget{dm: dm, router: router, prover: prover, flagging: sc.Flagging})
+	numBatches := max(1, sc.NumPackets/batchSize)
+	interval := sc.SimDuration / float64(numBatches)
+
+	pktID := 0
+	for b := range numBatches {
+		sendTime := float64(b) * interval
+		if roots != nil {
+			// the prover publishes each batch's root as the next batch starts
+			sim.Schedule(sendTime+interval, func() { roots.Publish(b, sim.Now) })
+		}
+		for range batchSize {
+			id := pktID
+			pktID++
+			sim.Schedule(sendTime, func() {
+				router.Forward(sim, network.NewPacket(id, b, "Source", sim.Now), dest)
+			})
+		}
+		probes.inject(sim, router, dest, b, sendTime, &pktID)
+	}
+	sim.Run(sc.SimDuration + 10.0)
+
+	return newTrialResult(sc, trialNum, finish(), prover.Packets, switchTime)
+}
+
+func newTrialResult(sc Scenario, trialNum int, res verification.VerificationResult, delivered []*network.Packet, switchTime float64) TrialResult {
+	var targeted int
+	for _, p := range delivered {
+		if p.IsTargeted {
+			targeted++
+		}
+	}
+	truth := sc.truth()
+
+	return TrialResult{
+		TrialNum:            trialNum,
+		Truth:               truth,
+		Verdict:             res.Verdict,
+		VerdictClass:        classifyVerdict(res, truth),
+		Confidence:          res.Confidence,
+		QueriesUsed:         res.TotalQueries,
+		ContradictionsFound: res.ContradictionsFound,
+		ProofFailures:       res.ProofFailures,
+		Unanswered:          res.Unanswered,
+		BoundViolations:     res.BoundViolations,
+		ProbesQueried:       res.ProbesQueried,
+		ProbesDelayed:       res.ProbesDelayed,
+		FalseFlags:          res.FalseFlags,
+		TargetedPackets:     targeted,
+		TargetedShare:       float64(targeted) / float64(max(1, len(delivered))),
+		Evidence:            res.EvidenceTypes(),
+		PosteriorH0:         res.PosteriorH0,
+		PosteriorH1:         res.PosteriorH1,
+		PosteriorH2:         res.PosteriorH2,
+		LieRate:             res.LieRate,
+		HiddenDelayRate:     res.HiddenDelayRate,
+		ExpectedCost:        res.ExpectedCost,
+		RealisedCost:        sc.Verification.RealisedCost(res, truth.hypothesis()),
+		DecisionTime:        res.DecisionTime,
+		SwitchTime:          switchTime,
+	}
+}
@@ -0,0 +1,245 @@
+package experiment
+
+import (
+	"testing"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+	"satnet-simulator/internal/verification"
+)
+
+func TestScenarioTruth(t *testing.T) {
+	congested := DefaultScenario()
+	congested.DelayModel.IncompetenceRate = 0.1
+	unreliable := DefaultScenario()
+	unreliable.Answering = Answering{Strategy: verification.AnswerUnreliable, ErrorRate: 0.2}
+	mixed := congested
+	mixed.Targeting = network.DefaultAdversarialTargeting(0.1)
+	idle := DefaultScenario()
+	idle.Targeting = network.DefaultAdversarialTargeting(0)
+	switches := DefaultScenario()
+	switches.Regimes = []Regime{{Start: 10, Targeting: network.DefaultAllTargeting()}}
+	told := mixed
+	told.Truth = TruthIncompetent
+
+	for _, tc := range []struct {
+		name string
+		sc   Scenario
+		want Truth
+	}{
+		{"Default", DefaultScenario(), TruthHonest},
+		{"Congested", congested, TruthIncompetent},
+		{"UnreliableAnswers", unreliable, TruthIncompetent},
+		{"CongestedAndTargeting", mixed, TruthMalicious},
+		{"TargetingNothing", idle, TruthHonest},
+		{"TargetsAfterSwitch", switches, TruthMalicious},
+		{"Explicit", told, TruthIncompetent},
+		{"HonestBaseline", DefaultHonestBaseline().Scenario(), TruthHonest},
+		{"IncompetentBaseline", DefaultIncompetentBaseline().Scenario(), TruthIncompetent},
+		{"MaliciousBaseline", DefaultMaliciousBaseline().Scenario(), TruthMalicious},
+	} {
+		if got := tc.sc.truth(); got != tc.want {
+			t.Errorf("%s: truth %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// The baseline runners are views over Run; their trials must keep the seeds
+// they had before it.
+func TestBaselineRunsAreScenarios(t *testing.T) {
+	cfg := DefaultHonestBaseline()
+	cfg.NumTrials, cfg.NumPackets, cfg.SimDuration = 3, 200, 50
+
+	r := NewRunner()
+	r.Verbose = false
+	r.SetBaseSeed(11)
+	legacy := r.RunHonest(cfg)
+	sc := cfg.Scenario()
+	if sc.seedScope() != "honest" {
+		t.Fatalf("honest baseline seeds under %q", sc.seedScope())
+	}
+	agg := r.Run(sc)
+	for i, tr := range agg.Trials {
+		old := legacy.Trials[i]
+		if tr.Verdict != old.Verdict || tr.QueriesUsed != old.QueriesUsed || tr.PosteriorH0 != old.PosteriorH0 {
+			t.Errorf("trial %d: %s after %d queries, baseline runner gave %s after %d", i, tr.Verdict, tr.QueriesUsed, old.Verdict, old.QueriesUsed)
+		}
+	}
+	if legacy.FalseDishonestRate != agg.AccusedRate || legacy.TrustedRate != agg.TrustedRate {
+		t.Errorf("honest view: trusted %v false dishonest %v, aggregate %v %v", legacy.TrustedRate, legacy.FalseDishonestRate, agg.TrustedRate, agg.AccusedRate)
+	}
+}
+
+func TestMixedScenario(t *testing.T) {
+	sc := DefaultScenario()
+	sc.Name = "mixed"
+	sc.NumTrials, sc.NumPackets, sc.SimDuration = 6, 500, 100
+	sc.DelayModel.IncompetenceRate = 0.1
+	sc.DelayModel.IncompetenceMu = -3.9
+	sc.DelayModel.IncompetenceSigma = 0.5
+	sc.DelayModel.TargetedMin, sc.DelayModel.TargetedMax = 0.05, 0.05
+	sc.Targeting = network.DefaultAdversarialTargeting(0.2)
+	sc.Answering = Answering{Strategy: verification.AnswerParametric, PLie: 1}
+
+	r := NewRunner()
+	r.Verbose = false
+	r.SetBaseSeed(3)
+	agg := r.Run(sc)
+	if agg.Truth != TruthMalicious {
+		t.Fatalf("truth %s, want %s", agg.Truth, TruthMalicious)
+	}
+	if len(agg.Trials) != sc.NumTrials {
+		t.Fatalf("%d trials, want %d", len(agg.Trials), sc.NumTrials)
+	}
+	for _, tr := range agg.Trials {
+		if tr.Truth != TruthMalicious || tr.TargetedPackets == 0 {
+			t.Errorf("trial %d: truth %s with %d targeted packets", tr.TrialNum, tr.Truth, tr.TargetedPackets)
+		}
+	}
+	total := agg.TrustedRate + agg.CaughtIncompetentRate + agg.CaughtMaliciousRate + agg.SLABreachedRate + agg.InconclusiveRate
+	if total < 1-1e-9 || total > 1+1e-9 {
+		t.Errorf("verdict classes cover %v of the trials", total)
+	}
+	if agg.CorrectRate != agg.CaughtMaliciousRate {
+		t.Errorf("correct rate %v, want the caught-malicious rate %v", agg.CorrectRate, agg.CaughtMaliciousRate)
+	}
+	if want := agg.CaughtIncompetentRate + agg.CaughtMaliciousRate + agg.SLABreachedRate; agg.AccusedRate != want {
+		t.Errorf("accused rate %v, want %v", agg.AccusedRate, want)
+	}
+}
+
+func TestClassifyVerdict(t *testing.T) {
+	tie := verification.VerificationResult{Verdict: "DISHONEST", PosteriorH1: 0.5, PosteriorH2: 0.5}
+	for _, tc := range []struct {
+		res   verification.VerificationResult
+		truth Truth
+		want  string
+	}{
+		{verification.VerificationResult{Verdict: "TRUSTED"}, TruthMalicious, ClassTrusted},
+		{verification.VerificationResult{Verdict: "INSUFFICIENT_DATA"}, TruthHonest, ClassInconclusive},
+		{verification.VerificationResult{Verdict: "DISHONEST (SLA_BREACHED)"}, TruthIncompetent, ClassSLABreached},
+		{verification.VerificationResult{Verdict: "DISHONEST", PosteriorH1: 0.9, PosteriorH2: 0.1}, TruthMalicious, ClassCaughtIncompetent},
+		{verification.VerificationResult{Verdict: "DISHONEST", PosteriorH1: 0.1, PosteriorH2: 0.9}, TruthIncompetent, ClassCaughtMalicious},
+		{tie, TruthMalicious, ClassCaughtMalicious},
+		{tie, TruthIncompetent, ClassCaughtIncompetent},
+	} {
+		if got := classifyVerdict(tc.res, tc.truth); got != tc.want {
+			t.Errorf("%s (H1 %v, H2 %v) under %s: %s, want %s", tc.res.Verdict, tc.res.PosteriorH1, tc.res.PosteriorH2, tc.truth, got, tc.want)
+		}
+	}
+}
+
+func TestScenarioValidate(t *testing.T) {
+	switches := DefaultScenario()
+	switches.Regimes = []Regime{{Start: 10, Targeting: network.DefaultAllTargeting()}}
+	if err := switches.Validate(); err == nil {
+		t.Error("regimes under the batch verifier passed validation")
+	}
+	switches.Streaming = verification.DefaultStreamingConfig()
+	if err := switches.Validate(); err != nil {
+		t.Errorf("regimes under a streaming verifier: %v", err)
+	}
+
+	sc := DefaultScenario()
+	sc.Regimes = switches.Regimes
+	r := NewRunner()
+	r.Verbose = false
+	if agg := r.Run(sc); len(agg.Trials) != 0 {
+		t.Errorf("invalid scenario ran %d trials", len(agg.Trials))
+	}
+	if _, err := ParseScenario([]byte(`{"name": "x", "kind": "scenario",
+		"base": {"Regimes": [{"Start": 10}]},
+		"runs": [{"id": "a", "sweep": "Single", "output": "x.json"}]}`)); err == nil {
+		t.Error("scenario file with regimes and no streaming parsed")
+	}
+	if _, err := ParseScenario([]byte(`{"name": "x", "kind": "scenario",
+		"base": {"Verification": {"Prior": [1, 0, 1]}},
+		"runs": [{"id": "a", "sweep": "Single", "output": "x.json"}]}`)); err == nil {
+		t.Error("scenario file with a zero prior weight parsed")
+	}
+	if _, err := ParseScenario([]byte(`{"name": "x", "kind": "scenario",
+		"base": {"Truth": "MALICOUS"},
+		"runs": [{"id": "a", "sweep": "Single", "output": "x.json"}]}`)); err == nil {
+		t.Error("scenario file with a misspelt truth parsed")
+	}
+	if _, err := ParseScenario([]byte(`{"name": "x", "kind": "scenario",
+		"base": {"Timeline": {"events": [{"at": 10, "set": {"PFlag": 2}}]}},
+		"runs": [{"id": "a", "sweep": "Single", "output": "x.json"}]}`)); err == nil {
+		t.Error("scenario file with a timeline setting PFlag to 2 parsed")
+	}
+}
+
+// A path is scored against one liar, so a second must not validate, and a
+// segment whose targeting delays nothing is not one.
+func TestPathValidate(t *testing.T) {
+	cfg := LyingSegmentConfig(DefaultPathBaseline(), 1, 0.1, verification.ShiftExit)
+	cfg.Segments[0].Targeting = network.DefaultAdversarialTargeting(0)
+	if err := cfg.Validate(); err != nil || cfg.liar() != 1 {
+		t.Errorf("zero-rate targeting on segment 0: liar %d, %v", cfg.liar(), err)
+	}
+	cfg = LyingSegmentConfig(cfg, 2, 0.1, verification.ShiftExit)
+	if err := cfg.Validate(); err == nil {
+		t.Error("two lying segments passed validation")
+	}
+}
+
+// Honest base delays wander above the speed-of-light bound, so a physical
+// Path needs a tolerance that covers their spread; with one, honest trials
+// show no bound violations.
+func TestPhysicalBoundHonest(t *testing.T) {
+	sc := DefaultScenario()
+	sc.NumTrials, sc.NumPackets = 5, 500
+	path := LondonNewYork()
+	sc.Verification.Path = path
+	if err := sc.Validate(); err == nil {
+		t.Fatal("a Path with no InflationTolerance passed validation")
+	}
+
+	r := NewRunner()
+	r.Verbose = false
+	for _, tc := range []struct {
+		tol        float64
+		violations bool
+	}{
+		{sc.DelayModel.BaseDelayMax - path.MinDelay() + 1e-3, false},
+		{1e-3, true}, // the old default: honest jitter alone breaks it
+	} {
+		sc.Verification.InflationTolerance = tc.tol
+		total := 0
+		for _, tr := range r.Run(sc).Trials {
+			total += tr.BoundViolations
+		}
+		if got := total > 0; got != tc.violations {
+			t.Errorf("tolerance %.4f s: %d bound violations across honest trials", tc.tol, total)
+		}
+	}
+}
+
+func TestSwitchTimeIsEarliest(t *testing.T) {
+	sim := engine.NewSimulation()
+	dm := network.NewDelayModelConfig(DefaultScenario().DelayModel)
+	router := network.NewRouter(dm, network.DefaultHonestTargeting(), Flagging{}.fn())
+	prover := verification.NewProver(verification.AdversaryConfig{})
+	regimes := []Regime{{Start: 50}, {Start: 10}}
+	if at := scheduleRegimes(sim, router, prover, Flagging{}, regimes); at != 10 {
+		t.Errorf("switch time %v, want the earlier regime's 10", at)
+	}
+}
+
+// The pooled rates are one posterior over every trial's counts, not an
+// average of per-trial intervals.
+func TestPooledRates(t *testing.T) {
+	cfg := verification.DefaultVerificationConfig()
+	trials := []TrialResult{
+		{LieRate: cfg.RateEstimate(1, 10)},
+		{LieRate: cfg.RateEstimate(3, 10)},
+	}
+	s := trialStats(trials, cfg, false)
+	want := cfg.RateEstimate(4, 20)
+	if s.PooledLieRate != want {
+		t.Errorf("pooled lie rate %+v, want %+v", s.PooledLieRate, want)
+	}
+	if s.PooledLieRate.Lower > s.PooledLieRate.Mean || s.PooledLieRate.Upper < s.PooledLieRate.Mean {
+		t.Errorf("mean %v outside its interval [%v, %v]", s.PooledLieRate.Mean, s.PooledLieRate.Lower, s.PooledLieRate.Upper)
+	}
+}
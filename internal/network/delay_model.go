@@ -67,6 +67,27 @@ func (dm *DelayModel) Initialise(duration float64) {
 	dm.initialised = true
 }
 
+// Config returns the parameters the model is running with.
+func (dm *DelayModel) Config() DelayModelConfig {
+	return dm.config
+}
+
+// SetConfig changes the parameters from now on. The base-delay schedule was
+// drawn by Initialise and does not change with them.
+func (dm *DelayModel) SetConfig(cfg DelayModelConfig) {
+	dm.config = cfg
+}
+
+// BaseDelaySchedule returns when each path segment starts and its base delay,
+// in time order.
+func (dm *DelayModel) BaseDelaySchedule() (starts, delays []float64) {
+	for _, tr := range dm.transitions {
+		starts = append(starts, tr.time)
+		delays = append(delays, tr.baseDelay)
+	}
+	return starts, delays
+}
+
 func (dm *DelayModel) sampleBaseDelay() float64 {
 	return dm.config.BaseDelayMin + rand.Float64()*(dm.config.BaseDelayMax-dm.config.BaseDelayMin)
 }
@@ -1,7 +1,11 @@
 package network
 
 import (
+	"encoding/json"
+	"fmt"
 	"math/rand"
+	"slices"
+
 	"satnet-simulator/internal/engine"
 )
 
@@ -30,12 +34,45 @@ func (m TargetingMode) String() string {
 	return targetingModeNames[m]
 }
 
+// ParseTargetingMode is the inverse of String.
+func ParseTargetingMode(s string) (TargetingMode, error) {
+	for m, name := range targetingModeNames {
+		if name == s {
+			return TargetingMode(m), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown targeting mode %q", s)
+}
+
+// UnmarshalJSON accepts a mode's name as well as its number, so configs
+// written by hand can say "RANDOM".
+func (m *TargetingMode) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		mode, err := ParseTargetingMode(name)
+		if err != nil {
+			return err
+		}
+		*m = mode
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(b, &n); err != nil || n < int(TargetNone) || n > int(TargetAll) {
+		return fmt.Errorf("invalid targeting mode %s", b)
+	}
+	*m = TargetingMode(n)
+	return nil
+}
+
 type TargetingConfig struct {
 	Mode           TargetingMode
 	TargetFraction float64
 	Period         int
 	Quota          int
 	BatchSize      int
+	// Sources restricts targeting to packets from these sources; empty
+	// targets every source.
+	Sources []string
 }
 
 func DefaultHonestTargeting() TargetingConfig {
@@ -88,7 +125,15 @@ type Router struct {
 	Flagging        FlaggingFn
 	PacketsRouted   int
 	PacketsTargeted int
+	PacketsLost     int
 	quotaState      map[int]*batchQuotaState
+
+	// SpotProbe reports whether the adversary recognises pkt as a verifier
+	// probe. Recognised probes are never delayed; nil recognises none.
+	SpotProbe func(pkt Packet) bool
+
+	// Down models an outage: packets forwarded while it is set are lost.
+	Down bool
 }
 
 func NewRouter(delayModel *DelayModel, targeting TargetingConfig, flagging FlaggingFn) *Router {
@@ -114,6 +159,10 @@ func (r *Router) isTargeted(batchID int) bool {
 	return false
 }
 
+func (r *Router) targetsSource(src string) bool {
+	return len(r.TargetingCfg.Sources) == 0 || slices.Contains(r.TargetingCfg.Sources, src)
+}
+
 func (r *Router) isTargetedQuota(batchID int) bool {
 	B := r.TargetingCfg.BatchSize
 	k := r.TargetingCfg.Quota
@@ -153,8 +202,15 @@ func (r *Router) isTargetedQuota(batchID int) bool {
 }
 
 func (r *Router) Forward(sim *engine.Simulation, pkt Packet, dest Destination) {
+	if r.Down {
+		r.PacketsLost++
+		return
+	}
 	sendTime := sim.Now
-	isTargeted := r.isTargeted(pkt.BatchID)
+	isTargeted := r.targetsSource(pkt.Src) && r.isTargeted(pkt.BatchID)
+	if isTargeted && r.SpotProbe != nil && r.SpotProbe(pkt) {
+		isTargeted = false
+	}
 	r.PacketsRouted++
 	if isTargeted {
 		r.PacketsTargeted++
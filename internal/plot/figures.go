@@ -0,0 +1,267 @@
+package plot
+
+import (
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Line is one series of a LinePlot. Lower and Upper, when set, bound a
+// shaded band around it, such as a Wilson interval.
+type Line struct {
+	Label        string // empty leaves it out of the legend
+	X, Y         []float64
+	Lower, Upper []float64
+	Colour       int  // index into the palette
+	Faint        bool // thin and translucent, as behind a mean
+}
+
+// LinePlot is series against a shared x axis; NaN values leave gaps.
+type LinePlot struct {
+	Title, XLabel, YLabel string
+	LogX                  bool
+	YMin, YMax            float64 // both zero fits the data
+	Lines                 []Line
+}
+
+func (p LinePlot) WriteSVG(w io.Writer) error {
+	c := newCanvas(p.Title)
+	var xs, ys [][]float64
+	for _, l := range p.Lines {
+		xs = append(xs, l.X)
+		ys = append(ys, l.Y, l.Lower, l.Upper)
+	}
+	left, top, right, bottom := plotArea()
+	xlo, xhi, ok := extent(xs...)
+	if !ok {
+		xlo, xhi = 0, 1
+	}
+	ylo, yhi := p.YMin, p.YMax
+	if ylo == 0 && yhi == 0 {
+		if ylo, yhi, ok = extent(ys...); !ok {
+			ylo, yhi = 0, 1
+		}
+		if ylo == yhi {
+			ylo, yhi = ylo-1, yhi+1
+		}
+	}
+	x := scale{min: xlo, max: xhi, log: p.LogX && xlo > 0, lo: left, hi: right}
+	y := scale{min: ylo, max: yhi, lo: bottom, hi: top}
+	c.axes(x, y)
+
+	var labels, colours []string
+	for _, l := range p.Lines {
+		col := colour(l.Colour)
+		if l.Lower != nil && l.Upper != nil {
+			for _, run := range runs(l.X, l.Lower, l.Upper) {
+				var bx, by []float64
+				for _, i := range run {
+					bx, by = append(bx, x.at(l.X[i])), append(by, y.at(l.Upper[i]))
+				}
+				for k := len(run) - 1; k >= 0; k-- {
+					i := run[k]
+					bx, by = append(bx, x.at(l.X[i])), append(by, y.at(l.Lower[i]))
+				}
+				c.polygon(bx, by, col, 0.18)
+			}
+		}
+		width, opacity := 2.0, 1.0
+		if l.Faint {
+			width, opacity = 1, 0.45
+		}
+		for _, run := range runs(l.X, l.Y) {
+			var px, py []float64
+			for _, i := range run {
+				px, py = append(px, x.at(l.X[i])), append(py, y.at(l.Y[i]))
+			}
+			c.polyline(px, py, col, width, opacity)
+			if !l.Faint {
+				for i := range px {
+					c.circle(px[i], py[i], 2.5, col)
+				}
+			}
+		}
+		if l.Label != "" {
+			labels, colours = append(labels, l.Label), append(colours, col)
+		}
+	}
+	c.legend(labels, colours)
+	c.axisLabels(p.XLabel, p.YLabel)
+	return c.writeTo(w)
+}
+
+// runs splits the indices of xs into stretches where every series is finite.
+func runs(xs []float64, series ...[]float64) [][]int {
+	var out [][]int
+	var cur []int
+	for i := range xs {
+		ok := !math.IsNaN(xs[i])
+		for _, s := range series {
+			ok = ok && i < len(s) && !math.IsNaN(s[i]) && !math.IsInf(s[i], 0)
+		}
+		if ok {
+			cur = append(cur, i)
+			continue
+		}
+		if len(cur) > 0 {
+			out = append(out, cur)
+		}
+		cur = nil
+	}
+	if len(cur) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// Box summarises one distribution: whiskers at P10 and P90, the box between
+// the quartiles, a bar at the median.
+type Box struct {
+	X                        float64
+	N                        int // values it summarises; zero draws nothing
+	P10, Q1, Median, Q3, P90 float64
+}
+
+// BoxPlot shows one box per X value, evenly spaced in X order.
+type BoxPlot struct {
+	Title, XLabel, YLabel string
+	Boxes                 []Box
+}
+
+// Summarise is the Box of vs; it sorts vs.
+func Summarise(x float64, vs []float64) Box {
+	b := Box{X: x, N: len(vs)}
+	if len(vs) == 0 {
+		return b
+	}
+	slices.Sort(vs)
+	q := func(p float64) float64 { return vs[min(int(p*float64(len(vs))), len(vs)-1)] }
+	b.P10, b.Q1, b.Median, b.Q3, b.P90 = q(0.1), q(0.25), q(0.5), q(0.75), q(0.9)
+	return b
+}
+
+func (p BoxPlot) WriteSVG(w io.Writer) error {
+	c := newCanvas(p.Title)
+	left, top, right, bottom := plotArea()
+	var vs []float64
+	for _, b := range p.Boxes {
+		if b.N > 0 {
+			vs = append(vs, b.P10, b.P90)
+		}
+	}
+	lo, hi, ok := extent(vs)
+	if !ok {
+		lo, hi = 0, 1
+	}
+	if lo == hi {
+		lo, hi = lo-1, hi+1
+	}
+	y := scale{min: min(0, lo), max: hi, lo: bottom, hi: top}
+	for _, t := range y.ticks() {
+		py := y.at(t)
+		c.line(left, py, right, py, "#e5e5e5", 1, "")
+		c.text(left-6, py+4, label(t), "end", 11, 0)
+	}
+	c.rect(left, top, right-left, bottom-top, "none", "#333")
+
+	slot := (right - left) / float64(max(1, len(p.Boxes)))
+	every := max(1, len(p.Boxes)/12) // keep the labels apart
+	for i, b := range p.Boxes {
+		cx := left + slot*(float64(i)+0.5)
+		if i%every == 0 {
+			c.text(cx, bottom+16, label(b.X), "middle", 11, 0)
+		}
+		if b.N == 0 {
+			continue
+		}
+		half := min(slot*0.3, 18)
+		c.line(cx, y.at(b.P10), cx, y.at(b.P90), "#333", 1, "")
+		c.rect(cx-half, y.at(b.Q3), 2*half, y.at(b.Q1)-y.at(b.Q3), colour(0), "#333")
+		c.line(cx-half, y.at(b.Median), cx+half, y.at(b.Median), "white", 2, "")
+	}
+	c.axisLabels(p.XLabel, p.YLabel)
+	return c.writeTo(w)
+}
+
+// Heatmap colours a grid of values; X and Y label its columns and rows, and
+// Z[i][j] is the value at row Y[i], column X[j]. NaN cells are left grey.
+type Heatmap struct {
+	Title, XLabel, YLabel, ZLabel string
+	X, Y                          []float64
+	Z                             [][]float64
+	ZMin, ZMax                    float64 // both zero fits the data
+}
+
+// viridis is sampled at five stops and interpolated linearly between them.
+var viridis = [][3]float64{
+	{68, 1, 84}, {59, 82, 139}, {33, 145, 140}, {94, 201, 98}, {253, 231, 37},
+}
+
+func heat(t float64) string {
+	t = math.Max(0, math.Min(1, t)) * float64(len(viridis)-1)
+	i := min(int(t), len(viridis)-2)
+	f := t - float64(i)
+	var rgb [3]int
+	for k := range rgb {
+		rgb[k] = int(math.Round(viridis[i][k]*(1-f) + viridis[i+1][k]*f))
+	}
+	return "rgb(" + strconv.Itoa(rgb[0]) + "," + strconv.Itoa(rgb[1]) + "," + strconv.Itoa(rgb[2]) + ")"
+}
+
+func (h Heatmap) WriteSVG(w io.Writer) error {
+	c := newCanvas(h.Title)
+	left, top, right, bottom := plotArea()
+	lo, hi := h.ZMin, h.ZMax
+	if lo == 0 && hi == 0 {
+		var ok bool
+		if lo, hi, ok = extent(h.Z...); !ok {
+			lo, hi = 0, 1
+		}
+	}
+	norm := func(v float64) float64 {
+		if hi == lo {
+			return 0.5
+		}
+		return (v - lo) / (hi - lo)
+	}
+
+	cw := (right - left) / float64(max(1, len(h.X)))
+	ch := (bottom - top) / float64(max(1, len(h.Y)))
+	for i := range h.Y {
+		py := bottom - float64(i+1)*ch // first row at the bottom
+		for j := range h.X {
+			fill := "#cccccc"
+			if i < len(h.Z) && j < len(h.Z[i]) && !math.IsNaN(h.Z[i][j]) {
+				fill = heat(norm(h.Z[i][j]))
+			}
+			c.rect(left+float64(j)*cw, py, cw+0.5, ch+0.5, fill, "")
+		}
+	}
+	everyX, everyY := max(1, len(h.X)/10), max(1, len(h.Y)/12)
+	for j, v := range h.X {
+		if j%everyX == 0 {
+			c.text(left+(float64(j)+0.5)*cw, bottom+16, label(v), "middle", 11, 0)
+		}
+	}
+	for i, v := range h.Y {
+		if i%everyY == 0 {
+			c.text(left-6, bottom-(float64(i)+0.5)*ch+4, label(v), "end", 11, 0)
+		}
+	}
+	c.rect(left, top, right-left, bottom-top, "none", "#333")
+
+	// Colour bar.
+	const steps = 50
+	bx, bh := right+24, (bottom-top)/steps
+	for k := range steps {
+		c.rect(bx, bottom-float64(k+1)*bh, 16, bh+0.5, heat(float64(k)/(steps-1)), "")
+	}
+	bar := scale{min: lo, max: hi, lo: bottom, hi: top}
+	for _, t := range bar.ticks() {
+		c.text(bx+22, bar.at(t)+4, label(t), "start", 11, 0)
+	}
+	c.text(bx+8, top-8, h.ZLabel, "start", 11, 0)
+	c.axisLabels(h.XLabel, h.YLabel)
+	return c.writeTo(w)
+}
@@ -0,0 +1,167 @@
+package plot
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// phaseMap is a 2×3 grid in the saved-aggregate shape: p_target outermost
+// with p_flag derived from it, p_lie innermost.
+func phaseMap(t *testing.T) Results {
+	t.Helper()
+	var aggs []map[string]any
+	for _, pt := range []float64{0.01, 0.1} {
+		for _, pl := range []float64{0, 0.5, 1} {
+			aggs = append(aggs, map[string]any{
+				"Config": map[string]any{
+					"Name":      fmt.Sprintf("pm_%v_%v", pt, pl),
+					"NumTrials": 2,
+					"Targeting": map[string]any{"TargetFraction": pt},
+					"PFlag":     0.05 / pt,
+					"PLie":      pl,
+				},
+				"Trials": []map[string]any{
+					{"TrialNum": 0, "QueriesUsed": 10, "VerdictClass": "CAUGHT_MALICIOUS"},
+					{"TrialNum": 1, "QueriesUsed": 99, "VerdictClass": "INCONCLUSIVE"},
+				},
+				"TrustedRate":           1 - pl,
+				"CaughtMaliciousRate":   pl,
+				"SLABreachedRate":       0,
+				"TrustedRateCI":         map[string]any{"Lower": 0, "Upper": 1},
+				"CaughtMaliciousRateCI": map[string]any{"Lower": 0, "Upper": 1},
+				"SLABreachedRateCI":     map[string]any{"Lower": 0, "Upper": 0},
+			})
+		}
+	}
+	b, err := json.Marshal(aggs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ParseResults(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Name = "pm"
+	return res
+}
+
+func TestAxes(t *testing.T) {
+	res := phaseMap(t)
+	axes := res.Axes()
+	if len(axes) != 2 {
+		t.Fatalf("%d axes, want 2: %+v", len(axes), axes)
+	}
+	if axes[0].Label() != "PLie" || !slices.Equal(axes[0].distinct(), []float64{0, 0.5, 1}) {
+		t.Errorf("inner axis %+v", axes[0])
+	}
+	if want := []string{"Config.PFlag", "Config.Targeting.TargetFraction"}; !slices.Equal(slices.Sorted(slices.Values(axes[1].Fields)), want) {
+		t.Errorf("outer axis fields %v, want %v in lockstep", axes[1].Fields, want)
+	}
+
+	var names []string
+	for _, r := range res.Rates() {
+		names = append(names, r.Name)
+	}
+	if want := []string{"CaughtMaliciousRate", "TrustedRate"}; !slices.Equal(slices.Sorted(slices.Values(names)), want) {
+		t.Errorf("rates %v, want %v without the all-zero one", names, want)
+	}
+	if q := res.Aggregates[0].queries(); !slices.Equal(q, []float64{10}) {
+		t.Errorf("queries %v, want the conclusive trial's alone", q)
+	}
+}
+
+func TestFigures(t *testing.T) {
+	res := phaseMap(t)
+	figs, err := res.Figures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(figs) != 2 {
+		t.Fatalf("%d figures from a phase map, want a heatmap per rate", len(figs))
+	}
+	h := figs[0].SVG.(Heatmap)
+	if len(h.Z) != 2 || len(h.Z[0]) != 3 {
+		t.Fatalf("heatmap is %d×%d", len(h.Z), len(h.Z[0]))
+	}
+	for _, f := range figs {
+		checkSVG(t, f.SVG)
+	}
+
+	// One row of the grid is a one-axis sweep.
+	row := Results{Name: "row", Aggregates: res.Aggregates[:3]}
+	figs, err = row.Figures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(figs) != 2 || figs[0].Name != "rates" || figs[1].Name != "queries" {
+		t.Fatalf("figures %v", figs)
+	}
+	p := figs[0].SVG.(LinePlot)
+	if p.XLabel != "PLie" || len(p.Lines) != 2 || p.Lines[0].Lower == nil {
+		t.Errorf("rates plot %+v", p)
+	}
+	for _, f := range figs {
+		checkSVG(t, f.SVG)
+	}
+
+	overlay, err := Overlay("seeds", []Results{row, row})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var means int
+	for _, l := range overlay.Lines {
+		if !l.Faint {
+			means++
+		}
+	}
+	if len(overlay.Lines) != 6 || means != 2 {
+		t.Errorf("overlay has %d lines, %d of them means", len(overlay.Lines), means)
+	}
+	checkSVG(t, overlay)
+	if _, err := Overlay("mixed", []Results{row, res}); err == nil {
+		t.Error("overlaid a phase map on a sweep")
+	}
+}
+
+func TestScaleTicks(t *testing.T) {
+	for _, tc := range []struct {
+		s    scale
+		want []float64
+	}{
+		{scale{min: 0, max: 1}, []float64{0, 0.2, 0.4, 0.6, 0.8, 1}},
+		{scale{min: 0.001, max: 0.5, log: true}, []float64{0.001, 0.01, 0.1}},
+		{scale{min: 3, max: 3}, []float64{3}},
+	} {
+		if got := tc.s.ticks(); !slices.EqualFunc(got, tc.want, func(a, b float64) bool { return math.Abs(a-b) < 1e-12 }) {
+			t.Errorf("%+v: ticks %v, want %v", tc.s, got, tc.want)
+		}
+	}
+}
+
+func checkSVG(t *testing.T, fig interface{ WriteSVG(io.Writer) error }) {
+	t.Helper()
+	var b bytes.Buffer
+	if err := fig.WriteSVG(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "NaN") {
+		t.Error("SVG has NaN coordinates")
+	}
+	dec := xml.NewDecoder(&b)
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("malformed SVG: %v", err)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package plot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Results is a saved JSON array of aggregates of any kind, each flattened to
+// its leaves by Go path ("Config.Targeting.TargetFraction", "Trials.3.QueriesUsed")
+// in the order they were written.
+type Results struct {
+	Name       string // the file's base name without its extension
+	Aggregates []Aggregate
+}
+
+type Aggregate struct {
+	Keys   []string
+	Values map[string]any // float64, string, bool or nil
+}
+
+func (a Aggregate) number(key string) (float64, bool) {
+	v, ok := a.Values[key].(float64)
+	return v, ok
+}
+
+// ReadResults loads a results file written by the experiment runners.
+func ReadResults(path string) (Results, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Results{}, err
+	}
+	res, err := ParseResults(b)
+	res.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return res, err
+}
+
+func ParseResults(data []byte) (Results, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Results{}, fmt.Errorf("not a JSON array of aggregates: %w", err)
+	}
+	var res Results
+	for i, r := range raw {
+		a := Aggregate{Values: make(map[string]any)}
+		dec := json.NewDecoder(bytes.NewReader(r))
+		if err := walk(dec, "", &a); err != nil {
+			return res, fmt.Errorf("aggregate %d: %w", i, err)
+		}
+		res.Aggregates = append(res.Aggregates, a)
+	}
+	return res, nil
+}
+
+// walk reads one JSON value from dec, keeping object keys in order, which
+// decoding into a map would lose.
+func walk(dec *json.Decoder, prefix string, a *Aggregate) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		for i := 0; dec.More(); i++ {
+			key := strconv.Itoa(i)
+			if t == '{' {
+				k, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key = k.(string)
+			}
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if err := walk(dec, key, a); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token() // the closing delimiter
+		return err
+	}
+	a.Keys = append(a.Keys, prefix)
+	a.Values[prefix] = tok
+	return nil
+}
+
+// configPrefixes are where each kind of aggregate keeps what was run.
+var configPrefixes = []string{"Config.", "Scenario.", "Population."}
+
+// Axis is a swept parameter: the config fields that vary across the
+// aggregates in lockstep, such as p_target and the p_flag derived from it,
+// named after the first of them.
+type Axis struct {
+	Fields []string
+	Values []float64 // per aggregate, of the first field
+}
+
+func (ax Axis) Label() string {
+	for _, p := range configPrefixes {
+		if s, ok := strings.CutPrefix(ax.Fields[0], p); ok {
+			return s
+		}
+	}
+	return ax.Fields[0]
+}
+
+// distinct is ax's values without repeats, ascending.
+func (ax Axis) distinct() []float64 {
+	out := slices.Clone(ax.Values)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// changes counts how often ax moves between consecutive aggregates; the
+// innermost axis of a grid moves most.
+func (ax Axis) changes() int {
+	n := 0
+	for i := 1; i < len(ax.Values); i++ {
+		if ax.Values[i] != ax.Values[i-1] {
+			n++
+		}
+	}
+	return n
+}
+
+// Axes are the numeric config fields that differ between aggregates,
+// grouped into axes, innermost first.
+func (r Results) Axes() []Axis {
+	if len(r.Aggregates) < 2 {
+		return nil
+	}
+	first := r.Aggregates[0]
+	var axes []Axis
+	pattern := make(map[string]int) // rank sequence → index in axes
+	for _, key := range first.Keys {
+		if !slices.ContainsFunc(configPrefixes, func(p string) bool { return strings.HasPrefix(key, p) }) {
+			continue
+		}
+		values := make([]float64, len(r.Aggregates))
+		ok := true
+		for i, a := range r.Aggregates {
+			if values[i], ok = a.number(key); !ok {
+				break
+			}
+		}
+		if !ok || slices.Min(values) == slices.Max(values) {
+			continue
+		}
+		// Fields that rank the aggregates alike step together.
+		rank := make(map[float64]int)
+		var seq strings.Builder
+		for _, v := range values {
+			if _, seen := rank[v]; !seen {
+				rank[v] = len(rank)
+			}
+			fmt.Fprintf(&seq, "%d,", rank[v])
+		}
+		if i, ok := pattern[seq.String()]; ok {
+			axes[i].Fields = append(axes[i].Fields, key)
+			continue
+		}
+		pattern[seq.String()] = len(axes)
+		axes = append(axes, Axis{Fields: []string{key}, Values: values})
+	}
+	slices.SortStableFunc(axes, func(a, b Axis) int { return b.changes() - a.changes() })
+	return axes
+}
+
+// Rate is a top-level rate with its Wilson interval, such as TrustedRate and
+// TrustedRateCI.
+type Rate struct {
+	Name                string
+	Value, Lower, Upper []float64 // per aggregate; NaN where missing
+}
+
+// Rates are every rate with an interval that is non-zero in some aggregate,
+// in the order the first aggregate lists them.
+func (r Results) Rates() []Rate {
+	if len(r.Aggregates) == 0 {
+		return nil
+	}
+	var out []Rate
+	for _, key := range r.Aggregates[0].Keys {
+		if strings.Contains(key, ".") || !strings.HasSuffix(key, "Rate") {
+			continue
+		}
+		ci := key + "CI"
+		if _, ok := r.Aggregates[0].Values[ci+".Lower"]; !ok {
+			ci = strings.TrimSuffix(key, "Rate") + "CI" // CorrectAttributionRate's is CorrectAttributionCI
+			if _, ok := r.Aggregates[0].Values[ci+".Lower"]; !ok {
+				continue
+			}
+		}
+		rate := Rate{Name: key}
+		nonzero := false
+		for _, a := range r.Aggregates {
+			v, lo, hi := math.NaN(), math.NaN(), math.NaN()
+			if x, ok := a.number(key); ok {
+				v = x
+				nonzero = nonzero || x != 0
+			}
+			if x, ok := a.number(ci + ".Lower"); ok {
+				lo = x
+			}
+			if x, ok := a.number(ci + ".Upper"); ok {
+				hi = x
+			}
+			rate.Value, rate.Lower, rate.Upper = append(rate.Value, v), append(rate.Lower, lo), append(rate.Upper, hi)
+		}
+		if nonzero {
+			out = append(out, rate)
+		}
+	}
+	return out
+}
+
+// queries is aggregate a's queries to verdict, inconclusive trials aside.
+func (a Aggregate) queries() []float64 {
+	var out []float64
+	for i := 0; ; i++ {
+		prefix := "Trials." + strconv.Itoa(i) + "."
+		q, ok := a.number(prefix + "QueriesUsed")
+		if !ok {
+			if _, more := a.Values[prefix+"TrialNum"]; more {
+				continue
+			}
+			return out
+		}
+		if a.Values[prefix+"VerdictClass"] != "INCONCLUSIVE" {
+			out = append(out, q)
+		}
+	}
+}
+
+// Figure is a named figure ready to write.
+type Figure struct {
+	Name string // suffix for the file name: rates, queries or a rate's name
+	SVG  interface{ WriteSVG(io.Writer) error }
+}
+
+// logScale reports whether xs are positive and spread over enough orders of
+// magnitude to plot on a log axis.
+func logScale(xs []float64) bool {
+	lo, hi, ok := extent(xs)
+	return ok && lo > 0 && hi/lo >= 50
+}
+
+// Figures draws what suits r's shape. One swept axis, or none, gives the
+// rates against it with their bands and the queries-to-verdict distribution
+// at each point; two give a heatmap per rate, as for the phase maps. More
+// axes than two are plotted against the aggregate's index.
+func (r Results) Figures() ([]Figure, error) {
+	if len(r.Aggregates) == 0 {
+		return nil, errors.New("no aggregates")
+	}
+	rates := r.Rates()
+	axes := r.Axes()
+	if len(axes) == 2 {
+		if len(rates) == 0 {
+			return nil, errors.New("no rates to plot")
+		}
+		var out []Figure
+		for _, rate := range rates {
+			out = append(out, Figure{rate.Name, r.heatmap(rate, axes[0], axes[1])})
+		}
+		return out, nil
+	}
+
+	x, xLabel := r.index()
+	if len(axes) == 1 {
+		x, xLabel = axes[0].Values, axes[0].Label()
+	}
+	var out []Figure
+	if len(rates) > 0 {
+		p := LinePlot{Title: r.Name, XLabel: xLabel, YLabel: "rate", LogX: logScale(x), YMin: 0, YMax: 1}
+		for i, rate := range rates {
+			p.Lines = append(p.Lines, Line{
+				Label: rate.Name, X: x, Y: rate.Value, Lower: rate.Lower, Upper: rate.Upper, Colour: i,
+			})
+		}
+		out = append(out, Figure{"rates", p})
+	}
+	bp := BoxPlot{Title: r.Name + ": queries to verdict", XLabel: xLabel, YLabel: "queries"}
+	drawn := false
+	for i, a := range r.Aggregates {
+		q := a.queries()
+		drawn = drawn || len(q) > 0
+		bp.Boxes = append(bp.Boxes, Summarise(x[i], q))
+	}
+	if drawn {
+		out = append(out, Figure{"queries", bp})
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no rates or trials to plot")
+	}
+	return out, nil
+}
+
+func (r Results) index() ([]float64, string) {
+	x := make([]float64, len(r.Aggregates))
+	for i := range x {
+		x[i] = float64(i)
+	}
+	return x, "aggregate"
+}
+
+func (r Results) heatmap(rate Rate, xAxis, yAxis Axis) Heatmap {
+	h := Heatmap{
+		Title:  r.Name + ": " + rate.Name,
+		XLabel: xAxis.Label(), YLabel: yAxis.Label(), ZLabel: rate.Name,
+		X: xAxis.distinct(), Y: yAxis.distinct(),
+		ZMin: 0, ZMax: 1,
+	}
+	h.Z = make([][]float64, len(h.Y))
+	for i := range h.Z {
+		h.Z[i] = make([]float64, len(h.X))
+		for j := range h.Z[i] {
+			h.Z[i][j] = math.NaN()
+		}
+	}
+	for k := range r.Aggregates {
+		i, _ := slices.BinarySearch(h.Y, yAxis.Values[k])
+		j, _ := slices.BinarySearch(h.X, xAxis.Values[k])
+		h.Z[i][j] = rate.Value[k]
+	}
+	return h
+}
+
+// Overlay draws the same sweep run under several seeds, one file each: every
+// rate's per-seed curves faintly, with their mean over the seeds on top.
+// The files must sweep one axis over the same values.
+func Overlay(title string, seeds []Results) (LinePlot, error) {
+	if len(seeds) < 2 {
+		return LinePlot{}, errors.New("an overlay needs at least two result files")
+	}
+	var x []float64
+	var xLabel string
+	for _, s := range seeds {
+		axes := s.Axes()
+		if len(axes) > 1 {
+			return LinePlot{}, fmt.Errorf("%s sweeps %d axes; overlays take one", s.Name, len(axes))
+		}
+		sx, label := s.index()
+		if len(axes) == 1 {
+			sx, label = axes[0].Values, axes[0].Label()
+		}
+		if x == nil {
+			x, xLabel = sx, label
+			continue
+		}
+		if label != xLabel || !slices.Equal(sx, x) {
+			return LinePlot{}, fmt.Errorf("%s sweeps %s over other values than %s", s.Name, label, seeds[0].Name)
+		}
+	}
+
+	p := LinePlot{Title: title, XLabel: xLabel, YLabel: "rate", LogX: logScale(x), YMin: 0, YMax: 1}
+	names := make(map[string]int) // rate → colour, in first-seen order
+	sums := make(map[string][]float64)
+	counts := make(map[string][]int)
+	for _, s := range seeds {
+		for _, rate := range s.Rates() {
+			if _, ok := names[rate.Name]; !ok {
+				names[rate.Name] = len(names)
+				sums[rate.Name] = make([]float64, len(x))
+				counts[rate.Name] = make([]int, len(x))
+			}
+			p.Lines = append(p.Lines, Line{X: x, Y: rate.Value, Colour: names[rate.Name], Faint: true})
+			for i, v := range rate.Value {
+				if !math.IsNaN(v) {
+					sums[rate.Name][i] += v
+					counts[rate.Name][i]++
+				}
+			}
+		}
+	}
+	order := make([]string, len(names))
+	for name, i := range names {
+		order[i] = name
+	}
+	for _, name := range order {
+		mean := make([]float64, len(x))
+		for i := range mean {
+			mean[i] = math.NaN()
+			if counts[name][i] > 0 {
+				mean[i] = sums[name][i] / float64(counts[name][i])
+			}
+		}
+		p.Lines = append(p.Lines, Line{Label: name + " (seed mean)", X: x, Y: mean, Colour: names[name]})
+	}
+	return p, nil
+}
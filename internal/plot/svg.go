@@ -0,0 +1,215 @@
+// Package plot renders experiment results as SVG figures: verdict rates
+// against a swept parameter with their Wilson bands, seed overlays,
+// queries-to-verdict distributions and phase-map heatmaps. It reads the
+// JSON the experiment runners save, not their Go types, so figures can be
+// drawn from results written by older builds.
+package plot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Figure size and margins in SVG user units; the right margin holds the
+// legend or colour bar.
+const (
+	width        = 760
+	height       = 480
+	marginLeft   = 70
+	marginRight  = 190
+	marginTop    = 40
+	marginBottom = 60
+)
+
+// palette is Tableau 10, which stays distinguishable in print.
+var palette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac",
+}
+
+func colour(i int) string { return palette[i%len(palette)] }
+
+// canvas accumulates SVG elements.
+type canvas struct {
+	b strings.Builder
+}
+
+func newCanvas(title string) *canvas {
+	c := &canvas{}
+	fmt.Fprintf(&c.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		width, height, width, height)
+	c.rect(0, 0, width, height, "white", "")
+	c.text(marginLeft+(width-marginLeft-marginRight)/2, 24, title, "middle", 15, 0)
+	return c
+}
+
+func (c *canvas) writeTo(w io.Writer) error {
+	_, err := io.WriteString(w, c.b.String()+"</svg>\n")
+	return err
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func num(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+
+func (c *canvas) line(x1, y1, x2, y2 float64, stroke string, w float64, dash string) {
+	fmt.Fprintf(&c.b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s"`, num(x1), num(y1), num(x2), num(y2), stroke, num(w))
+	if dash != "" {
+		fmt.Fprintf(&c.b, ` stroke-dasharray="%s"`, dash)
+	}
+	c.b.WriteString("/>\n")
+}
+
+func (c *canvas) rect(x, y, w, h float64, fill, stroke string) {
+	fmt.Fprintf(&c.b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"`, num(x), num(y), num(w), num(h), fill)
+	if stroke != "" {
+		fmt.Fprintf(&c.b, ` stroke="%s"`, stroke)
+	}
+	c.b.WriteString("/>\n")
+}
+
+func (c *canvas) circle(x, y, r float64, fill string) {
+	fmt.Fprintf(&c.b, `<circle cx="%s" cy="%s" r="%s" fill="%s"/>`+"\n", num(x), num(y), num(r), fill)
+}
+
+// polyline draws the points in order; opacity below 1 fades it.
+func (c *canvas) polyline(xs, ys []float64, stroke string, w, opacity float64) {
+	fmt.Fprintf(&c.b, `<polyline fill="none" stroke="%s" stroke-width="%s" stroke-opacity="%s" points="%s"/>`+"\n",
+		stroke, num(w), num(opacity), points(xs, ys))
+}
+
+func (c *canvas) polygon(xs, ys []float64, fill string, opacity float64) {
+	fmt.Fprintf(&c.b, `<polygon fill="%s" fill-opacity="%s" stroke="none" points="%s"/>`+"\n", fill, num(opacity), points(xs, ys))
+}
+
+func points(xs, ys []float64) string {
+	parts := make([]string, len(xs))
+	for i := range xs {
+		parts[i] = num(xs[i]) + "," + num(ys[i])
+	}
+	return strings.Join(parts, " ")
+}
+
+// text draws s anchored at start, middle or end, rotated by rotate degrees.
+func (c *canvas) text(x, y float64, s, anchor string, size, rotate float64) {
+	fmt.Fprintf(&c.b, `<text x="%s" y="%s" text-anchor="%s" font-size="%s"`, num(x), num(y), anchor, num(size))
+	if rotate != 0 {
+		fmt.Fprintf(&c.b, ` transform="rotate(%s %s %s)"`, num(rotate), num(x), num(y))
+	}
+	fmt.Fprintf(&c.b, ">%s</text>\n", escape(s))
+}
+
+// plotArea is the rectangle inside the margins.
+func plotArea() (left, top, right, bottom float64) {
+	return marginLeft, marginTop, width - marginRight, height - marginBottom
+}
+
+// axisLabels writes the x label under the plot area and the y label beside it.
+func (c *canvas) axisLabels(xLabel, yLabel string) {
+	left, top, right, bottom := plotArea()
+	c.text((left+right)/2, bottom+42, xLabel, "middle", 13, 0)
+	c.text(left-52, (top+bottom)/2, yLabel, "middle", 13, -90)
+}
+
+// legend lists labels with their colours down the right margin.
+func (c *canvas) legend(labels, colours []string) {
+	_, top, right, _ := plotArea()
+	for i, l := range labels {
+		y := top + 10 + float64(i)*18
+		c.rect(right+14, y-8, 14, 10, colours[i], "")
+		c.text(right+34, y, l, "start", 11, 0)
+	}
+}
+
+// scale maps data values onto pixels, linearly or by log10.
+type scale struct {
+	min, max float64
+	log      bool
+	lo, hi   float64 // pixels for min and max
+}
+
+func (s scale) at(v float64) float64 {
+	a, b, x := s.min, s.max, v
+	if s.log {
+		a, b, x = math.Log10(a), math.Log10(b), math.Log10(v)
+	}
+	if b == a {
+		return (s.lo + s.hi) / 2
+	}
+	return s.lo + (x-a)/(b-a)*(s.hi-s.lo)
+}
+
+// ticks are round values spanning the scale: decades on a log scale, steps
+// of 1, 2 or 5 times a power of ten otherwise.
+func (s scale) ticks() []float64 {
+	var out []float64
+	if s.log {
+		for e := math.Floor(math.Log10(s.min)); e <= math.Ceil(math.Log10(s.max)); e++ {
+			if v := math.Pow(10, e); v >= s.min*(1-1e-9) && v <= s.max*(1+1e-9) {
+				out = append(out, v)
+			}
+		}
+		if len(out) >= 2 {
+			return out
+		}
+		out = nil // less than a decade: fall back to linear steps
+	}
+	span := s.max - s.min
+	if span <= 0 {
+		return []float64{s.min}
+	}
+	step := math.Pow(10, math.Floor(math.Log10(span/5)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if span/(step*m) <= 6 {
+			step *= m
+			break
+		}
+	}
+	for v := math.Ceil(s.min/step) * step; v <= s.max+step*1e-9; v += step {
+		out = append(out, math.Round(v/step)*step)
+	}
+	return out
+}
+
+func label(v float64) string {
+	return strconv.FormatFloat(v, 'g', 3, 64)
+}
+
+// axes draws the frame, grid lines and tick labels of a plot area.
+func (c *canvas) axes(x, y scale) {
+	left, top, right, bottom := plotArea()
+	for _, t := range y.ticks() {
+		py := y.at(t)
+		c.line(left, py, right, py, "#e5e5e5", 1, "")
+		c.text(left-6, py+4, label(t), "end", 11, 0)
+	}
+	for _, t := range x.ticks() {
+		px := x.at(t)
+		c.line(px, top, px, bottom, "#e5e5e5", 1, "")
+		c.text(px, bottom+16, label(t), "middle", 11, 0)
+	}
+	c.rect(left, top, right-left, bottom-top, "none", "#333")
+}
+
+// extent is the range of the finite values in vs; ok is false when there
+// are none.
+func extent(vs ...[]float64) (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, s := range vs {
+		for _, v := range s {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			lo, hi = min(lo, v), max(hi, v)
+		}
+	}
+	return lo, hi, lo <= hi
+}
@@ -0,0 +1,546 @@
+package verification
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// AuditLog is an append-only, hash-chained record of one verification run.
+// Each entry carries the hash of the previous one, so deleting, reordering or
+// editing any line breaks every hash after it. Answers are logged together
+// with the prover's signature, which makes each contradiction something the
+// operator cannot later deny.
+type AuditLog struct {
+	w    io.Writer
+	seq  int
+	prev string
+	err  error
+}
+
+// AuditEntry is one JSON line of the log. Exactly one of Header, Query,
+// SLACheck, BoundCheck, Commitment or Verdict is set, according to Kind.
+type AuditEntry struct {
+	Seq        int
+	Kind       string
+	PrevHash   string
+	Header     *AuditHeader        `json:",omitempty"`
+	Query      *AuditQuery         `json:",omitempty"`
+	Verdict    *VerificationResult `json:",omitempty"`
+	SLACheck   *AuditSLACheck      `json:",omitempty"`
+	BoundCheck *AuditBoundCheck    `json:",omitempty"`
+	Commitment *AuditCommitment    `json:",omitempty"`
+	Hash       string              `json:",omitempty"`
+}
+
+// AuditCommitment records a Merkle root the prover published for one batch.
+// Every later answer about the batch must prove inclusion under this root.
+type AuditCommitment struct {
+	BatchID int
+	Root    []byte
+	Size    int
+	Time    float64 // simulated seconds the root was published at
+}
+
+// AuditSLACheck records the streaming verifier breaching the SLA on the
+// router's own flags alone, before any query.
+type AuditSLACheck struct {
+	FlaggedPackets int
+	TotalPackets   int
+}
+
+// AuditBoundCheck records one audited batch's minimum held against the delay
+// ceiling at the time its fastest packet was sent.
+type AuditBoundCheck struct {
+	BatchID       int
+	SentTime      float64
+	BatchMinDelay float64
+	DelayCeiling  float64
+	Exceeded      bool
+}
+
+type AuditHeader struct {
+	Config         VerificationConfig
+	ProverKey      []byte // as the verifier logged it; a replay checks it against the prover's own
+	FlaggedPackets int
+	TotalPackets   int
+	Streaming      bool // counts grow during the run; SLA breaches arrive as SLA_CHECK entries
+	Continuous     bool // queries go on past a settled TRUSTED verdict; see StreamingConfig.Continuous
+}
+
+// AuditQuery holds everything needed to re-derive the verifier's conclusion
+// about one answer without trusting the verifier.
+type AuditQuery struct {
+	PacketID      int
+	BatchID       int
+	ObservedDelay float64
+	SentTime      float64
+	BatchMinDelay float64
+	Flagged       bool
+
+	Minimal   bool
+	Timestamp float64 // simulated seconds, as signed; the run's end from the batch verifier
+	AnswerSeq int     // the prover's answer count, as signed; no two answers share one
+	Signature []byte
+
+	// running totals the SLA check used; constant for the batch verifier,
+	// growing for the streaming one
+	FlaggedPackets int
+	TotalPackets   int
+
+	// HasProof records that the prover supplied a proof at all: the proof
+	// for a one-packet batch has no steps
+	CommitmentRoot []byte           `json:",omitempty"`
+	HasProof       bool             `json:",omitempty"`
+	ProofBatchID   int              `json:",omitempty"`
+	Proof          []AuditProofStep `json:",omitempty"`
+
+	Contradiction    bool
+	FlagInconsistent bool
+	ProofFailed      bool
+	SignatureFailed  bool
+
+	// no answer reached the verifier; Minimal, Timestamp and Signature are
+	// then meaningless
+	Unanswered bool `json:",omitempty"`
+	Refused    bool `json:",omitempty"`
+
+	SegmentBaseline      float64 `json:",omitempty"`
+	SegmentContradiction bool    `json:",omitempty"`
+	FalseFlag            bool    `json:",omitempty"`
+	FalseDenial          bool    `json:",omitempty"`
+	MinimumQuery         bool    `json:",omitempty"`
+	Probe                bool    `json:",omitempty"`
+	ProbeDelay           float64 `json:",omitempty"` // the verifier's own measurement
+	ProbeDelayed         bool    `json:",omitempty"`
+}
+
+type AuditProofStep struct {
+	Sibling []byte
+	Left    bool
+}
+
+const (
+	auditKindHeader  = "HEADER"
+	auditKindQuery   = "QUERY"
+	auditKindVerdict = "VERDICT"
+	auditKindSLA     = "SLA_CHECK"
+	auditKindBound   = "BOUND_CHECK"
+	auditKindCommit  = "COMMITMENT"
+)
+
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w}
+}
+
+// Err returns the first write error, if any. Logging never interrupts a run.
+func (l *AuditLog) Err() error {
+	if l == nil {
+		return nil
+	}
+	return l.err
+}
+
+func entryHash(e AuditEntry) (string, error) {
+	e.Hash = ""
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (l *AuditLog) append(e AuditEntry) {
+	if l == nil || l.err != nil {
+		return
+	}
+	e.Seq = l.seq
+	e.PrevHash = l.prev
+	h, err := entryHash(e)
+	if err != nil {
+		l.err = err
+		return
+	}
+	e.Hash = h
+	raw, err := json.Marshal(e)
+	if err != nil {
+		l.err = err
+		return
+	}
+	if _, err := l.w.Write(append(raw, '\n')); err != nil {
+		l.err = err
+		return
+	}
+	l.seq++
+	l.prev = h
+}
+
+func (l *AuditLog) writeHeader(cfg VerificationConfig, key ed25519.PublicKey, flagged, total int, streaming, continuous bool) {
+	l.append(AuditEntry{Kind: auditKindHeader, Header: &AuditHeader{
+		Config:         cfg,
+		ProverKey:      key,
+		FlaggedPackets: flagged,
+		TotalPackets:   total,
+		Streaming:      streaming,
+		Continuous:     continuous,
+	}})
+}
+
+func (l *AuditLog) writeQuery(q query, a answer, minDelay float64, flagged bool, c Commitment, e evidence, st *auditState) {
+	if l == nil {
+		return
+	}
+	aq := &AuditQuery{
+		PacketID:             q.packetID,
+		BatchID:              q.batchID,
+		ObservedDelay:        q.observedDelay,
+		SentTime:             q.sentTime,
+		BatchMinDelay:        minDelay,
+		Flagged:              flagged,
+		Minimal:              a.isMinimal,
+		Timestamp:            a.timestamp,
+		AnswerSeq:            a.seq,
+		Signature:            a.signature,
+		Contradiction:        e.contradiction,
+		FlagInconsistent:     e.flagInconsistent,
+		ProofFailed:          e.proofFailed,
+		SignatureFailed:      e.signatureFailed,
+		Unanswered:           e.unanswered,
+		Refused:              e.refused,
+		SegmentBaseline:      e.segmentBaseline,
+		SegmentContradiction: e.segmentContradiction,
+		FalseFlag:            e.falseFlag,
+		FalseDenial:          e.falseDenial,
+		MinimumQuery:         e.minimumQuery,
+		Probe:                e.probe,
+		ProbeDelay:           e.probeDelay,
+		ProbeDelayed:         e.probeDelayed,
+		FlaggedPackets:       st.flaggedCount,
+		TotalPackets:         st.totalPackets,
+	}
+	if c.Size > 0 {
+		aq.CommitmentRoot = c.Root[:]
+	}
+	if a.proof != nil {
+		aq.HasProof = true
+		aq.ProofBatchID = a.proof.batchID
+		for _, s := range a.proof.steps {
+			aq.Proof = append(aq.Proof, AuditProofStep{Sibling: s.sibling[:], Left: s.left})
+		}
+	}
+	l.append(AuditEntry{Kind: auditKindQuery, Query: aq})
+}
+
+func (l *AuditLog) writeSLACheck(st *auditState) {
+	l.append(AuditEntry{Kind: auditKindSLA, SLACheck: &AuditSLACheck{
+		FlaggedPackets: st.flaggedCount,
+		TotalPackets:   st.totalPackets,
+	}})
+}
+
+func (l *AuditLog) writeBoundCheck(batchID int, sentTime, batchMin, ceiling float64, exceeded bool) {
+	l.append(AuditEntry{Kind: auditKindBound, BoundCheck: &AuditBoundCheck{
+		BatchID:       batchID,
+		SentTime:      sentTime,
+		BatchMinDelay: batchMin,
+		DelayCeiling:  ceiling,
+		Exceeded:      exceeded,
+	}})
+}
+
+func (l *AuditLog) writeCommitment(c Commitment, at float64) {
+	l.append(AuditEntry{Kind: auditKindCommit, Commitment: &AuditCommitment{
+		BatchID: c.BatchID,
+		Root:    c.Root[:],
+		Size:    c.Size,
+		Time:    at,
+	}})
+}
+
+func (l *AuditLog) writeVerdict(res VerificationResult) {
+	l.append(AuditEntry{Kind: auditKindVerdict, Verdict: &res})
+}
+
+// AuditReport is what an independent replay of a log concluded.
+type AuditReport struct {
+	Entries           int
+	ChainIntact       bool
+	SignaturesChecked int
+	BadSignatures     []int // Seq of entries whose signature did not verify
+	LoggedVerdict     *VerificationResult
+	Replayed          VerificationResult
+	VerdictMatches    bool
+	Contradictions    []AuditEntry // signed contradictions, presentable to a third party
+	Problems          []string
+}
+
+func (r AuditReport) OK() bool {
+	return r.ChainIntact && r.VerdictMatches && len(r.BadSignatures) == 0 && len(r.Problems) == 0
+}
+
+// ReplayAuditLog checks the hash chain and every signature, then re-derives
+// the verdict from the logged observations alone. It returns an error only
+// when the log cannot be read; integrity failures are reported in Problems.
+//
+// proverKey is the prover's public key as obtained from the prover, not from
+// the log: a header key the verifier wrote itself proves nothing about who
+// signed. Signatures are checked against proverKey, and a header key that
+// differs from it is a problem. A nil proverKey checks them against the
+// header key, and a log with any signature then fails.
+func ReplayAuditLog(r io.Reader, proverKey ed25519.PublicKey) (AuditReport, error) {
+	rep := AuditReport{ChainIntact: true}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var hdr *AuditHeader
+	var st *auditState
+	roots := make(map[int][]byte) // published roots by batch ID
+	answerSeqs := make(map[int]bool)
+	prev := ""
+
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return rep, fmt.Errorf("entry %d: %w", rep.Entries, err)
+		}
+
+		if e.Seq != rep.Entries || e.PrevHash != prev {
+			rep.ChainIntact = false
+			rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: chain link broken", rep.Entries))
+		}
+		h, err := entryHash(e)
+		if err != nil {
+			return rep, err
+		}
+		if h != e.Hash {
+			rep.ChainIntact = false
+			rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: hash mismatch", rep.Entries))
+		}
+		prev = e.Hash
+		rep.Entries++
+
+		switch e.Kind {
+		case auditKindHeader:
+			if hdr != nil || e.Header == nil {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: unexpected header", e.Seq))
+				continue
+			}
+			hdr = e.Header
+			if proverKey != nil {
+				if !bytes.Equal(hdr.ProverKey, proverKey) {
+					rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: logged prover key is not the prover's key", e.Seq))
+				}
+				hdr.ProverKey = proverKey
+			}
+			st = newAuditState(hdr.Config, hdr.FlaggedPackets, hdr.TotalPackets)
+			if !hdr.Streaming && st.flagRateExceeded() {
+				st.slaBreached = true
+			}
+
+		case auditKindQuery:
+			if st == nil || e.Query == nil {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: query before header", e.Seq))
+				continue
+			}
+			if st.concluded(hdr.Continuous) {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: query after the verdict was already settled", e.Seq))
+			}
+			// a signed answer logged twice is a replay; logs from before
+			// answers were numbered carry 0 throughout
+			if seq := e.Query.AnswerSeq; seq > 0 {
+				if answerSeqs[seq] {
+					rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: answer %d logged twice", e.Seq, seq))
+				}
+				answerSeqs[seq] = true
+			}
+			e.Query.replay(&rep, e, hdr, st, roots)
+
+		case auditKindCommit:
+			if st == nil || e.Commitment == nil {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: commitment before header", e.Seq))
+				continue
+			}
+			if _, ok := roots[e.Commitment.BatchID]; ok {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: batch %d committed twice", e.Seq, e.Commitment.BatchID))
+				continue
+			}
+			roots[e.Commitment.BatchID] = e.Commitment.Root
+
+		case auditKindSLA:
+			if st == nil || e.SLACheck == nil {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: SLA check before header", e.Seq))
+				continue
+			}
+			st.flaggedCount, st.totalPackets = e.SLACheck.FlaggedPackets, e.SLACheck.TotalPackets
+			if !st.flagRateExceeded() {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: logged SLA breach does not follow from the logged counts", e.Seq))
+			}
+			st.slaBreached = true
+
+		case auditKindBound:
+			if st == nil || e.BoundCheck == nil {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: bound check before header", e.Seq))
+				continue
+			}
+			if st.concluded(hdr.Continuous) {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: bound check after the verdict was already settled", e.Seq))
+			}
+			b := e.BoundCheck
+			ceiling, ok := hdr.Config.delayCeiling(b.SentTime)
+			if !ok || ceiling != b.DelayCeiling {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: logged ceiling does not follow from the config", e.Seq))
+			}
+			if st.observeBound(b.BatchMinDelay, ceiling) != b.Exceeded {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: logged bound violation does not follow from the logged minimum", e.Seq))
+			}
+
+		case auditKindVerdict:
+			if st == nil || e.Verdict == nil {
+				rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: verdict before header", e.Seq))
+				continue
+			}
+			rep.LoggedVerdict = e.Verdict
+
+		default:
+			rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: unknown kind %q", e.Seq, e.Kind))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return rep, err
+	}
+
+	if st == nil {
+		return rep, errors.New("audit log has no header")
+	}
+	if proverKey == nil && rep.SignaturesChecked > 0 {
+		rep.Problems = append(rep.Problems, "signatures were checked against the logged key only; no prover key was given")
+	}
+	rep.Replayed = st.result()
+	if rep.LoggedVerdict == nil {
+		rep.Problems = append(rep.Problems, "log has no verdict entry")
+		return rep, nil
+	}
+	rep.VerdictMatches = sameVerdict(*rep.LoggedVerdict, rep.Replayed)
+	if !rep.VerdictMatches {
+		rep.Problems = append(rep.Problems, fmt.Sprintf("logged verdict %s does not match replayed verdict %s",
+			rep.LoggedVerdict.Verdict, rep.Replayed.Verdict))
+	}
+	return rep, nil
+}
+
+// probeEvidence re-derives the probe term from the verifier's logged
+// measurement.
+func (aq *AuditQuery) probeEvidence(e evidence) evidence {
+	if aq.Probe {
+		e.probe = true
+		e.probeDelay = aq.ProbeDelay
+		e.probeDelayed = probeDelayed(aq.ProbeDelay, aq.BatchMinDelay, aq.Flagged)
+	}
+	return e
+}
+
+func (aq *AuditQuery) replay(rep *AuditReport, e AuditEntry, hdr *AuditHeader, st *auditState, roots map[int][]byte) {
+	q := query{packetID: aq.PacketID, batchID: aq.BatchID, observedDelay: aq.ObservedDelay, sentTime: aq.SentTime}
+	a := answer{isMinimal: aq.Minimal, timestamp: aq.Timestamp, seq: aq.AnswerSeq, signature: aq.Signature}
+
+	st.flaggedCount, st.totalPackets = aq.FlaggedPackets, aq.TotalPackets
+	if aq.Unanswered || aq.Refused {
+		if aq.Unanswered && aq.Refused || aq.Contradiction || aq.FlagInconsistent || aq.ProofFailed || aq.SignatureFailed {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: unanswered query carries answer evidence", e.Seq))
+		}
+		st.observe(aq.probeEvidence(evidence{unanswered: aq.Unanswered, refused: aq.Refused}))
+		return
+	}
+	ev := aq.probeEvidence(assessAnswer(aq.ObservedDelay, aq.BatchMinDelay, aq.Flagged, a))
+	if hdr.Config.Baseline == BaselineSegment {
+		ev.segmentBaseline = aq.SegmentBaseline
+		ev.segmentExposed = segmentExposed(aq.ObservedDelay, aq.BatchMinDelay, aq.SegmentBaseline)
+		ev.segmentContradiction = segmentContradiction(aq.ObservedDelay, aq.BatchMinDelay, aq.SegmentBaseline, a)
+	}
+	if aq.MinimumQuery {
+		// which packets were sampled is the verifier's choice, but this one
+		// skips the contradiction check and so must have been the minimum
+		ev.minimumQuery = true
+		if aq.Flagged || aq.ObservedDelay > aq.BatchMinDelay {
+			rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: minimum query about a packet that was not the batch minimum", e.Seq))
+		}
+	}
+
+	sigOK := false
+	if len(aq.Signature) > 0 {
+		rep.SignaturesChecked++
+		sigOK = verifyAnswer(hdr.ProverKey, q, a)
+		if !sigOK {
+			rep.BadSignatures = append(rep.BadSignatures, e.Seq)
+		}
+	}
+	if hdr.Config.RequireSignatures {
+		ev.signatureFailed = !sigOK
+	}
+
+	if hdr.Config.RequireCommitments {
+		// the proof only counts under the root published for the batch
+		// before the query, not one the entry names itself
+		root, published := roots[aq.BatchID]
+		ev.proofFailed = !published || !bytes.Equal(root, aq.CommitmentRoot) || !aq.proofVerifies()
+	}
+
+	logged := ev
+	logged.contradiction = aq.Contradiction
+	logged.flagInconsistent = aq.FlagInconsistent
+	logged.proofFailed = aq.ProofFailed
+	logged.signatureFailed = aq.SignatureFailed
+	logged.segmentContradiction = aq.SegmentContradiction
+	logged.falseFlag = aq.FalseFlag
+	logged.falseDenial = aq.FalseDenial
+	logged.probe = aq.Probe
+	logged.probeDelay = aq.ProbeDelay
+	logged.probeDelayed = aq.ProbeDelayed
+	if ev != logged {
+		rep.Problems = append(rep.Problems, fmt.Sprintf("entry %d: logged evidence does not follow from the logged observation", e.Seq))
+	}
+	if ev.contradiction && sigOK {
+		rep.Contradictions = append(rep.Contradictions, e)
+	}
+	st.observe(ev)
+}
+
+func (aq *AuditQuery) proofVerifies() bool {
+	if len(aq.CommitmentRoot) != sha256.Size || !aq.HasProof || aq.ProofBatchID != aq.BatchID {
+		return false
+	}
+	steps := make([]proofStep, len(aq.Proof))
+	for i, s := range aq.Proof {
+		if len(s.Sibling) != sha256.Size {
+			return false
+		}
+		copy(steps[i].sibling[:], s.Sibling)
+		steps[i].left = s.Left
+	}
+	var root digest
+	copy(root[:], aq.CommitmentRoot)
+	leaf := commitmentLeaf(aq.PacketID, aq.ObservedDelay, aq.Flagged, aq.Minimal)
+	return verifyMerkleProof(leaf, steps, root)
+}
+
+func sameVerdict(a, b VerificationResult) bool {
+	const tol = 1e-9
+	return a.Verdict == b.Verdict &&
+		a.TotalQueries == b.TotalQueries &&
+		a.ContradictionsFound == b.ContradictionsFound &&
+		math.Abs(a.PosteriorH0-b.PosteriorH0) < tol &&
+		math.Abs(a.PosteriorH1-b.PosteriorH1) < tol &&
+		math.Abs(a.PosteriorH2-b.PosteriorH2) < tol
+}
@@ -0,0 +1,186 @@
+package verification
+
+import (
+	"bytes"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"satnet-simulator/internal/network"
+)
+
+func TestAuditLogReplay(t *testing.T) {
+	rand.Seed(11)
+	prover := NewProver(AdversaryConfig{AnsweringStr: AnswerLiesThatMinimal})
+	prover.EnableSigning()
+	id := 0
+	for b := range 40 {
+		for j := range 4 {
+			pkt := network.NewPacket(id, b, "Source", float64(b))
+			pkt.TotalDelay = 0.040 + 0.010*float64(j)
+			prover.RecordTransmission(pkt)
+			id++
+		}
+	}
+
+	cfg := DefaultVerificationConfig()
+	cfg.RequireSignatures = true
+	cfg.RequireCommitments = true
+
+	var buf bytes.Buffer
+	v := NewVerifier(prover, cfg)
+	v.IngestPackets(prover.Packets)
+	v.Audit = NewAuditLog(&buf)
+	res := v.RunVerification()
+	if err := v.Audit.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := ReplayAuditLog(bytes.NewReader(buf.Bytes()), prover.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() {
+		t.Fatalf("clean log failed replay: %v", rep.Problems)
+	}
+	if rep.Replayed.Verdict != res.Verdict || len(rep.Contradictions) != res.ContradictionsFound {
+		t.Errorf("replay disagrees: got %s with %d contradictions, run had %s with %d",
+			rep.Replayed.Verdict, len(rep.Contradictions), res.Verdict, res.ContradictionsFound)
+	}
+
+	// The prover retracting a signed claim breaks both the signature and the chain.
+	tampered := strings.Replace(buf.String(), `"Minimal":true`, `"Minimal":false`, 1)
+	rep, err = ReplayAuditLog(strings.NewReader(tampered), prover.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.OK() || rep.ChainIntact || len(rep.BadSignatures) == 0 {
+		t.Errorf("tampered log passed: chain=%t bad_sigs=%v", rep.ChainIntact, rep.BadSignatures)
+	}
+}
+
+// A replay trusts the prover's key, not the one the verifier logged.
+func TestAuditLogProverKey(t *testing.T) {
+	rand.Seed(11)
+	prover := NewProver(AdversaryConfig{AnsweringStr: AnswerHonest})
+	prover.EnableSigning()
+	for b := range 10 {
+		for j := range 2 {
+			pkt := network.NewPacket(2*b+j, b, "Source", float64(b))
+			pkt.TotalDelay = 0.040
+			prover.RecordTransmission(pkt)
+		}
+	}
+	cfg := DefaultVerificationConfig()
+	cfg.RequireSignatures = true
+	var buf bytes.Buffer
+	v := NewVerifier(prover, cfg)
+	v.IngestPackets(prover.Packets)
+	v.Audit = NewAuditLog(&buf)
+	v.RunVerification()
+
+	other := NewProver(AdversaryConfig{})
+	other.EnableSigning()
+	for _, tc := range []struct {
+		name string
+		key  []byte
+		ok   bool
+	}{
+		{"ProverKey", prover.PublicKey, true},
+		{"OtherKey", other.PublicKey, false},
+		{"NoKey", nil, false},
+	} {
+		rep, err := ReplayAuditLog(bytes.NewReader(buf.Bytes()), tc.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rep.OK() != tc.ok {
+			t.Errorf("%s: OK %t, want %t (problems %v)", tc.name, rep.OK(), tc.ok, rep.Problems)
+		}
+	}
+}
+
+// A one-leaf tree's inclusion proof has no steps; the log must still record
+// that it was supplied so the replay compares the leaf with the root.
+func TestAuditLogOneLeafProof(t *testing.T) {
+	prover := NewProver(AdversaryConfig{AnsweringStr: AnswerHonest})
+	pkt := network.NewPacket(0, 0, "Source", 0)
+	pkt.TotalDelay = 0.040
+	prover.RecordTransmission(pkt)
+	c := prover.CommitBatch(0)
+
+	q := query{packetID: 0, batchID: 0, observedDelay: 0.040}
+	a := prover.AnswerQuery(q)
+	if a.proof == nil || len(a.proof.steps) != 0 {
+		t.Fatalf("one-leaf proof: %+v", a.proof)
+	}
+
+	cfg := DefaultVerificationConfig()
+	cfg.RequireCommitments = true
+	var buf bytes.Buffer
+	log := NewAuditLog(&buf)
+	st := newAuditState(cfg, 0, 1)
+	log.writeHeader(cfg, nil, 0, 1, false, false)
+	log.writeCommitment(c, 0)
+	e := assessAnswer(q.observedDelay, 0.040, false, a)
+	st.observe(e)
+	log.writeQuery(q, a, 0.040, false, c, e, st)
+	log.writeVerdict(st.result())
+	if err := log.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := ReplayAuditLog(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() {
+		t.Fatalf("one-leaf proof failed replay: %v", rep.Problems)
+	}
+}
+
+// Batch answers all carry the same time, so only their seq keeps one signed
+// answer from standing in for another; a log holding one twice is a replay.
+func TestAuditLogAnswerReplayed(t *testing.T) {
+	prover := NewProver(AdversaryConfig{AnsweringStr: AnswerHonest})
+	prover.EnableSigning()
+	prover.Clock = func() float64 { return 100 }
+	for id := range 2 {
+		pkt := network.NewPacket(id, 0, "Source", 0)
+		pkt.TotalDelay = 0.040
+		prover.RecordTransmission(pkt)
+	}
+	q := query{packetID: 0, batchID: 0, observedDelay: 0.040}
+	a1, a2 := prover.AnswerQuery(q), prover.AnswerQuery(q)
+	if a1.timestamp != a2.timestamp || a1.seq == a2.seq {
+		t.Fatalf("answers stamped (%v, %d) and (%v, %d)", a1.timestamp, a1.seq, a2.timestamp, a2.seq)
+	}
+	swapped := a1
+	swapped.seq = a2.seq
+	if !verifyAnswer(prover.PublicKey, q, a1) || verifyAnswer(prover.PublicKey, q, swapped) {
+		t.Fatal("the signature should cover the answer's seq")
+	}
+
+	cfg := DefaultVerificationConfig()
+	cfg.RequireSignatures = true
+	var buf bytes.Buffer
+	log := NewAuditLog(&buf)
+	st := newAuditState(cfg, 0, 2)
+	log.writeHeader(cfg, prover.PublicKey, 0, 2, false, false)
+	for range 2 {
+		e := assessAnswer(q.observedDelay, 0.040, false, a1)
+		st.observe(e)
+		log.writeQuery(q, a1, 0.040, false, Commitment{}, e, st)
+	}
+	if err := log.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rep, err := ReplayAuditLog(bytes.NewReader(buf.Bytes()), prover.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(rep.Problems, func(p string) bool { return strings.Contains(p, "logged twice") }) {
+		t.Errorf("replayed answer not reported: %v", rep.Problems)
+	}
+}
@@ -0,0 +1,100 @@
+package verification
+
+import "math"
+
+const (
+	speedOfLightKmPerS = 299792.458
+	earthRadiusKm      = 6371.0
+)
+
+// GroundStation is a terminal on the Earth's surface, in degrees.
+type GroundStation struct {
+	Name string
+	Lat  float64
+	Lon  float64
+}
+
+// GroundPath is the route being audited: two ground stations talking through
+// a constellation shell at ShellAltitudeKm.
+type GroundPath struct {
+	From            GroundStation
+	To              GroundStation
+	ShellAltitudeKm float64
+}
+
+func (p GroundPath) configured() bool {
+	return p.ShellAltitudeKm > 0
+}
+
+// MinDelay is the one-way speed-of-light lower bound in seconds. Any path
+// that reaches the shell is at least as long as the single bounce off the
+// shell above the midpoint of the great circle, and inter-satellite links
+// only add to it.
+func (p GroundPath) MinDelay() float64 {
+	theta := centralAngle(p.From, p.To)
+	r, rs := earthRadiusKm, earthRadiusKm+p.ShellAltitudeKm
+	leg := math.Sqrt(r*r + rs*rs - 2*r*rs*math.Cos(theta/2))
+	return 2 * leg / speedOfLightKmPerS
+}
+
+// centralAngle is the haversine angle between two stations, in radians.
+func centralAngle(a, b GroundStation) float64 {
+	rad := math.Pi / 180
+	lat1, lat2 := a.Lat*rad, b.Lat*rad
+	dLat, dLon := lat2-lat1, (b.Lon-a.Lon)*rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * math.Asin(math.Sqrt(min(1, h)))
+}
+
+// DelayRange is an operator's declaration that the base delay of packets
+// sent in [From, To) lies within [Min, Max]. To = 0 leaves it open-ended.
+type DelayRange struct {
+	From float64
+	To   float64
+	Min  float64
+	Max  float64
+}
+
+func (r DelayRange) covers(t float64) bool {
+	return t >= r.From && (r.To <= 0 || t < r.To)
+}
+
+// inflationTolerance is InflationTolerance, or 1 ms when it is unset. Only a
+// declared range can rely on the default: Validate refuses a Path without an
+// explicit tolerance.
+func (cfg VerificationConfig) inflationTolerance() float64 {
+	if cfg.InflationTolerance <= 0 {
+		return 1e-3
+	}
+	return cfg.InflationTolerance
+}
+
+// delayCeiling is the largest credible batch minimum for a packet sent at t.
+// A declared range is the operator's own promise and wins over the physical
+// bound, which only knows the geometry and so needs a generous tolerance.
+func (cfg VerificationConfig) delayCeiling(t float64) (float64, bool) {
+	for _, r := range cfg.DeclaredBaseDelay {
+		if r.covers(t) {
+			return r.Max + cfg.inflationTolerance(), true
+		}
+	}
+	if cfg.Path.configured() {
+		return cfg.Path.MinDelay() + cfg.inflationTolerance(), true
+	}
+	return 0, false
+}
+
+// observeBound scores one audited batch's minimum against the ceiling. The
+// verifier sees every batch minimum itself, so this needs no answer and is
+// scored once per batch rather than per query.
+func (s *auditState) observeBound(batchMin, ceiling float64) bool {
+	s.forget()
+	before := [3]float64(s.logPost)
+	exceeded := batchMin > ceiling
+	if exceeded {
+		s.boundViolations++
+	}
+	s.add(s.lt.bound.logLikelihoods(exceeded))
+	s.remember(before)
+	return exceeded
+}
@@ -0,0 +1,89 @@
+package verification
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+
+	"satnet-simulator/internal/network"
+)
+
+func TestPathMinDelay(t *testing.T) {
+	station := GroundStation{Lat: 10, Lon: 20}
+	overhead := GroundPath{From: station, To: station, ShellAltitudeKm: 550}
+	if got, want := overhead.MinDelay(), 2*550/speedOfLightKmPerS; math.Abs(got-want) > 1e-12 {
+		t.Errorf("same-station bound = %.6f s, want straight up and down %.6f s", got, want)
+	}
+
+	london := GroundStation{Lat: 51.5074, Lon: -0.1278}
+	newYork := GroundStation{Lat: 40.7128, Lon: -74.0060}
+	path := GroundPath{From: london, To: newYork, ShellAltitudeKm: 550}
+	surface := centralAngle(london, newYork) * earthRadiusKm
+	if surface < 5500 || surface > 5600 {
+		t.Fatalf("London-New York great circle = %.0f km, want about 5570", surface)
+	}
+	if lb := path.MinDelay(); lb <= surface/speedOfLightKmPerS {
+		t.Errorf("bound %.4f s should exceed the surface distance at c, %.4f s", lb, surface/speedOfLightKmPerS)
+	}
+}
+
+func TestDeclaredRangeWinsOverPhysicalBound(t *testing.T) {
+	cfg := DefaultVerificationConfig()
+	cfg.Path = GroundPath{From: GroundStation{}, To: GroundStation{Lon: 30}, ShellAltitudeKm: 550}
+	cfg.DeclaredBaseDelay = []DelayRange{{From: 10, To: 20, Min: 0.040, Max: 0.040}}
+
+	if c, ok := cfg.delayCeiling(15); !ok || c != 0.040+cfg.inflationTolerance() {
+		t.Errorf("inside the declared range the ceiling is %.4f, want %.4f", c, 0.040+cfg.inflationTolerance())
+	}
+	if c, ok := cfg.delayCeiling(25); !ok || c != cfg.Path.MinDelay()+cfg.inflationTolerance() {
+		t.Errorf("outside it the ceiling is %.4f, want the physical bound", c)
+	}
+	cfg.Path = GroundPath{}
+	if _, ok := cfg.delayCeiling(25); ok {
+		t.Error("with neither a range nor a path there should be no ceiling")
+	}
+}
+
+// The verifier holds every audited batch minimum to the ceiling itself, once
+// per batch, so a prover that never calls anything minimal still shows up.
+func TestBoundCheckedOncePerBatch(t *testing.T) {
+	rand.Seed(3)
+	const batches = 10
+	prover := NewProver(AdversaryConfig{AnsweringStr: AnswerHonest})
+	id := 0
+	for b := range batches {
+		for range 4 {
+			pkt := network.NewPacket(id, b, "Source", float64(b))
+			pkt.TotalDelay = 0.090
+			pkt.IsTargeted = true
+			prover.RecordTransmission(pkt)
+			id++
+		}
+	}
+	cfg := DefaultVerificationConfig()
+	cfg.DeclaredBaseDelay = []DelayRange{{From: 0, To: batches, Min: 0.040, Max: 0.040}}
+	cfg.QueriesPerBatch = 4
+	cfg.ConfidenceThreshold = 1   // never settles, so every batch is audited
+	cfg.FlaggingRateThreshold = 0 // nor do the admitted delays breach the SLA
+
+	var buf bytes.Buffer
+	v := NewVerifier(prover, cfg)
+	v.IngestPackets(prover.Packets)
+	v.Audit = NewAuditLog(&buf)
+	res := v.RunVerification()
+	if res.BoundViolations != batches {
+		t.Errorf("%d bound violations over %d batches of %d queries", res.BoundViolations, batches, res.TotalQueries)
+	}
+	if res.PosteriorH2 <= res.PosteriorH0 {
+		t.Errorf("batch minima above the declared range should point away from H0: %+v", res)
+	}
+
+	rep, err := ReplayAuditLog(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() || rep.Replayed.BoundViolations != batches {
+		t.Errorf("replay found %d bound violations: %v", rep.Replayed.BoundViolations, rep.Problems)
+	}
+}
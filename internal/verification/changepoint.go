@@ -0,0 +1,139 @@
+package verification
+
+// BaselineMode selects what a queried packet's delay is compared against.
+type BaselineMode string
+
+const (
+	// BaselineBatch compares with the minimum of the packet's own batch.
+	BaselineBatch BaselineMode = ""
+	// BaselineSegment also compares with the minimum over the packet's
+	// base-delay segment, found by change-point detection over batch minima.
+	// Inflating a whole batch then no longer hides the delay, as long as some
+	// batch in the same segment got through clean. A base-delay segment
+	// shorter than MinSegmentLength looks exactly like an inflated batch, so
+	// these segment contradictions are weaker evidence than batch ones (see
+	// SegmentFalseAlarm).
+	BaselineSegment BaselineMode = "SEGMENT"
+)
+
+func (cfg VerificationConfig) segmentTolerance() float64 {
+	if cfg.SegmentTolerance <= 0 {
+		return 1e-3
+	}
+	return cfg.SegmentTolerance
+}
+
+func (cfg VerificationConfig) segmentFalseAlarm() float64 {
+	if cfg.SegmentFalseAlarm <= 0 {
+		return 0.03
+	}
+	return cfg.SegmentFalseAlarm
+}
+
+func (cfg VerificationConfig) minSegmentLength() int {
+	if cfg.MinSegmentLength <= 0 {
+		return 3
+	}
+	return cfg.MinSegmentLength
+}
+
+// changePointPenalty defaults to the squared tolerance: a shift larger than
+// the tolerance between two minimum-length segments then always pays for its
+// change point, and smaller shifts are absorbed by the tolerance anyway.
+func (cfg VerificationConfig) changePointPenalty() float64 {
+	if cfg.ChangePointPenalty > 0 {
+		return cfg.ChangePointPenalty
+	}
+	tol := cfg.segmentTolerance()
+	return tol * tol
+}
+
+// segmentStarts partitions xs into segments of at least minLen points with
+// PELT (Killick et al. 2012) under an L2 cost, and returns the index each
+// segment starts at. Series shorter than two segments are one segment.
+func segmentStarts(xs []float64, penalty float64, minLen int) []int {
+	n := len(xs)
+	if n < 2*minLen {
+		return []int{0}
+	}
+
+	sum := make([]float64, n+1)
+	sumSq := make([]float64, n+1)
+	for i, x := range xs {
+		sum[i+1] = sum[i] + x
+		sumSq[i+1] = sumSq[i] + x*x
+	}
+	cost := func(a, b int) float64 {
+		s := sum[b] - sum[a]
+		return sumSq[b] - sumSq[a] - s*s/float64(b-a)
+	}
+
+	best := make([]float64, n+1) // best[t]: optimal penalised cost of xs[:t]
+	last := make([]int, n+1)     // start of the final segment in that optimum
+	best[0] = -penalty
+	var candidates []int
+	for t := minLen; t <= n; t++ {
+		if s := t - minLen; s == 0 || s >= minLen {
+			candidates = append(candidates, s)
+		}
+		best[t], last[t] = 0, -1
+		for _, s := range candidates {
+			if c := best[s] + cost(s, t) + penalty; last[t] < 0 || c < best[t] {
+				best[t], last[t] = c, s
+			}
+		}
+		// prune starts that can never again beat the optimum at t
+		kept := candidates[:0]
+		for _, s := range candidates {
+			if best[s]+cost(s, t) <= best[t] {
+				kept = append(kept, s)
+			}
+		}
+		candidates = kept
+	}
+
+	var starts []int
+	for t := n; t > 0; t = last[t] {
+		starts = append(starts, last[t])
+	}
+	for i, j := 0, len(starts)-1; i < j; i, j = i+1, j-1 {
+		starts[i], starts[j] = starts[j], starts[i]
+	}
+	return starts
+}
+
+// segmentBaselines returns, for each batch minimum in time order, its
+// segment's minimum plus the tolerance, and the number of segments found.
+func (cfg VerificationConfig) segmentBaselines(minima []float64) ([]float64, int) {
+	starts := segmentStarts(minima, cfg.changePointPenalty(), cfg.minSegmentLength())
+	tol := cfg.segmentTolerance()
+	out := make([]float64, len(minima))
+	for k, a := range starts {
+		b := len(minima)
+		if k+1 < len(starts) {
+			b = starts[k+1]
+		}
+		segMin := minima[a]
+		for _, m := range minima[a+1 : b] {
+			segMin = min(segMin, m)
+		}
+		for i := a; i < b; i++ {
+			out[i] = segMin + tol
+		}
+	}
+	return out, len(starts)
+}
+
+// segmentContradiction is a minimal claim that only the segment baseline
+// refutes. Anything the batch minimum already refutes is a plain
+// contradiction and is not counted twice.
+func segmentContradiction(observedDelay, batchMin, segmentBaseline float64, ans answer) bool {
+	return ans.isMinimal && segmentExposed(observedDelay, batchMin, segmentBaseline)
+}
+
+// segmentExposed reports whether a query could turn up a segment
+// contradiction at all: it is about a batch minimum above the segment
+// baseline. Whatever the answer, no other query can.
+func segmentExposed(observedDelay, batchMin, segmentBaseline float64) bool {
+	return observedDelay <= batchMin && observedDelay > segmentBaseline
+}
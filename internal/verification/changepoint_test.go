@@ -0,0 +1,85 @@
+package verification
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSegmentStarts(t *testing.T) {
+	var xs []float64
+	for _, seg := range []struct {
+		base float64
+		n    int
+	}{{0.030, 8}, {0.065, 5}, {0.042, 10}} {
+		for range seg.n {
+			xs = append(xs, seg.base)
+		}
+	}
+	// a whole batch inflated by 50 ms in the middle of the last segment
+	xs[17] += 0.050
+
+	// the spike may get a segment of its own, but never one without a clean
+	// batch in it, so every batch keeps its true base delay as baseline
+	starts := segmentStarts(xs, 1e-6, 3)
+	for _, want := range []int{0, 8, 13} {
+		if !slices.Contains(starts, want) {
+			t.Errorf("segmentStarts = %v, missing the change at %d", starts, want)
+		}
+	}
+
+	cfg := DefaultVerificationConfig()
+	cfg.Baseline = BaselineSegment
+	baselines, _ := cfg.segmentBaselines(xs)
+	for i, b := range baselines {
+		want := xs[i] + cfg.segmentTolerance()
+		if i == 17 {
+			want = xs[16] + cfg.segmentTolerance()
+		}
+		if b != want {
+			t.Errorf("baseline of batch %d = %.4f, want %.4f", i, b, want)
+		}
+	}
+	if !segmentContradiction(xs[17], xs[17], baselines[17], answer{isMinimal: true}) {
+		t.Errorf("claiming the inflated batch minimum is minimal should contradict the segment baseline %.3f", baselines[17])
+	}
+	if segmentContradiction(xs[16], xs[16], baselines[16], answer{isMinimal: true}) {
+		t.Errorf("a clean batch should not contradict its own segment")
+	}
+}
+
+// Only a query about a batch minimum above the segment baseline can make a
+// segment contradiction; any other query must leave the segment term alone.
+func TestSegmentTermOnlyWhereExposed(t *testing.T) {
+	const batchMin, baseline = 0.050, 0.040
+	for _, tc := range []struct {
+		name     string
+		observed float64
+		baseline float64
+		exposed  bool
+	}{
+		{"AboveBatchMinimum", 0.060, baseline, false},
+		{"MinimumAtBaseline", batchMin, batchMin, false},
+		{"MinimumAboveBaseline", batchMin, baseline, true},
+	} {
+		ans := answer{isMinimal: tc.observed <= batchMin}
+		e := assessAnswer(tc.observed, batchMin, false, ans)
+		e.segmentBaseline = tc.baseline
+		e.segmentExposed = segmentExposed(tc.observed, batchMin, tc.baseline)
+		e.segmentContradiction = segmentContradiction(tc.observed, batchMin, tc.baseline, ans)
+		if e.segmentExposed != tc.exposed {
+			t.Errorf("%s: exposed %t, want %t", tc.name, e.segmentExposed, tc.exposed)
+		}
+
+		var post [2][3]float64
+		for i, mode := range []BaselineMode{BaselineBatch, BaselineSegment} {
+			cfg := DefaultVerificationConfig()
+			cfg.Baseline = mode
+			st := newAuditState(cfg, 0, 10)
+			st.observe(e)
+			post[i] = [3]float64(st.logPost)
+		}
+		if moved := post[0] != post[1]; moved != tc.exposed {
+			t.Errorf("%s: segment term moved the posterior: %t, want %t", tc.name, moved, tc.exposed)
+		}
+	}
+}
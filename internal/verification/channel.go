@@ -0,0 +1,132 @@
+package verification
+
+import (
+	"math/rand"
+
+	"satnet-simulator/internal/network"
+)
+
+// ChannelConfig is the control channel queries and answers travel over. Each
+// message takes Latency plus a uniform draw from [0, Jitter) seconds and is
+// lost with probability LossRate, independently in each direction.
+type ChannelConfig struct {
+	Latency  float64
+	Jitter   float64
+	LossRate float64
+}
+
+func (c ChannelConfig) delay() float64 {
+	if c.Jitter <= 0 {
+		return c.Latency
+	}
+	return c.Latency + rand.Float64()*c.Jitter
+}
+
+func (c ChannelConfig) lost() bool {
+	return c.LossRate > 0 && rand.Float64() < c.LossRate
+}
+
+// roundTripLoss is P(the query or its answer is lost).
+func (c ChannelConfig) roundTripLoss() float64 {
+	return 1 - (1-c.LossRate)*(1-c.LossRate)
+}
+
+// withChannel fills in an unset ChannelLoss from ch, so timeouts are
+// discounted by the loss the verifier actually expects. A ChannelLoss the
+// caller set, 0 included, is kept.
+func (cfg VerificationConfig) withChannel(ch ChannelConfig) VerificationConfig {
+	if cfg.ChannelLoss == nil {
+		loss := ch.roundTripLoss()
+		cfg.ChannelLoss = &loss
+	}
+	return cfg
+}
+
+// channelLoss is ChannelLoss, or 0 when it is unset.
+func (cfg VerificationConfig) channelLoss() float64 {
+	if cfg.ChannelLoss == nil {
+		return 0
+	}
+	return *cfg.ChannelLoss
+}
+
+// ResponsePolicy is how the prover treats a query once it arrives. The zero
+// value answers promptly.
+type ResponsePolicy string
+
+const (
+	RespondPromptly ResponsePolicy = ""
+	RespondLate     ResponsePolicy = "RESPOND_LATE"   // answers, but only after StallDelay
+	RespondRefuse   ResponsePolicy = "RESPOND_REFUSE" // sends an explicit refusal
+	RespondSilent   ResponsePolicy = "RESPOND_SILENT" // never replies
+)
+
+// TimeoutPolicy is how the verifier scores a query that was refused or not
+// answered before its deadline.
+type TimeoutPolicy string
+
+const (
+	// TimeoutIgnore counts the query but adds no evidence.
+	TimeoutIgnore TimeoutPolicy = ""
+	// TimeoutEvidence scores every query on whether it was answered, allowing
+	// for the loss the verifier expects from the channel.
+	TimeoutEvidence TimeoutPolicy = "TIMEOUT_EVIDENCE"
+	// TimeoutContradiction treats a missing answer as a caught lie.
+	TimeoutContradiction TimeoutPolicy = "TIMEOUT_CONTRADICTION"
+)
+
+// defaultStallDelay is how late AnswerDelayedHonest answers when no
+// StallDelay is configured.
+const defaultStallDelay = 2.0
+
+// reply is what the prover puts back on the channel for one query.
+type reply struct {
+	ans     answer
+	refused bool
+	silent  bool
+	delay   float64 // processing time before the reply is sent
+}
+
+// respond applies the prover's ResponsePolicy to q. AnswerDelayedHonest
+// without an explicit policy stalls on every query about a targeted packet:
+// it never lies, it just hopes the verifier gives up waiting.
+func (p *Prover) respond(q query) reply {
+	policy, rate, targetedOnly := p.Config.ResponsePolicy, p.Config.StallRate, p.Config.StallTargetedOnly
+	if policy == RespondPromptly && p.Config.AnsweringStr == AnswerDelayedHonest {
+		policy, rate, targetedOnly = RespondLate, 1.0, true
+	}
+
+	stall := policy != RespondPromptly
+	if stall && targetedOnly {
+		rec := p.record(q)
+		stall = rec != nil && rec.IsTargeted
+	}
+	if stall && rate < 1 {
+		stall = rand.Float64() < rate
+	}
+	if !stall {
+		return reply{ans: p.AnswerQuery(q)}
+	}
+
+	switch policy {
+	case RespondSilent:
+		p.Queries++
+		return reply{silent: true}
+	case RespondRefuse:
+		p.Queries++
+		return reply{refused: true}
+	}
+	delay := p.Config.StallDelay
+	if delay <= 0 {
+		delay = defaultStallDelay
+	}
+	return reply{ans: p.AnswerQuery(q), delay: delay}
+}
+
+// record finds the prover's own record of the queried packet, or nil.
+func (p *Prover) record(q query) *network.Packet {
+	if byDelay, ok := p.byTimeDelay[q.batchID]; ok {
+		return byDelay[q.observedDelay]
+	}
+	return nil
+}
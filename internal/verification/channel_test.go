@@ -0,0 +1,64 @@
+package verification
+
+import (
+	"testing"
+
+	"satnet-simulator/internal/network"
+)
+
+// The batch verifier sends its queries over the same channel as the streaming
+// one, so stalls, refusals and losses reach it too.
+func TestBatchQueriesUseChannel(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		adv        AdversaryConfig
+		channel    ChannelConfig
+		deadline   float64
+		unanswered bool
+	}{
+		{"Prompt", AdversaryConfig{}, ChannelConfig{Latency: 0.05}, 1, false},
+		{"LateNoDeadline", AdversaryConfig{ResponsePolicy: RespondLate, StallRate: 1, StallDelay: 2}, ChannelConfig{}, 0, false},
+		{"LatePastDeadline", AdversaryConfig{ResponsePolicy: RespondLate, StallRate: 1, StallDelay: 2}, ChannelConfig{}, 1, true},
+		{"Refused", AdversaryConfig{ResponsePolicy: RespondRefuse, StallRate: 1}, ChannelConfig{}, 0, true},
+		{"Silent", AdversaryConfig{ResponsePolicy: RespondSilent, StallRate: 1}, ChannelConfig{}, 1, true},
+		// Nothing is targeted, so a targeted-only staller answers everything.
+		{"TargetedOnly", AdversaryConfig{ResponsePolicy: RespondSilent, StallRate: 1, StallTargetedOnly: true}, ChannelConfig{}, 1, false},
+		{"Lost", AdversaryConfig{}, ChannelConfig{LossRate: 1}, 0, true},
+	} {
+		tc.adv.AnsweringStr = AnswerHonest
+		prover := NewProver(tc.adv)
+		id := 0
+		for b := range 20 {
+			for range 4 {
+				pkt := network.NewPacket(id, b, "Source", float64(b))
+				pkt.TotalDelay = 0.040
+				prover.RecordTransmission(pkt)
+				id++
+			}
+		}
+		v := NewVerifier(prover, DefaultVerificationConfig())
+		v.IngestPackets(prover.Packets)
+		v.Channel, v.QueryDeadline = tc.channel, tc.deadline
+		res := v.RunVerification()
+
+		want := 0
+		if tc.unanswered {
+			want = res.TotalQueries
+		}
+		if res.TotalQueries == 0 || res.Unanswered != want {
+			t.Errorf("%s: %d of %d queries unanswered, want %d", tc.name, res.Unanswered, res.TotalQueries, want)
+		}
+	}
+}
+
+func TestChannelLossExplicitZero(t *testing.T) {
+	lossy := ChannelConfig{LossRate: 0.1}
+	if got := DefaultVerificationConfig().withChannel(lossy).channelLoss(); got != lossy.roundTripLoss() {
+		t.Errorf("unset ChannelLoss became %v, want %v from the channel", got, lossy.roundTripLoss())
+	}
+	cfg := DefaultVerificationConfig()
+	cfg.ChannelLoss = new(float64)
+	if got := cfg.withChannel(lossy).channelLoss(); got != 0 {
+		t.Errorf("explicit ChannelLoss 0 became %v", got)
+	}
+}
@@ -0,0 +1,195 @@
+package verification
+
+import (
+	"math"
+	"slices"
+
+	"satnet-simulator/internal/network"
+)
+
+// Customers of the same operator each see only their own packets. An operator
+// that targets a few of them looks honest to the rest, and a victim auditing
+// alone can be fooled by an operator that slows its whole stream, since each
+// of its batches is then its own baseline. A Coalition is several customers
+// auditing the operator together.
+
+// PoolMode is what coalition members share with each other.
+type PoolMode string
+
+const (
+	// PoolQueries feeds every member's query results into one audit, taking a
+	// batch from each member in turn, until the pooled evidence settles.
+	PoolQueries PoolMode = ""
+	// PoolLikelihoods has each member audit alone and publish only its flag
+	// counts, query counts and log-likelihood totals: a handful of numbers
+	// that say nothing about any one packet. The members' log-likelihoods
+	// add up under H0 and H1, which would hold for every customer. H2 is
+	// read as the operator targeting one member, nobody knows which, so the
+	// other members' evidence counts under H2 as it does under H0.
+	PoolLikelihoods PoolMode = "LIKELIHOODS"
+)
+
+type CoalitionConfig struct {
+	Pool PoolMode
+	// Members also publish the minimum delay of each batch they sent, and
+	// measure their own packets against the fastest packet any member saw
+	// in that batch. Packets sent at the same time then form one comparison
+	// batch across customers.
+	CrossBatches bool
+}
+
+type Coalition struct {
+	Prover  *Prover
+	Members [][]*network.Packet // each member's own packets
+	Config  VerificationConfig
+	Sharing CoalitionConfig
+}
+
+// Run audits the operator on behalf of the whole coalition.
+func (c *Coalition) Run() VerificationResult {
+	verifiers := make([]*Verifier, len(c.Members))
+	for m, pkts := range c.Members {
+		verifiers[m] = &Verifier{Prover: c.Prover, Packets: pkts, Config: c.Config}
+	}
+	if c.Sharing.CrossBatches {
+		minima := coalitionMinima(c.Members)
+		for _, v := range verifiers {
+			v.peerMinima = minima
+		}
+	}
+	if c.Sharing.Pool == PoolLikelihoods {
+		return c.poolLikelihoods(verifiers)
+	}
+	return c.poolQueries(verifiers)
+}
+
+func (c *Coalition) poolQueries(verifiers []*Verifier) VerificationResult {
+	var flagged, total int
+	for _, v := range verifiers {
+		flagged += v.countFlaggedPackets()
+		total += len(v.Packets)
+	}
+	if total < 2 {
+		return insufficientData(c.Config)
+	}
+	st := newAuditState(c.Config, flagged, total)
+	if st.flagRateExceeded() {
+		st.slaBreached = true
+		return st.result()
+	}
+
+	plans := make([]*auditPlan, len(verifiers))
+	var segments int
+	for m, v := range verifiers {
+		plans[m] = v.plan()
+		segments += plans[m].segments
+	}
+	for i := 0; !st.settled(); i++ {
+		more := false
+		for m, v := range verifiers {
+			if i >= len(plans[m].order) || st.settled() {
+				continue
+			}
+			more = true
+			v.auditBatch(st, plans[m], plans[m].order[i])
+		}
+		if !more {
+			break
+		}
+	}
+	res := st.result()
+	res.Segments = segments
+	return res
+}
+
+func (c *Coalition) poolLikelihoods(verifiers []*Verifier) VerificationResult {
+	pooled := newAuditState(c.Config, 0, 0)
+	var segments int
+	var lls [][3]float64
+	for _, v := range verifiers {
+		st, n := v.audit()
+		if st == nil {
+			continue
+		}
+		pooled.pool(st)
+		lls = append(lls, st.logLikelihoods())
+		segments += n
+	}
+	if pooled.totalPackets < 2 {
+		return insufficientData(c.Config)
+	}
+	pooled.logPost[HypMalicious] = pooled.logPrior[HypMalicious] + unknownVictim(lls)
+	if pooled.flagRateExceeded() {
+		pooled.slaBreached = true
+	}
+	res := pooled.result()
+	res.Segments = segments
+	return res
+}
+
+// pool adds what another audit found to s. Only the other audit's
+// log-likelihoods are added, so the prior is counted once.
+func (s *auditState) pool(o *auditState) {
+	for i := range 3 {
+		s.logPost[i] += o.logPost[i] - o.logPrior[i]
+	}
+	s.flaggedCount += o.flaggedCount
+	s.totalPackets += o.totalPackets
+	s.queries += o.queries
+	s.contradictions += o.contradictions
+	s.hiddenDelaysFound += o.hiddenDelaysFound
+	s.proofFailures += o.proofFailures
+	s.signatureFailures += o.signatureFailures
+	s.unanswered += o.unanswered
+	s.segmentHits += o.segmentHits
+	s.boundViolations += o.boundViolations
+	s.probes += o.probes
+	s.probesDelayed += o.probesDelayed
+	s.falseFlags += o.falseFlags
+	s.falseDenials += o.falseDenials
+	s.lies += o.lies
+	s.aboveMinQueries += o.aboveMinQueries
+	s.unflaggedQueries += o.unflaggedQueries
+	s.slaBreached = s.slaBreached || o.slaBreached
+}
+
+func (s *auditState) logLikelihoods() [3]float64 {
+	var ll [3]float64
+	for i := range 3 {
+		ll[i] = s.logPost[i] - s.logPrior[i]
+	}
+	return ll
+}
+
+// unknownVictim is the log-likelihood of H2 across the coalition when the
+// operator targets exactly one member, each equally likely: the mean over
+// members of that member's H2 evidence times everyone else's H0 evidence.
+func unknownVictim(lls [][3]float64) float64 {
+	var sumH0 float64
+	for _, ll := range lls {
+		sumH0 += ll[HypHonest]
+	}
+	terms := make([]float64, len(lls))
+	for m, ll := range lls {
+		terms[m] = ll[HypMalicious] + sumH0 - ll[HypHonest]
+	}
+	top := slices.Max(terms)
+	var sum float64
+	for _, t := range terms {
+		sum += math.Exp(t - top)
+	}
+	return top + math.Log(sum/float64(len(terms)))
+}
+
+// coalitionMinima is the fastest delay any member saw in each batch.
+func coalitionMinima(members [][]*network.Packet) map[int]float64 {
+	out := make(map[int]float64)
+	for _, pkts := range members {
+		for _, p := range pkts {
+			if m, ok := out[p.BatchID]; !ok || p.TotalDelay < m {
+				out[p.BatchID] = p.TotalDelay
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package verification
+
+import (
+	"math"
+	"testing"
+
+	"satnet-simulator/internal/network"
+)
+
+func TestCrossBatchesExposeAWholeStreamTarget(t *testing.T) {
+	// member 0's every packet is held back 50 ms, member 1's go straight through
+	prover := NewProver(AdversaryConfig{AnsweringStr: AnswerParametric, LieRate: 1})
+	members := make([][]*network.Packet, 2)
+	id := 0
+	for b := range 5 {
+		for m := range 2 {
+			for range 3 {
+				rec := network.Packet{ID: id, BatchID: b, IsTargeted: m == 0}
+				rec.BaseDelay = 0.020
+				rec.TotalDelay = 0.020
+				if m == 0 {
+					rec.TargetedDelay = 0.050
+					rec.TotalDelay += 0.050
+				}
+				prover.RecordTransmission(rec)
+				id++
+			}
+		}
+	}
+	for _, p := range prover.Packets {
+		m := 1
+		if p.IsTargeted {
+			m = 0
+		}
+		members[m] = append(members[m], p)
+	}
+
+	for _, pool := range []PoolMode{PoolQueries, PoolLikelihoods} {
+		for _, cross := range []bool{false, true} {
+			c := &Coalition{Prover: prover, Members: members, Config: DefaultVerificationConfig(),
+				Sharing: CoalitionConfig{Pool: pool, CrossBatches: cross}}
+			res := c.Run()
+			if caught := res.ContradictionsFound > 0; caught != cross {
+				t.Errorf("pool %q, cross batches %v: %d contradictions", pool, cross, res.ContradictionsFound)
+			}
+			if cross && res.Verdict != "DISHONEST" {
+				t.Errorf("pool %q with cross batches: verdict %s", pool, res.Verdict)
+			}
+		}
+	}
+}
+
+func TestUnknownVictim(t *testing.T) {
+	lone := [3]float64{-2, -1, 3}
+	if got := unknownVictim([][3]float64{lone}); got != lone[HypMalicious] {
+		t.Errorf("one member: got %v, want its own H2 evidence %v", got, lone[HypMalicious])
+	}
+	// two clean members: H2 is as likely as it is for either alone
+	clean := [3]float64{0, -1, -3}
+	if got := unknownVictim([][3]float64{clean, clean}); math.Abs(got-clean[HypMalicious]) > 1e-12 {
+		t.Errorf("two clean members: got %v, want %v", got, clean[HypMalicious])
+	}
+}
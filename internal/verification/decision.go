@@ -0,0 +1,162 @@
+package verification
+
+import "math"
+
+// Indices into VerificationConfig.Prior and the columns of the loss matrix.
+const (
+	HypHonest = iota
+	HypIncompetent
+	HypMalicious
+)
+
+// Rows of the loss matrix. Every DISHONEST verdict, including an SLA breach,
+// is an accusation; TRUSTED and INCONCLUSIVE both leave the customer on the
+// network, so they cost the same as trusting.
+const (
+	ActTrust = iota
+	ActAccuse
+)
+
+// DecisionRule selects how the hypothesis model stops and what it concludes.
+type DecisionRule string
+
+const (
+	// DecideThreshold stops once any posterior exceeds ConfidenceThreshold.
+	DecideThreshold DecisionRule = ""
+	// DecideBayesRisk stops once one more query is not expected to lower the
+	// Bayes risk by more than QueryCost, then takes the action with the least
+	// posterior expected loss.
+	DecideBayesRisk DecisionRule = "BAYES_RISK"
+)
+
+// LossMatrix is indexed [action][hypothesis].
+type LossMatrix [2][3]float64
+
+// DefaultLossMatrix is 0-1 loss: every wrong call costs one unit.
+func DefaultLossMatrix() LossMatrix {
+	return LossMatrix{
+		ActTrust:  {HypHonest: 0, HypIncompetent: 1, HypMalicious: 1},
+		ActAccuse: {HypHonest: 1, HypIncompetent: 0, HypMalicious: 0},
+	}
+}
+
+func (cfg VerificationConfig) lossMatrix() LossMatrix {
+	if cfg.Loss == (LossMatrix{}) {
+		return DefaultLossMatrix()
+	}
+	return cfg.Loss
+}
+
+// logPrior normalises Prior; the zero value is the uniform 1/3 prior.
+// Validate has already refused weights that are not all above 0.
+func (cfg VerificationConfig) logPrior() []float64 {
+	p := cfg.Prior
+	sum := p[0] + p[1] + p[2]
+	if p == ([3]float64{}) {
+		p, sum = [3]float64{1, 1, 1}, 3
+	}
+	out := make([]float64, 3)
+	for i := range 3 {
+		out[i] = math.Log(p[i] / sum)
+	}
+	return out
+}
+
+// actionRisks returns the posterior expected loss of trusting and of accusing.
+func (l LossMatrix) actionRisks(post [3]float64) [2]float64 {
+	var r [2]float64
+	for a := range 2 {
+		for h := range 3 {
+			r[a] += l[a][h] * post[h]
+		}
+	}
+	return r
+}
+
+func (l LossMatrix) bayesRisk(post [3]float64) float64 {
+	r := l.actionRisks(post)
+	return min(r[0], r[1])
+}
+
+// lookaheadRisk is the Bayes risk expected after one more query, averaged over
+// the predictive distribution of its (contradiction, flagInconsistent) outcome.
+// Proof, signature and timeout terms are left out, so the value of a query is
+// if anything understated and the verifier errs towards stopping.
+func (s *auditState) lookaheadRisk(post [3]float64, loss LossMatrix) float64 {
+	var expected float64
+	for c := range 2 {
+		for f := range 2 {
+			ll := s.lt.jointLogLikelihoods(c == 1, f == 1)
+			var next [3]float64
+			var pe float64
+			for h := range 3 {
+				next[h] = post[h] * math.Exp(ll[h])
+				pe += next[h]
+			}
+			if pe == 0 {
+				continue
+			}
+			for h := range 3 {
+				next[h] /= pe
+			}
+			expected += pe * loss.bayesRisk(next)
+		}
+	}
+	return expected
+}
+
+// bayesSettled is the one-step lookahead stopping rule.
+func (s *auditState) bayesSettled() bool {
+	post := normaliseLogPosterior(s.logPost)
+	loss := s.cfg.lossMatrix()
+	return loss.bayesRisk(post)-s.lookaheadRisk(post, loss) <= s.cfg.QueryCost
+}
+
+// bayesResult takes the least-risk action, whether or not the stopping rule
+// fired: when the evidence runs out a decision still has to be made.
+func (s *auditState) bayesResult(res VerificationResult) VerificationResult {
+	post := [3]float64{res.PosteriorH0, res.PosteriorH1, res.PosteriorH2}
+	risks := s.cfg.lossMatrix().actionRisks(post)
+	if risks[ActAccuse] < risks[ActTrust] {
+		res.Verdict = "DISHONEST"
+		res.Trustworthy = false
+		res.Confidence = post[HypIncompetent] + post[HypMalicious]
+	} else {
+		res.Verdict = "TRUSTED"
+		res.Trustworthy = true
+		res.Confidence = post[HypHonest]
+	}
+	return res
+}
+
+// withCosts fills in the posterior expected cost of the verdict: its expected
+// loss plus what the queries cost.
+func (cfg VerificationConfig) withCosts(res VerificationResult) VerificationResult {
+	post := [3]float64{res.PosteriorH0, res.PosteriorH1, res.PosteriorH2}
+	res.ExpectedCost = cfg.lossMatrix().actionRisks(post)[verdictAction(res.Verdict)] +
+		cfg.QueryCost*float64(res.TotalQueries)
+	return res
+}
+
+// RealisedCost is what the verdict actually cost given the true hypothesis.
+func (cfg VerificationConfig) RealisedCost(res VerificationResult, truth int) float64 {
+	return cfg.lossMatrix()[verdictAction(res.Verdict)][truth] + cfg.QueryCost*float64(res.TotalQueries)
+}
+
+func verdictAction(verdict string) int {
+	switch verdict {
+	case "TRUSTED", "INCONCLUSIVE", "INSUFFICIENT_DATA":
+		return ActTrust
+	}
+	return ActAccuse
+}
+
+// insufficientData is the result when there is nothing to audit: the prior,
+// unchanged.
+func insufficientData(cfg VerificationConfig) VerificationResult {
+	post := normaliseLogPosterior(cfg.logPrior())
+	return cfg.withCosts(VerificationResult{
+		Verdict: "INSUFFICIENT_DATA", Trustworthy: true,
+		PosteriorH0: post[0], PosteriorH1: post[1], PosteriorH2: post[2],
+	})
+}
@@ -0,0 +1,155 @@
+package verification
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"satnet-simulator/internal/network"
+)
+
+func TestUniformPriorUnchanged(t *testing.T) {
+	for i, lp := range DefaultVerificationConfig().logPrior() {
+		if lp != math.Log(1.0/3) {
+			t.Errorf("default prior on H%d = %v, want log(1/3)", i, math.Exp(lp))
+		}
+	}
+}
+
+func TestPriorValidation(t *testing.T) {
+	for _, tc := range []struct {
+		prior [3]float64
+		ok    bool
+	}{
+		{[3]float64{}, true},
+		{[3]float64{1, 2, 3}, true},
+		{[3]float64{1, 0, 1}, false},
+		{[3]float64{2, -1, 1}, false},
+		{[3]float64{math.NaN(), 1, 1}, false},
+		{[3]float64{math.Inf(1), 1, 1}, false},
+	} {
+		cfg := DefaultVerificationConfig()
+		cfg.Prior = tc.prior
+		if err := cfg.Validate(); (err == nil) != tc.ok {
+			t.Errorf("Prior %v: Validate() = %v", tc.prior, err)
+		}
+		if tc.ok {
+			for i, lp := range cfg.logPrior() {
+				if math.IsNaN(lp) || math.IsInf(lp, 0) {
+					t.Errorf("Prior %v: log prior on H%d is %v", tc.prior, i, lp)
+				}
+			}
+		}
+	}
+}
+
+func TestBayesRiskStopping(t *testing.T) {
+	cfg := DefaultVerificationConfig()
+	cfg.DecisionRule = DecideBayesRisk
+
+	t.Run("ExpensiveQueriesStopAtPrior", func(t *testing.T) {
+		c := cfg
+		c.QueryCost = 10
+		c.Loss = DefaultLossMatrix()
+		c.Loss[ActTrust][HypMalicious] = 20
+		st := newAuditState(c, 0, 100)
+		if !st.settled() {
+			t.Fatal("a query costing more than any loss should never be worth asking")
+		}
+		if res := st.result(); res.Verdict != "DISHONEST" {
+			t.Errorf("with trusting a malicious operator 20x worse, the prior should accuse; got %s", res.Verdict)
+		}
+	})
+
+	t.Run("CleanAnswersEndTrusted", func(t *testing.T) {
+		c := cfg
+		c.QueryCost = 1e-4
+		st := newAuditState(c, 0, 1000)
+		for range 1000 {
+			if st.settled() {
+				break
+			}
+			st.observe(evidence{})
+		}
+		res := st.result()
+		if !st.settled() || res.Verdict != "TRUSTED" {
+			t.Errorf("clean answers should settle TRUSTED, got %s after %d queries", res.Verdict, res.TotalQueries)
+		}
+		want := res.PosteriorH1 + res.PosteriorH2 + c.QueryCost*float64(res.TotalQueries)
+		if math.Abs(res.ExpectedCost-want) > 1e-12 {
+			t.Errorf("expected cost %.6f, want %.6f", res.ExpectedCost, want)
+		}
+	})
+}
+
+func TestFalseFlagsScoredOnlyWhenChecked(t *testing.T) {
+	e := assessAnswer(0.020, 0.020, true, answer{isMinimal: false})
+	if !e.falseFlag {
+		t.Fatal("a flagged packet at the batch minimum is a false flag")
+	}
+	for _, check := range []bool{false, true} {
+		cfg := DefaultVerificationConfig()
+		cfg.CheckFalseFlags = check
+		st := newAuditState(cfg, 0, 10)
+		st.observe(e)
+		res := st.result()
+		if got := res.FalseFlags > 0; got != check {
+			t.Errorf("CheckFalseFlags=%v: %d false flags counted", check, res.FalseFlags)
+		}
+		if has := slices.Contains(res.EvidenceTypes(), EvidenceFalseFlag); has != check {
+			t.Errorf("CheckFalseFlags=%v: evidence types %v", check, res.EvidenceTypes())
+		}
+	}
+}
+
+// A packet above the batch minimum cannot be a false flag, so checking for
+// them must leave its score alone.
+func TestFalseFlagsNotScoredAboveMinimum(t *testing.T) {
+	for _, flagged := range []bool{false, true} {
+		e := assessAnswer(0.030, 0.020, flagged, answer{isMinimal: false})
+		var post [2][3]float64
+		for i, check := range []bool{false, true} {
+			cfg := DefaultVerificationConfig()
+			cfg.CheckFalseFlags = check
+			st := newAuditState(cfg, 0, 10)
+			st.observe(e)
+			post[i] = [3]float64(st.logPost)
+		}
+		if post[0] != post[1] {
+			t.Errorf("flagged=%v: checking false flags moved the posterior from %v to %v", flagged, post[0], post[1])
+		}
+	}
+}
+
+func TestFalseDenialReplacesHiddenDelay(t *testing.T) {
+	cfg := DefaultVerificationConfig()
+	cfg.QueryMinimum = true
+	e := assessAnswer(0.020, 0.020, false, answer{isMinimal: false})
+	if !e.falseDenial || !e.flagInconsistent {
+		t.Fatalf("denying the unflagged batch minimum: %+v", e)
+	}
+	st := newAuditState(cfg, 0, 10)
+	st.observe(e)
+	res := st.result()
+	if res.FalseDenials != 1 || res.HiddenDelayRate.Hits != 0 {
+		t.Errorf("false denials %d, hidden delays %d; want 1 and 0", res.FalseDenials, res.HiddenDelayRate.Hits)
+	}
+	if res.PosteriorH2 <= res.PosteriorH1 {
+		t.Errorf("a false denial should point at H2, got H1=%.3f H2=%.3f", res.PosteriorH1, res.PosteriorH2)
+	}
+
+	pkt := func(id int, delay float64, flagged bool) *network.Packet {
+		p := &network.Packet{ID: id, IsFlagged: flagged}
+		p.TotalDelay = delay
+		return p
+	}
+	plan := []*network.Packet{pkt(1, 0.030, false)}
+	batch := []*network.Packet{plan[0], pkt(2, 0.020, true), pkt(3, 0.020, false)}
+	got, added := withMinimum(plan, batch, 0.020)
+	if added == nil || added.ID != 3 || len(got) != 2 || got[0] != added {
+		t.Errorf("withMinimum should put the unflagged minimum first, got %v", added)
+	}
+	if _, added := withMinimum(got, batch, 0.020); added != nil {
+		t.Error("a plan that already covers the minimum should be left alone")
+	}
+}
@@ -0,0 +1,25 @@
+package verification
+
+import "satnet-simulator/internal/network"
+
+// withMinimum makes sure the plan asks about the batch minimum. A random
+// sample of one or two packets rarely lands on it, and it is the only packet
+// where "not minimal" can be refuted: nothing faster went through, and an
+// unflagged packet admits no congestion. The fastest unflagged packet is put
+// first unless the plan already covers one, and returned as added.
+func withMinimum(plan, batch []*network.Packet, minDelay float64) (out []*network.Packet, added *network.Packet) {
+	atMin := func(p *network.Packet) bool {
+		return !p.IsFlagged && p.TotalDelay <= minDelay
+	}
+	for _, p := range plan {
+		if atMin(p) {
+			return plan, nil
+		}
+	}
+	for _, p := range batch {
+		if atMin(p) {
+			return append([]*network.Packet{p}, plan...), p
+		}
+	}
+	return plan, nil
+}
@@ -0,0 +1,287 @@
+package verification
+
+import (
+	"math"
+)
+
+// evidence is everything the verifier concludes from a single answer.
+type evidence struct {
+	contradiction    bool
+	flagInconsistent bool
+	falseFlag        bool // flagged, yet it was the batch minimum
+	falseDenial      bool // unflagged batch minimum claimed not minimal
+	minimumQuery     bool // asked because it was the batch minimum, not sampled
+	proofFailed      bool
+	signatureFailed  bool
+	unanswered       bool // deadline passed with no answer
+	refused          bool
+
+	// what the verifier saw before asking; the denominators of the rate model
+	aboveMin bool
+	flagged  bool
+
+	segmentBaseline      float64 // only under BaselineSegment
+	segmentExposed       bool    // a batch minimum above the segment baseline
+	segmentContradiction bool
+
+	probe        bool    // the packet is one of the verifier's own probes
+	probeDelay   float64 // as the verifier measured it
+	probeDelayed bool
+}
+
+func assessAnswer(observedDelay, minDelay float64, flagged bool, ans answer) evidence {
+	return evidence{
+		contradiction:    ans.isMinimal && observedDelay > minDelay,
+		flagInconsistent: !ans.isMinimal && !flagged,
+		falseFlag:        flagged && observedDelay <= minDelay,
+		falseDenial:      !ans.isMinimal && !flagged && observedDelay <= minDelay,
+		aboveMin:         observedDelay > minDelay,
+		flagged:          flagged,
+	}
+}
+
+// auditState folds evidence into the posterior one answer at a time. The batch
+// verifier and the audit-log replay both go through it so they cannot drift.
+type auditState struct {
+	cfg      VerificationConfig
+	lt       *likelihoodTable
+	logPost  []float64
+	logPrior []float64
+	logAlpha float64
+	recent   [][3]float64 // per-query moves of logPost still inside the Window
+
+	flaggedCount int
+	totalPackets int
+
+	queries           int
+	contradictions    int
+	hiddenDelaysFound int
+	proofFailures     int
+	signatureFailures int
+	unanswered        int
+	segmentHits       int
+	boundViolations   int
+	probes            int
+	probesDelayed     int
+	falseFlags        int
+	falseDenials      int
+	slaBreached       bool
+
+	// answered queries the rate model counts over
+	lies             int
+	aboveMinQueries  int
+	unflaggedQueries int
+}
+
+func newAuditState(cfg VerificationConfig, flaggedCount, totalPackets int) *auditState {
+	return &auditState{
+		cfg:          cfg,
+		lt:           newLikelihoodTable(cfg.Epsilon, cfg.ErrorTolerance, cfg.channelLoss(), cfg.segmentFalseAlarm()),
+		logPost:      cfg.logPrior(),
+		logPrior:     cfg.logPrior(),
+		logAlpha:     math.Log(cfg.ConfidenceThreshold),
+		flaggedCount: flaggedCount,
+		totalPackets: totalPackets,
+	}
+}
+
+// flagRateExceeded is the up-front SLA check on the router's own flags.
+func (s *auditState) flagRateExceeded() bool {
+	return s.cfg.FlaggingRateThreshold > 0 &&
+		float64(s.flaggedCount)/float64(s.totalPackets) > s.cfg.FlaggingRateThreshold
+}
+
+func (s *auditState) settled() bool {
+	if s.slaBreached {
+		return true
+	}
+	if s.cfg.Model == ModelRates {
+		verdict, _ := s.rateVerdict()
+		return verdict != "INCONCLUSIVE"
+	}
+	if s.cfg.DecisionRule == DecideBayesRisk {
+		return s.bayesSettled()
+	}
+	return maxLogExceeds(s.logPost, s.logAlpha)
+}
+
+// concluded reports whether the audit is over: at the first settled verdict,
+// or under continuous monitoring at the first settled accusation. A monitor
+// that stopped at TRUSTED would never see the operator change.
+func (s *auditState) concluded(continuous bool) bool {
+	if !s.settled() {
+		return false
+	}
+	return !continuous || verdictAction(s.verdict().Verdict) == ActAccuse
+}
+
+func (s *auditState) add(ll [3]float64) {
+	for i := range 3 {
+		s.logPost[i] += ll[i]
+	}
+}
+
+func (s *auditState) observe(e evidence) {
+	s.forget()
+	before := [3]float64(s.logPost)
+	s.score(e)
+	s.remember(before)
+}
+
+func (s *auditState) score(e evidence) {
+	s.queries++
+	if e.probe {
+		// the verifier timed the probe itself, so this stands whether or not
+		// the prover answers
+		s.probes++
+		if e.probeDelayed {
+			s.probesDelayed++
+		}
+		s.add(s.lt.probe.logLikelihoods(e.probeDelayed))
+	}
+	if e.unanswered || e.refused {
+		// A refusal is scored like a timeout: it tells the verifier no more
+		// than silence would.
+		s.unanswered++
+		switch s.cfg.TimeoutPolicy {
+		case TimeoutEvidence:
+			s.add(s.lt.unanswered.logLikelihoods(true))
+		case TimeoutContradiction:
+			s.contradictions++
+			s.add(s.lt.jointLogLikelihoods(true, false))
+		}
+		return
+	}
+	if s.cfg.TimeoutPolicy == TimeoutEvidence {
+		s.add(s.lt.unanswered.logLikelihoods(false))
+	}
+	if s.cfg.Baseline == BaselineSegment && e.segmentExposed {
+		// a query that could not have made a segment contradiction is not
+		// scored as having passed
+		if e.segmentContradiction {
+			s.segmentHits++
+		}
+		s.add(s.lt.segment.logLikelihoods(e.segmentContradiction))
+	}
+	if s.cfg.CheckFalseFlags && !e.aboveMin {
+		// Only a packet at the batch minimum can be falsely flagged; one
+		// above it is not scored as having passed.
+		if e.falseFlag {
+			s.falseFlags++
+		}
+		s.add(s.lt.falseFlag.logLikelihoods(e.falseFlag))
+	}
+	denied := s.cfg.QueryMinimum && e.falseDenial
+	if s.cfg.QueryMinimum && !e.aboveMin && !e.flagged {
+		// Only an unflagged batch minimum can be falsely denied. Nothing
+		// faster went through, so there is no delay for the denial to
+		// admit: it is not a hidden delay, and not scored as one.
+		if denied {
+			s.falseDenials++
+			e.flagInconsistent = false
+		}
+		s.add(s.lt.falseDenial.logLikelihoods(denied))
+	}
+	if e.aboveMin {
+		s.aboveMinQueries++
+		if e.contradiction {
+			s.lies++
+		}
+	}
+	if !e.flagged {
+		s.unflaggedQueries++
+	}
+	if e.contradiction {
+		s.contradictions++
+	}
+	if e.flagInconsistent {
+		s.hiddenDelaysFound++
+	}
+
+	// A false denial takes the place of the contradiction check, which a
+	// "not minimal" answer can never fail. A packet asked about because it
+	// was the minimum could never have failed it either, so it is not
+	// scored as having passed.
+	if !denied && !e.minimumQuery {
+		s.add(s.lt.jointLogLikelihoods(e.contradiction, e.flagInconsistent))
+	}
+
+	if s.cfg.RequireCommitments || s.cfg.RequireSignatures {
+		if e.proofFailed {
+			s.proofFailures++
+		}
+		if e.signatureFailed {
+			s.signatureFailures++
+		}
+		s.add(s.lt.proofFailure.logLikelihoods(e.proofFailed || e.signatureFailed))
+	}
+
+	// The corrected flag rate accounts for both packets explicitly flagged by the
+	// router and unflagged packets the verifier proved were delayed due to incompetence.
+	if e.flagInconsistent && s.cfg.FlaggingRateThreshold > 0 {
+		correctedFlagRate := float64(s.hiddenDelaysFound+s.flaggedCount) / float64(s.totalPackets)
+		if correctedFlagRate > s.cfg.FlaggingRateThreshold {
+			s.slaBreached = true
+		}
+	}
+}
+
+func (s *auditState) result() VerificationResult {
+	return s.cfg.withCosts(s.verdict())
+}
+
+func (s *auditState) verdict() VerificationResult {
+	post := normaliseLogPosterior(s.logPost)
+	res := VerificationResult{
+		TotalQueries:          s.queries,
+		ContradictionsFound:   s.contradictions,
+		ProofFailures:         s.proofFailures,
+		SignatureFailures:     s.signatureFailures,
+		Unanswered:            s.unanswered,
+		SegmentContradictions: s.segmentHits,
+		BoundViolations:       s.boundViolations,
+		ProbesQueried:         s.probes,
+		ProbesDelayed:         s.probesDelayed,
+		FalseFlags:            s.falseFlags,
+		FalseDenials:          s.falseDenials,
+		PosteriorH0:           post[0],
+		PosteriorH1:           post[1],
+		PosteriorH2:           post[2],
+	}
+
+	res.LieRate = s.cfg.RateEstimate(s.lies, s.aboveMinQueries)
+	res.HiddenDelayRate = s.cfg.RateEstimate(s.hiddenDelaysFound, s.unflaggedQueries)
+
+	if s.slaBreached {
+		res.Verdict = "DISHONEST (SLA_BREACHED)"
+		res.Confidence = 1.0
+		res.Trustworthy = false
+		return res
+	}
+	if s.cfg.Model == ModelRates {
+		return s.rateResult(res)
+	}
+	if s.cfg.DecisionRule == DecideBayesRisk {
+		return s.bayesResult(res)
+	}
+
+	res.Verdict = "INCONCLUSIVE"
+	res.Trustworthy = post[0] >= post[1] && post[0] >= post[2]
+	res.Confidence = max(post[0], post[1], post[2])
+
+	alpha := s.cfg.ConfidenceThreshold
+	if post[2] > alpha {
+		res.Verdict = "DISHONEST"
+		res.Trustworthy = false
+		res.Confidence = post[2]
+	} else if post[1] > alpha {
+		res.Verdict = "DISHONEST"
+		res.Trustworthy = false
+		res.Confidence = post[1]
+	} else if post[0] > alpha {
+		res.Verdict = "TRUSTED"
+		res.Trustworthy = true
+		res.Confidence = post[0]
+	}
+	return res
+}
@@ -0,0 +1,40 @@
+package verification
+
+// An operator can be honest for weeks and then start targeting. Evidence from
+// the honest weeks would then hold the posterior on H0 long after the switch,
+// so the hypothesis model can be told to discount old evidence: Forgetting
+// pulls the log posterior back towards the prior by a fixed share before
+// every query, and Window drops each query's evidence once Window newer
+// queries have been scored. Window wins if both are set. Neither touches the
+// rate model or the flag-rate SLA, which stay cumulative.
+
+// forget discounts everything scored so far by the Forgetting share.
+func (s *auditState) forget() {
+	f := s.cfg.Forgetting
+	if s.cfg.Window > 0 || f <= 0 {
+		return
+	}
+	f = min(f, 1)
+	for i := range 3 {
+		s.logPost[i] = s.logPrior[i] + (1-f)*(s.logPost[i]-s.logPrior[i])
+	}
+}
+
+// remember records what the query just scored moved the log posterior by,
+// and takes back the oldest query's share once the window is full.
+func (s *auditState) remember(before [3]float64) {
+	if s.cfg.Window <= 0 {
+		return
+	}
+	var moved [3]float64
+	for i := range 3 {
+		moved[i] = s.logPost[i] - before[i]
+	}
+	s.recent = append(s.recent, moved)
+	if len(s.recent) > s.cfg.Window {
+		for i := range 3 {
+			s.logPost[i] -= s.recent[0][i]
+		}
+		s.recent = s.recent[1:]
+	}
+}
@@ -0,0 +1,41 @@
+package verification
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWindowDropsOldEvidence(t *testing.T) {
+	cfg := DefaultVerificationConfig()
+	cfg.Window = 1
+	st := newAuditState(cfg, 0, 100)
+	st.observe(evidence{contradiction: true})
+	st.observe(evidence{})
+
+	want := newAuditState(DefaultVerificationConfig(), 0, 100)
+	want.observe(evidence{})
+	for i := range 3 {
+		if math.Abs(st.logPost[i]-want.logPost[i]) > 1e-9 {
+			t.Fatalf("H%d: log posterior %.6f, want %.6f as if only the last query counted", i, st.logPost[i], want.logPost[i])
+		}
+	}
+	if st.result().ContradictionsFound != 1 {
+		t.Error("the window should not drop the counts")
+	}
+}
+
+func TestForgettingBoundsTheEvidence(t *testing.T) {
+	cfg := DefaultVerificationConfig()
+	cfg.Forgetting = 0.1
+	st := newAuditState(cfg, 0, 100)
+	for range 1000 {
+		st.observe(evidence{})
+	}
+	// the log odds of H0 over H2 settle at one clean query's worth over the
+	// forgetting share, however long the operator has been clean
+	ll := st.lt.jointLogLikelihoods(false, false)
+	limit := (ll[HypHonest] - ll[HypMalicious]) / cfg.Forgetting
+	if got := st.logPost[HypHonest] - st.logPost[HypMalicious]; math.Abs(got-limit) > 1e-6 {
+		t.Errorf("log odds H0:H2 = %.6f, want %.6f", got, limit)
+	}
+}
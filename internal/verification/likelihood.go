@@ -9,9 +9,39 @@ import "math"
 type likelihoodTable struct {
 	// logLikelihoods[contradiction][flagInconsistent][hypothesis]
 	logLikelihoods [2][2][3]float64
+
+	proofFailure binaryTerm // only applied when commitments or signatures are required
+	unanswered   binaryTerm // only applied under TimeoutEvidence
+	segment      binaryTerm // only applied under BaselineSegment
+	bound        binaryTerm // only applied where a delay ceiling is known
+	probe        binaryTerm // only applied to the verifier's own probes
+	falseFlag    binaryTerm // only applied with CheckFalseFlags
+	falseDenial  binaryTerm // only applied with QueryMinimum
 }
 
-func newLikelihoodTable(epsilon, eta float64) *likelihoodTable {
+// binaryTerm holds the log-likelihoods of an observation that either turns up
+// or does not: [1] when it does and [0] when it does not, per hypothesis.
+type binaryTerm [2][3]float64
+
+// newBinaryTerm builds the term for an observation that turns up with
+// probability p[h] under hypothesis h.
+func newBinaryTerm(p [3]float64) binaryTerm {
+	var t binaryTerm
+	for i := range 3 {
+		t[1][i] = math.Log(p[i])
+		t[0][i] = math.Log(1 - p[i])
+	}
+	return t
+}
+
+func (t binaryTerm) logLikelihoods(hit bool) [3]float64 {
+	if hit {
+		return t[1]
+	}
+	return t[0]
+}
+
+func newLikelihoodTable(epsilon, eta, channelLoss, segmentFalseAlarm float64) *likelihoodTable {
 	lt := &likelihoodTable{}
 	for c := range 2 {
 		for f := range 2 {
@@ -38,6 +68,37 @@ func newLikelihoodTable(epsilon, eta float64) *likelihoodTable {
 			}
 		}
 	}
+
+	// Some observations have no innocent cause: answering against a
+	// commitment or signature, a base delay above physics or the operator's
+	// declaration, flagging the batch minimum, or denying that it was minimal.
+	// Congestion produces none of them, so they are only likely under H2. η is
+	// the least-favourable rate for a dishonest prover, which keeps a pass
+	// close to uninformative.
+	onlyMalicious := newBinaryTerm([3]float64{epsilon, epsilon, eta})
+	lt.proofFailure = onlyMalicious
+	lt.bound = onlyMalicious
+	lt.falseFlag = onlyMalicious
+	lt.falseDenial = onlyMalicious
+
+	// A missing answer is either the channel's fault, which hits every
+	// hypothesis alike, or the prover's. An honest prover only misses a
+	// deadline by accident, while an overloaded or stalling one may well.
+	var missProb [3]float64
+	for i, stall := range [3]float64{epsilon, eta, eta} {
+		missProb[i] = channelLoss + (1-channelLoss)*stall
+	}
+	lt.unanswered = newBinaryTerm(missProb)
+
+	// Short base-delay segments make honest and incompetent provers trip the
+	// segment baseline at the false-alarm rate; only deliberate whole-batch
+	// inflation pushes it towards η.
+	lt.segment = newBinaryTerm([3]float64{segmentFalseAlarm, segmentFalseAlarm, max(eta, segmentFalseAlarm)})
+
+	// A probe the router sat on without flagging is either congestion it
+	// failed to report or a deliberate delay; an honest router only does it
+	// by accident.
+	lt.probe = newBinaryTerm([3]float64{epsilon, eta, eta})
 	return lt
 }
 
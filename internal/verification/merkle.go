@@ -0,0 +1,113 @@
+package verification
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+)
+
+// Leaves and interior nodes are hashed with different prefixes so a leaf can
+// never be passed off as an interior node (second-preimage protection).
+const (
+	merkleLeafPrefix byte = 0x00
+	merkleNodePrefix byte = 0x01
+)
+
+type digest [sha256.Size]byte
+
+// Commitment is the Merkle root a prover publishes over one batch of delay
+// records before the verifier starts querying.
+type Commitment struct {
+	BatchID int
+	Root    [sha256.Size]byte
+	Size    int
+}
+
+type proofStep struct {
+	sibling digest
+	left    bool // sibling sits to the left of the running hash
+}
+
+type inclusionProof struct {
+	batchID int
+	steps   []proofStep
+}
+
+// commitmentLeaf hashes the record the prover is committing to: which packet,
+// what delay it observed, whether it was flagged and what the prover will
+// claim about minimality if asked.
+func commitmentLeaf(pktID int, delay float64, flagged, minimal bool) digest {
+	var buf [1 + 8 + 8 + 1 + 1]byte
+	buf[0] = merkleLeafPrefix
+	binary.BigEndian.PutUint64(buf[1:9], uint64(pktID))
+	binary.BigEndian.PutUint64(buf[9:17], math.Float64bits(delay))
+	if flagged {
+		buf[17] = 1
+	}
+	if minimal {
+		buf[18] = 1
+	}
+	return sha256.Sum256(buf[:])
+}
+
+func merkleParent(l, r digest) digest {
+	var buf [1 + 2*sha256.Size]byte
+	buf[0] = merkleNodePrefix
+	copy(buf[1:], l[:])
+	copy(buf[1+sha256.Size:], r[:])
+	return sha256.Sum256(buf[:])
+}
+
+// buildMerkleLevels returns every level of the tree, leaves first. A node
+// without a sibling is promoted unchanged rather than duplicated, which
+// avoids the duplicate-leaf ambiguity of the Bitcoin construction.
+func buildMerkleLevels(leaves []digest) [][]digest {
+	if len(leaves) == 0 {
+		return nil
+	}
+	levels := [][]digest{leaves}
+	for cur := leaves; len(cur) > 1; {
+		next := make([]digest, 0, (len(cur)+1)/2)
+		for i := 0; i < len(cur); i += 2 {
+			if i+1 == len(cur) {
+				next = append(next, cur[i])
+				continue
+			}
+			next = append(next, merkleParent(cur[i], cur[i+1]))
+		}
+		levels = append(levels, next)
+		cur = next
+	}
+	return levels
+}
+
+func merkleRoot(levels [][]digest) digest {
+	if len(levels) == 0 {
+		return digest{}
+	}
+	return levels[len(levels)-1][0]
+}
+
+func merkleProof(levels [][]digest, index int) []proofStep {
+	steps := make([]proofStep, 0, len(levels))
+	for _, level := range levels[:max(0, len(levels)-1)] {
+		sib := index ^ 1
+		if sib < len(level) {
+			steps = append(steps, proofStep{sibling: level[sib], left: sib < index})
+		}
+		index /= 2
+	}
+	return steps
+}
+
+func verifyMerkleProof(leaf digest, steps []proofStep, root digest) bool {
+	h := leaf
+	for _, s := range steps {
+		if s.left {
+			h = merkleParent(s.sibling, h)
+		} else {
+			h = merkleParent(h, s.sibling)
+		}
+	}
+	return h == root
+}
@@ -0,0 +1,134 @@
+package verification
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"satnet-simulator/internal/network"
+)
+
+func TestMerkleProofsAllSizes(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		leaves := make([]digest, n)
+		for i := range leaves {
+			leaves[i] = commitmentLeaf(i, float64(i)*0.01, i%3 == 0, i%2 == 0)
+		}
+		levels := buildMerkleLevels(leaves)
+		root := merkleRoot(levels)
+		for i := range leaves {
+			if !verifyMerkleProof(leaves[i], merkleProof(levels, i), root) {
+				t.Fatalf("n=%d: proof for leaf %d does not verify", n, i)
+			}
+			forged := commitmentLeaf(i, float64(i)*0.01, i%3 == 0, i%2 != 0)
+			if verifyMerkleProof(forged, merkleProof(levels, i), root) {
+				t.Fatalf("n=%d: flipped claim for leaf %d verified", n, i)
+			}
+		}
+	}
+}
+
+func TestCommitmentsCatchEquivocation(t *testing.T) {
+	rand.Seed(7)
+	build := func(equivocation float64) *Prover {
+		p := NewProver(AdversaryConfig{AnsweringStr: AnswerHonest, EquivocationRate: equivocation})
+		id := 0
+		for b := range 50 {
+			for j := range 5 {
+				pkt := network.NewPacket(id, b, "Source", float64(b))
+				pkt.TotalDelay = 0.040
+				if j == 0 {
+					// ties on delay must still get their own leaves
+					pkt.TotalDelay = 0.050
+					pkt.HasIncompetence = true
+				}
+				p.RecordTransmission(pkt)
+				id++
+			}
+		}
+		return p
+	}
+
+	cfg := DefaultVerificationConfig()
+	cfg.RequireCommitments = true
+	cfg.QueriesPerBatch = 5
+	cfg.ConfidenceThreshold = 1 - 1e-12
+
+	honest := build(0)
+	v := NewVerifier(honest, cfg)
+	v.IngestPackets(honest.Packets)
+	if res := v.RunVerification(); res.ProofFailures != 0 {
+		t.Errorf("honest prover failed %d proofs", res.ProofFailures)
+	}
+
+	liar := build(1)
+	v = NewVerifier(liar, cfg)
+	v.IngestPackets(liar.Packets)
+	res := v.RunVerification()
+	if res.ProofFailures == 0 || res.ProofFailures != res.TotalQueries {
+		t.Errorf("always-equivocating prover: %d proof failures over %d queries", res.ProofFailures, res.TotalQueries)
+	}
+	if res.Trustworthy {
+		t.Errorf("always-equivocating prover was trusted: %+v", res)
+	}
+}
+
+// Roots published at each batch boundary cover what had arrived by then;
+// stragglers are left out rather than failing their proofs, and a batch the
+// prover never published fails every proof.
+func TestPublishedRoots(t *testing.T) {
+	cfg := DefaultVerificationConfig()
+	cfg.RequireCommitments = true
+	cfg.QueriesPerBatch = 5
+	cfg.ConfidenceThreshold = 1 // never settles, so every batch is audited
+
+	run := func(unpublished int) (VerificationResult, []byte) {
+		p := NewProver(AdversaryConfig{AnsweringStr: AnswerHonest})
+		roots := NewPublishedRoots(p)
+		id := 0
+		for b := range 20 {
+			for range 5 {
+				pkt := network.NewPacket(id, b, "Source", float64(b))
+				pkt.TotalDelay = 0.040
+				p.RecordTransmission(pkt)
+				id++
+			}
+			if b != unpublished {
+				roots.Publish(b, float64(b+1))
+			}
+			straggler := network.NewPacket(id, b, "Source", float64(b))
+			straggler.TotalDelay = 1.5 // arrives after its batch's root
+			p.RecordTransmission(straggler)
+			id++
+		}
+		var buf bytes.Buffer
+		v := NewVerifier(p, cfg)
+		v.IngestPackets(p.Packets)
+		v.Roots = roots
+		v.Audit = NewAuditLog(&buf)
+		return v.RunVerification(), buf.Bytes()
+	}
+
+	res, log := run(-1)
+	if res.ProofFailures != 0 || res.TotalQueries != 100 {
+		t.Errorf("honest prover publishing every root: %d proof failures over %d queries", res.ProofFailures, res.TotalQueries)
+	}
+	if n := bytes.Count(log, []byte(`"Kind":"COMMITMENT"`)); n != 20 {
+		t.Errorf("log records %d roots, want 20", n)
+	}
+	rep, err := ReplayAuditLog(bytes.NewReader(log), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() {
+		t.Errorf("log failed replay: %v", rep.Problems)
+	}
+
+	res, log = run(7)
+	if res.ProofFailures != 5 {
+		t.Errorf("with batch 7 unpublished: %d proof failures, want its 5 queries", res.ProofFailures)
+	}
+	if rep, err := ReplayAuditLog(bytes.NewReader(log), nil); err != nil || !rep.OK() {
+		t.Errorf("log failed replay: %v %v", err, rep.Problems)
+	}
+}
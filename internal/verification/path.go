@@ -0,0 +1,210 @@
+package verification
+
+import (
+	"math/rand"
+	"slices"
+
+	"satnet-simulator/internal/network"
+)
+
+// A path can cross several operators: a ground segment, a satellite operator
+// and a terrestrial backhaul, say. Each runs its own Prover, which records the
+// packets passing through its segment with SentTime as the entry time and
+// TotalDelay as the time spent inside. Asked about a packet, each operator
+// claims when the packet entered and left its segment. Honest claims tile the
+// measured end-to-end delay exactly, so an operator hiding its own delay has
+// to move one of its timestamps, and the handoff it moved no longer lines up.
+
+// HopShift is which of its own timestamps a lying operator moves to hide a
+// delay.
+type HopShift string
+
+const (
+	ShiftExit   HopShift = ""       // claim it left earlier
+	ShiftEntry  HopShift = "ENTRY"  // claim it arrived later
+	ShiftEither HopShift = "EITHER" // pick one at random for each packet
+)
+
+// HopClaim is an operator's account of a packet's passage through its segment.
+type HopClaim struct {
+	Entry   float64
+	Exit    float64
+	Minimal bool
+}
+
+// ClaimHop answers for the packet with the given ID in batch batchID. A
+// targeted, unflagged packet the strategy would call minimal has its
+// deliberate delay taken out of the claimed interval, on the side HopShift
+// picks.
+func (p *Prover) ClaimHop(batchID, pktID int) (HopClaim, bool) {
+	i := slices.IndexFunc(p.byBatch[batchID], func(rec *network.Packet) bool { return rec.ID == pktID })
+	if i < 0 {
+		return HopClaim{}, false
+	}
+	rec := p.byBatch[batchID][i]
+	p.Queries++
+
+	c := HopClaim{Entry: rec.SentTime, Exit: rec.SentTime + rec.TotalDelay, Minimal: p.decideAnswer(rec).isMinimal}
+	if c.Minimal && rec.IsTargeted && !rec.IsFlagged {
+		shift := p.Config.HopShift
+		if shift == ShiftEither {
+			shift = ShiftExit
+			if rand.Float64() < 0.5 {
+				shift = ShiftEntry
+			}
+		}
+		if shift == ShiftEntry {
+			c.Entry += rec.TargetedDelay
+		} else {
+			c.Exit -= rec.TargetedDelay
+		}
+	}
+	return c, true
+}
+
+type PathVerificationConfig struct {
+	QueriesPerBatch int
+	// Handoffs between operators are countersigned when they happen, so the
+	// verifier holds the true time of each and can tell which side of a
+	// broken handoff moved.
+	Receipts       bool
+	ClockTolerance float64 // seconds two timestamps may differ by and still agree; 0 means 1 ns
+}
+
+func DefaultPathVerificationConfig() PathVerificationConfig {
+	return PathVerificationConfig{QueriesPerBatch: 1}
+}
+
+func (cfg PathVerificationConfig) clockTolerance() float64 {
+	if cfg.ClockTolerance <= 0 {
+		return 1e-9
+	}
+	return cfg.ClockTolerance
+}
+
+// PathPacket is what the verifier measured end to end, plus the countersigned
+// handoff times between consecutive segments when there are receipts.
+type PathPacket struct {
+	ID       int
+	BatchID  int
+	Sent     float64
+	Arrived  float64
+	Receipts []float64 // len(Operators)-1 handoff times, in path order
+}
+
+type PathVerifier struct {
+	Operators []*Prover // one per segment, in path order
+	Packets   []PathPacket
+	Config    PathVerificationConfig
+}
+
+type PathResult struct {
+	Queries        int
+	BrokenHandoffs int       // boundaries, the two ends included, where the claims did not line up
+	Blame          []float64 // per segment; a broken handoff nobody can settle is split between its sides
+	Blamed         int       // the segment with the most blame; -1 when none has any or the top is tied
+	Ambiguous      bool      // two or more segments tie for the most blame
+}
+
+// Run queries every operator about QueriesPerBatch random packets of each
+// batch and blames the segments whose claims break the chain.
+func (v *PathVerifier) Run() PathResult {
+	k := len(v.Operators)
+	res := PathResult{Blame: make([]float64, k), Blamed: -1}
+	if k == 0 {
+		return res
+	}
+
+	batches := make(map[int][]PathPacket)
+	var ids []int
+	for _, p := range v.Packets {
+		if _, ok := batches[p.BatchID]; !ok {
+			ids = append(ids, p.BatchID)
+		}
+		batches[p.BatchID] = append(batches[p.BatchID], p)
+	}
+	slices.Sort(ids)
+
+	for _, bid := range ids {
+		batch := batches[bid]
+		perm := rand.Perm(len(batch))
+		for _, i := range perm[:min(max(1, v.Config.QueriesPerBatch), len(batch))] {
+			v.audit(batch[i], &res)
+		}
+	}
+
+	top := slices.Max(res.Blame)
+	if top > 0 {
+		var leaders int
+		for s, b := range res.Blame {
+			if b == top {
+				leaders++
+				res.Blamed = s
+			}
+		}
+		if leaders > 1 {
+			res.Blamed, res.Ambiguous = -1, true
+		}
+	}
+	return res
+}
+
+// audit checks one packet's claims boundary by boundary. The two ends are
+// anchored by the verifier's own measurements, so a claim that misses them
+// can only be the end segment's; an interior boundary is settled by its
+// receipt when there is one, and split otherwise.
+func (v *PathVerifier) audit(p PathPacket, res *PathResult) {
+	k := len(v.Operators)
+	res.Queries++
+	claims := make([]HopClaim, k)
+	missing := false
+	for s, op := range v.Operators {
+		c, ok := op.ClaimHop(p.BatchID, p.ID)
+		if !ok {
+			// no record of a packet that went through: the segment's fault
+			res.Blame[s]++
+			res.BrokenHandoffs++
+			missing = true
+		}
+		claims[s] = c
+	}
+	if missing {
+		return
+	}
+
+	tol := v.Config.clockTolerance()
+	differs := func(a, b float64) bool { return a-b > tol || b-a > tol }
+
+	if differs(claims[0].Entry, p.Sent) {
+		res.BrokenHandoffs++
+		res.Blame[0]++
+	}
+	if differs(claims[k-1].Exit, p.Arrived) {
+		res.BrokenHandoffs++
+		res.Blame[k-1]++
+	}
+	for h := 1; h < k; h++ {
+		left, right := claims[h-1].Exit, claims[h].Entry
+		if v.Config.Receipts && len(p.Receipts) == k-1 {
+			receipt := p.Receipts[h-1]
+			broken := false
+			if differs(left, receipt) {
+				res.Blame[h-1]++
+				broken = true
+			}
+			if differs(right, receipt) {
+				res.Blame[h]++
+				broken = true
+			}
+			if broken {
+				res.BrokenHandoffs++
+			}
+			continue
+		}
+		if differs(left, right) {
+			res.BrokenHandoffs++
+			res.Blame[h-1] += 0.5
+			res.Blame[h] += 0.5
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package verification
+
+import (
+	"testing"
+
+	"satnet-simulator/internal/network"
+)
+
+func TestPathBlamesTheShiftedHandoff(t *testing.T) {
+	// ground [0, 0.01), satellite [0.01, 0.08) of which 0.05 deliberate,
+	// backhaul [0.08, 0.09)
+	hop := func(p *Prover, entry, delay, targeted float64) {
+		rec := network.Packet{ID: 7, BatchID: 0, SentTime: entry, IsTargeted: targeted > 0}
+		rec.TotalDelay = delay
+		rec.TargetedDelay = targeted
+		p.RecordTransmission(rec)
+	}
+	path := func(shift HopShift, receipts bool) PathResult {
+		ops := []*Prover{
+			NewProver(AdversaryConfig{AnsweringStr: AnswerHonest}),
+			NewProver(AdversaryConfig{AnsweringStr: AnswerParametric, LieRate: 1, HopShift: shift}),
+			NewProver(AdversaryConfig{AnsweringStr: AnswerHonest}),
+		}
+		hop(ops[0], 0, 0.01, 0)
+		hop(ops[1], 0.01, 0.07, 0.05)
+		hop(ops[2], 0.08, 0.01, 0)
+		cfg := DefaultPathVerificationConfig()
+		cfg.Receipts = receipts
+		v := &PathVerifier{
+			Operators: ops,
+			Packets:   []PathPacket{{ID: 7, Sent: 0, Arrived: 0.09, Receipts: []float64{0.01, 0.08}}},
+			Config:    cfg,
+		}
+		return v.Run()
+	}
+
+	for _, shift := range []HopShift{ShiftExit, ShiftEntry} {
+		res := path(shift, false)
+		if !res.Ambiguous || res.Blamed != -1 || res.BrokenHandoffs != 1 {
+			t.Errorf("shift %q without receipts: an interior handoff cannot be settled, got %+v", shift, res)
+		}
+		res = path(shift, true)
+		if res.Ambiguous || res.Blamed != 1 || res.Blame[0] != 0 || res.Blame[2] != 0 {
+			t.Errorf("shift %q with receipts should blame the satellite alone, got %+v", shift, res)
+		}
+	}
+
+	honest := &PathVerifier{
+		Operators: []*Prover{NewProver(AdversaryConfig{AnsweringStr: AnswerHonest})},
+		Packets:   []PathPacket{{ID: 7, Sent: 0, Arrived: 0.01}},
+		Config:    DefaultPathVerificationConfig(),
+	}
+	hop(honest.Operators[0], 0, 0.01, 0)
+	if res := honest.Run(); res.Blamed != -1 || res.Ambiguous || res.BrokenHandoffs != 0 {
+		t.Errorf("an honest chain should go unblamed, got %+v", res)
+	}
+}
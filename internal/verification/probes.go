@@ -0,0 +1,110 @@
+package verification
+
+import (
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+)
+
+// ProbeLog is the verifier's private record of the probe packets it injected
+// into the customer's traffic. It sits in front of the real destination and
+// notes when each probe arrives, so a probe's delay is measured by the
+// verifier itself rather than read from the prover's records.
+//
+// The methods are safe on a nil *ProbeLog, which means no probes were sent.
+type ProbeLog struct {
+	Next network.Destination
+
+	sent    map[int]float64
+	arrived map[int]float64
+}
+
+func NewProbeLog(next network.Destination) *ProbeLog {
+	return &ProbeLog{
+		Next:    next,
+		sent:    make(map[int]float64),
+		arrived: make(map[int]float64),
+	}
+}
+
+// Sent records that packet pktID is a probe sent at t.
+func (l *ProbeLog) Sent(pktID int, t float64) {
+	l.sent[pktID] = t
+}
+
+func (l *ProbeLog) IsProbe(pktID int) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.sent[pktID]
+	return ok
+}
+
+// Count is the number of probes sent.
+func (l *ProbeLog) Count() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.sent)
+}
+
+// Receive implements network.Destination.
+func (l *ProbeLog) Receive(sim *engine.Simulation, pkt network.Packet, pathUsed string) {
+	if l.IsProbe(pkt.ID) {
+		l.arrived[pkt.ID] = sim.Now
+	}
+	if l.Next != nil {
+		l.Next.Receive(sim, pkt, pathUsed)
+	}
+}
+
+// delay is the probe's one-way delay as the verifier measured it.
+func (l *ProbeLog) delay(pktID int) (float64, bool) {
+	if l == nil {
+		return 0, false
+	}
+	sent, ok := l.sent[pktID]
+	if !ok {
+		return 0, false
+	}
+	arrived, ok := l.arrived[pktID]
+	if !ok {
+		return 0, false
+	}
+	return arrived - sent, true
+}
+
+// queryPlan picks the packets of a batch to ask about: every probe, since
+// the verifier sent them to be asked about, and then up to queries customer
+// packets in perm order. Without probes it is batch[perm[:queries]].
+func (l *ProbeLog) queryPlan(batch []*network.Packet, perm []int, queries int) []*network.Packet {
+	var probes, customer []*network.Packet
+	for _, i := range perm {
+		if l.IsProbe(batch[i].ID) {
+			probes = append(probes, batch[i])
+		} else if len(customer) < queries {
+			customer = append(customer, batch[i])
+		}
+	}
+	return append(probes, customer...)
+}
+
+// annotate adds what the verifier's own measurement says about p: whether it
+// is a probe, and whether it arrived above the batch minimum without the
+// router owning up to it with a flag.
+func (l *ProbeLog) annotate(e *evidence, p *network.Packet, minDelay float64) {
+	d, ok := l.delay(p.ID)
+	if !ok {
+		return
+	}
+	e.probe = true
+	e.probeDelay = d
+	e.probeDelayed = probeDelayed(d, minDelay, p.IsFlagged)
+}
+
+// probeClockResolution absorbs the rounding in arrival minus send time, which
+// is not bit-for-bit the delay the router applied.
+const probeClockResolution = 1e-9
+
+func probeDelayed(delay, minDelay float64, flagged bool) bool {
+	return !flagged && delay > minDelay+probeClockResolution
+}
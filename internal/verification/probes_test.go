@@ -0,0 +1,57 @@
+package verification
+
+import (
+	"testing"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+)
+
+func TestProbesAlwaysQueried(t *testing.T) {
+	log := NewProbeLog(nil)
+	batch := make([]*network.Packet, 6)
+	for i := range batch {
+		batch[i] = &network.Packet{ID: i}
+	}
+	log.Sent(4, 0)
+	log.Sent(1, 0)
+
+	perm := []int{5, 4, 3, 2, 1, 0}
+	plan := log.queryPlan(batch, perm, 2)
+	want := []int{4, 1, 5, 3}
+	if len(plan) != len(want) {
+		t.Fatalf("plan has %d packets, want %d", len(plan), len(want))
+	}
+	for i, p := range plan {
+		if p.ID != want[i] {
+			t.Errorf("plan[%d] = packet %d, want %d", i, p.ID, want[i])
+		}
+	}
+
+	var none *ProbeLog
+	if plan := none.queryPlan(batch, perm, 2); len(plan) != 2 || plan[0].ID != 5 || plan[1].ID != 4 {
+		t.Errorf("without probes the plan should be the first 2 of perm")
+	}
+}
+
+func TestProbeDelayMeasuredByVerifier(t *testing.T) {
+	sim := engine.NewSimulation()
+	log := NewProbeLog(nil)
+	const sent, base, inflation = 100.3, 0.031, 0.05
+	log.Sent(7, sent)
+	sim.Schedule(sent+base+inflation, func() {
+		log.Receive(sim, network.Packet{ID: 7}, "")
+	})
+	sim.Run(200)
+
+	var e evidence
+	log.annotate(&e, &network.Packet{ID: 7}, base)
+	if !e.probe || !e.probeDelayed {
+		t.Errorf("a probe %.0f ms over the batch minimum should count as delayed: %+v", inflation*1000, e)
+	}
+	e = evidence{}
+	log.annotate(&e, &network.Packet{ID: 7}, base+inflation)
+	if e.probeDelayed {
+		t.Errorf("a probe at the batch minimum is not delayed, whatever the clock rounding: measured %.17f", e.probeDelay)
+	}
+}
@@ -1,7 +1,9 @@
 package verification
 
 import (
+	"crypto/ed25519"
 	"math/rand"
+	"slices"
 
 	"satnet-simulator/internal/network"
 )
@@ -17,13 +19,37 @@ const (
 	AnswerLiesAboutTargeted AnsweringStrategy = "ANSWER_LIES_ABOUT_TARGETED"
 	AnswerUnreliable        AnsweringStrategy = "ANSWER_UNRELIABLE"
 	AnswerParametric        AnsweringStrategy = "ANSWER_PARAMETRIC"
+	// AnswerSafeLies claims minimal only where the claim cannot be
+	// contradicted: about targeted packets that are still their batch's
+	// fastest. Every other delay is admitted.
+	AnswerSafeLies AnsweringStrategy = "ANSWER_SAFE_LIES"
 )
 
+// Valid reports whether s is one of the strategies above.
+func (s AnsweringStrategy) Valid() bool {
+	switch s {
+	case AnswerHonest, AnswerInconsistent, AnswerRandom, AnswerDelayedHonest,
+		AnswerLiesThatMinimal, AnswerLiesAboutTargeted, AnswerUnreliable,
+		AnswerParametric, AnswerSafeLies:
+		return true
+	}
+	return false
+}
+
 type AdversaryConfig struct {
 	AnsweringStr        AnsweringStrategy
 	FlaggingHonestyRate float64
 	AnswerErrorRate     float64
-	LieRate             float64 // p_lie: P(claim minimal | targeted, unflagged, queried)
+	LieRate             float64  // p_lie: P(claim minimal | targeted, unflagged, queried)
+	EquivocationRate    float64  // P(answer contradicts the committed claim | queried)
+	HopShift            HopShift // which timestamp a lying path operator moves; see ClaimHop
+
+	// Both verifiers send queries over a channel; the batch verifier only
+	// notices a stall when it has a QueryDeadline.
+	ResponsePolicy    ResponsePolicy
+	StallRate         float64 // P(ResponsePolicy applies | queried)
+	StallTargetedOnly bool    // apply ResponsePolicy only to queries about targeted packets
+	StallDelay        float64 // seconds a RespondLate answer is held back
 }
 
 type Prover struct {
@@ -32,6 +58,21 @@ type Prover struct {
 	Queries int
 	// O(1) indexing cache to look up packets based on their BatchID and TotalDelay when the verifier queries them.
 	byTimeDelay map[int]map[float64]*network.Packet
+	byBatch     map[int][]*network.Packet
+	commitments map[int]*batchCommitment
+
+	PublicKey ed25519.PublicKey // nil until EnableSigning
+	Clock     func() float64    // simulated time answers are signed at; nil signs them at 0
+	signer    ed25519.PrivateKey
+	answered  int // answers stamped so far; each gets the next as its seq
+}
+
+// batchCommitment is the prover's private side of a published Commitment: the
+// full tree (so it can produce inclusion proofs) and the claims it bound itself to.
+type batchCommitment struct {
+	levels [][]digest
+	index  map[int]int // packet ID -> leaf index
+	claims []answer
 }
 
 func NewProver(config AdversaryConfig) *Prover {
@@ -39,6 +80,8 @@ func NewProver(config AdversaryConfig) *Prover {
 		Config:      config,
 		Packets:     make([]*network.Packet, 0),
 		byTimeDelay: make(map[int]map[float64]*network.Packet),
+		byBatch:     make(map[int][]*network.Packet),
+		commitments: make(map[int]*batchCommitment),
 	}
 }
 
@@ -52,24 +95,74 @@ func (p *Prover) RecordTransmission(rec network.Packet) {
 		p.byTimeDelay[timeKey] = make(map[float64]*network.Packet)
 	}
 	p.byTimeDelay[timeKey][rec.TotalDelay] = ptr
+	p.byBatch[timeKey] = append(p.byBatch[timeKey], ptr)
 }
 
 func (p *Prover) AnswerQuery(q query) answer {
 	p.Queries++
+	return p.sign(q, p.lookupAnswer(q))
+}
 
-	timeKey := q.batchID
-	var rec *network.Packet
-	if byDelay, ok := p.byTimeDelay[timeKey]; ok {
-		rec = byDelay[q.observedDelay]
-	}
-
+func (p *Prover) lookupAnswer(q query) answer {
+	rec := p.record(q)
 	if rec == nil {
 		return answer{isMinimal: true}
 	}
 
+	if bc, ok := p.commitments[q.batchID]; ok {
+		return p.answerCommitted(bc, rec, q)
+	}
+
 	return p.decideAnswer(rec)
 }
 
+// CommitBatch fixes the prover's claim for every recorded packet in the batch
+// and returns the Merkle root over those records. Committing twice returns the
+// original root; records that arrive after the commitment are not covered.
+func (p *Prover) CommitBatch(batchID int) Commitment {
+	if bc, ok := p.commitments[batchID]; ok {
+		return Commitment{BatchID: batchID, Root: merkleRoot(bc.levels), Size: len(bc.claims)}
+	}
+
+	// delivery order depends on delay, so sort by packet ID to keep the leaf
+	// order (and therefore the RNG draws in decideAnswer) reproducible.
+	recs := slices.Clone(p.byBatch[batchID])
+	slices.SortFunc(recs, func(a, b *network.Packet) int { return a.ID - b.ID })
+
+	bc := &batchCommitment{
+		index:  make(map[int]int, len(recs)),
+		claims: make([]answer, len(recs)),
+	}
+	leaves := make([]digest, len(recs))
+	for i, rec := range recs {
+		bc.claims[i] = p.decideAnswer(rec)
+		bc.index[rec.ID] = i
+		leaves[i] = commitmentLeaf(rec.ID, rec.TotalDelay, rec.IsFlagged, bc.claims[i].isMinimal)
+	}
+	bc.levels = buildMerkleLevels(leaves)
+	p.commitments[batchID] = bc
+
+	return Commitment{BatchID: batchID, Root: merkleRoot(bc.levels), Size: len(recs)}
+}
+
+// answerCommitted looks the leaf up by packet ID rather than delay: packets
+// that share a delay share a byTimeDelay slot but each has its own leaf.
+func (p *Prover) answerCommitted(bc *batchCommitment, rec *network.Packet, q query) answer {
+	idx, ok := bc.index[q.packetID]
+	if !ok {
+		// recorded after the commitment; nothing to prove against
+		return p.decideAnswer(rec)
+	}
+	ans := bc.claims[idx]
+	ans.proof = &inclusionProof{batchID: q.batchID, steps: merkleProof(bc.levels, idx)}
+	if p.Config.EquivocationRate > 0 && rand.Float64() < p.Config.EquivocationRate {
+		// changes its story after seeing the query but can only offer the
+		// proof for what it originally committed to
+		ans.isMinimal = !ans.isMinimal
+	}
+	return ans
+}
+
 func (p *Prover) decideAnswer(rec *network.Packet) answer {
 	isTargeted := rec.IsTargeted
 	hasIncompetence := rec.HasIncompetence
@@ -109,7 +202,24 @@ func (p *Prover) decideAnswer(rec *network.Packet) answer {
 			return answer{isMinimal: false}
 		}
 		return answer{isMinimal: !hasIncompetence}
+
+	case AnswerSafeLies:
+		if isTargeted && !rec.IsFlagged && p.isBatchMinimum(rec) {
+			return answer{isMinimal: true}
+		}
+		return answer{isMinimal: !hasIncompetence && !isTargeted}
 	}
 
 	return answer{isMinimal: true}
 }
+
+// isBatchMinimum reports whether nothing the prover recorded in rec's batch
+// arrived faster than rec.
+func (p *Prover) isBatchMinimum(rec *network.Packet) bool {
+	for _, other := range p.byBatch[rec.BatchID] {
+		if other.TotalDelay < rec.TotalDelay {
+			return false
+		}
+	}
+	return true
+}
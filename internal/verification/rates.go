@@ -0,0 +1,237 @@
+package verification
+
+import "math"
+
+// VerifierModel selects the verifier's world model.
+type VerifierModel string
+
+const (
+	// ModelHypotheses weighs three fixed hypotheses (honest, incompetent,
+	// malicious) whose likelihoods come from Epsilon and ErrorTolerance.
+	ModelHypotheses VerifierModel = ""
+	// ModelRates keeps Beta posteriors over the prover's lie rate and
+	// hidden-delay rate and decides on their credible mass either side of the
+	// tolerated rates.
+	ModelRates VerifierModel = "RATES"
+)
+
+// RateEstimate summarises a Beta posterior over one of the prover's rates.
+// Lower and Upper bound an equal-tailed credible interval at
+// VerificationConfig.CredibleLevel.
+type RateEstimate struct {
+	Mean   float64
+	Lower  float64
+	Upper  float64
+	Hits   int
+	Trials int
+}
+
+// betaPosterior is Beta(a, b) after a Beta(a0, b0) prior and Binomial data.
+type betaPosterior struct{ a, b float64 }
+
+func (cfg VerificationConfig) ratePrior() (float64, float64) {
+	a, b := cfg.RatePriorAlpha, cfg.RatePriorBeta
+	if a <= 0 {
+		a = 1
+	}
+	if b <= 0 {
+		b = 1
+	}
+	return a, b
+}
+
+func (cfg VerificationConfig) credibleLevel() float64 {
+	if cfg.CredibleLevel <= 0 || cfg.CredibleLevel >= 1 {
+		return 0.95
+	}
+	return cfg.CredibleLevel
+}
+
+func newBetaPosterior(cfg VerificationConfig, hits, trials int) betaPosterior {
+	a, b := cfg.ratePrior()
+	return betaPosterior{a: a + float64(hits), b: b + float64(trials-hits)}
+}
+
+func (p betaPosterior) mean() float64 { return p.a / (p.a + p.b) }
+
+// cdf is P(rate <= x).
+func (p betaPosterior) cdf(x float64) float64 {
+	return regIncBeta(p.a, p.b, x)
+}
+
+// quantile inverts cdf by bisection; the CDF is monotone and cheap enough
+// that nothing cleverer is needed.
+func (p betaPosterior) quantile(q float64) float64 {
+	lo, hi := 0.0, 1.0
+	for range 60 {
+		mid := (lo + hi) / 2
+		if p.cdf(mid) < q {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// RateEstimate is the posterior over a rate after hits in trials, under cfg's
+// rate prior and credible level.
+func (cfg VerificationConfig) RateEstimate(hits, trials int) RateEstimate {
+	return newBetaPosterior(cfg, hits, trials).estimate(cfg.credibleLevel(), hits, trials)
+}
+
+func (p betaPosterior) estimate(level float64, hits, trials int) RateEstimate {
+	tail := (1 - level) / 2
+	return RateEstimate{
+		Mean:   p.mean(),
+		Lower:  p.quantile(tail),
+		Upper:  p.quantile(1 - tail),
+		Hits:   hits,
+		Trials: trials,
+	}
+}
+
+// regIncBeta is the regularised incomplete beta function I_x(a, b), evaluated
+// with the continued fraction from Numerical Recipes §6.4.
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log1p(-x))
+	// the continued fraction converges fastest on this side of the mean
+	if x < (a+1)/(a+b+2) {
+		return front * betaContFrac(a, b, x) / a
+	}
+	return 1 - front*betaContFrac(b, a, 1-x)/b
+}
+
+func betaContFrac(a, b, x float64) float64 {
+	const (
+		maxIter = 300
+		eps     = 1e-14
+		tiny    = 1e-300
+	)
+	qab, qap, qam := a+b, a+1, a-1
+	c, d := 1.0, 1-qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+		m2 := 2 * fm
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return h
+}
+
+// lieRate is the posterior over P(claims minimal | packet arrived above its
+// batch minimum).
+func (s *auditState) lieRate() betaPosterior {
+	return newBetaPosterior(s.cfg, s.lies, s.aboveMinQueries)
+}
+
+// hiddenDelayRate is the posterior over P(admits a delay it did not flag |
+// packet was not flagged).
+func (s *auditState) hiddenDelayRate() betaPosterior {
+	return newBetaPosterior(s.cfg, s.hiddenDelaysFound, s.unflaggedQueries)
+}
+
+// lieIncidence is the posterior over P(an answered query catches a lie). It
+// stays identifiable when the prover delays nothing, which the lie rate does
+// not, so it is what a TRUSTED verdict has to rest on.
+func (s *auditState) lieIncidence() betaPosterior {
+	return newBetaPosterior(s.cfg, s.lies, s.queries-s.unanswered)
+}
+
+// rateMasses returns P(lie rate > η), P(lie incidence <= η) and
+// P(corrected flag rate > τ_flag). The corrected rate is f + (1-f)·h for the
+// observed flag fraction f and the hidden-delay rate h, so the SLA tail is a
+// tail of h.
+func (s *auditState) rateMasses() (pLie, pClean, pSLA float64) {
+	eta := s.cfg.ErrorTolerance
+	pLie = 1 - s.lieRate().cdf(eta)
+	pClean = s.lieIncidence().cdf(eta)
+	tau := s.cfg.FlaggingRateThreshold
+	if tau <= 0 || s.totalPackets == 0 {
+		return pLie, pClean, 0
+	}
+	f := float64(s.flaggedCount) / float64(s.totalPackets)
+	if f >= 1 {
+		return pLie, pClean, 1
+	}
+	pSLA = 1 - s.hiddenDelayRate().cdf((tau-f)/(1-f))
+	return pLie, pClean, pSLA
+}
+
+// rateVerdict maps the rate posteriors onto the three-hypothesis result so
+// the runners can classify it unchanged. Whichever test fired owns its
+// hypothesis: H2 is the mass on lying, H1 the mass on an SLA breach, and H0
+// whatever is left.
+func (s *auditState) rateVerdict() (verdict string, post [3]float64) {
+	pLie, pClean, pSLA := s.rateMasses()
+	alpha := s.cfg.ConfidenceThreshold
+	switch {
+	case pLie > alpha:
+		post = [3]float64{(1 - pLie) * (1 - pSLA), (1 - pLie) * pSLA, pLie}
+		return "DISHONEST", post
+	case pSLA > alpha:
+		post = [3]float64{(1 - pSLA) * pClean, pSLA, (1 - pSLA) * (1 - pClean)}
+		return "DISHONEST", post
+	}
+	post = [3]float64{pClean * (1 - pSLA), pClean * pSLA, 1 - pClean}
+	if post[0] > alpha {
+		return "TRUSTED", post
+	}
+	return "INCONCLUSIVE", post
+}
+
+func (s *auditState) rateResult(res VerificationResult) VerificationResult {
+	verdict, post := s.rateVerdict()
+	res.Verdict = verdict
+	res.PosteriorH0, res.PosteriorH1, res.PosteriorH2 = post[0], post[1], post[2]
+	switch verdict {
+	case "DISHONEST":
+		res.Trustworthy = false
+		res.Confidence = max(post[1], post[2])
+	case "TRUSTED":
+		res.Trustworthy = true
+		res.Confidence = post[0]
+	default:
+		res.Trustworthy = post[0] >= max(post[1], post[2])
+		res.Confidence = max(post[0], post[1], post[2])
+	}
+	return res
+}
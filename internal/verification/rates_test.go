@@ -0,0 +1,38 @@
+package verification
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRegIncBeta(t *testing.T) {
+	cases := []struct{ a, b, x, want float64 }{
+		{1, 1, 0.3, 0.3},
+		{2, 3, 0.5, 0.6875},
+		{5, 1, 0.9, math.Pow(0.9, 5)},
+		{1, 40, 0.05, 1 - math.Pow(0.95, 40)},
+	}
+	for _, c := range cases {
+		if got := regIncBeta(c.a, c.b, c.x); math.Abs(got-c.want) > 1e-10 {
+			t.Errorf("I_%.2f(%.0f, %.0f) = %.12f, want %.12f", c.x, c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestRateEstimateCoversTruth(t *testing.T) {
+	cfg := DefaultVerificationConfig()
+	cfg.Model = ModelRates
+	st := newAuditState(cfg, 0, 1000)
+	// 12 lies in 100 queries about delayed packets
+	for i := range 100 {
+		st.observe(evidence{contradiction: i%25 < 3, aboveMin: true})
+	}
+	res := st.result()
+	if res.LieRate.Lower > 0.12 || res.LieRate.Upper < 0.12 {
+		t.Errorf("95%% interval [%.3f, %.3f] misses the 12%% lie rate", res.LieRate.Lower, res.LieRate.Upper)
+	}
+	if res.Verdict != "DISHONEST" || res.PosteriorH2 < cfg.ConfidenceThreshold {
+		t.Errorf("a 12%% liar should be DISHONEST under η=%.2f, got %s (H2=%.3f)",
+			cfg.ErrorTolerance, res.Verdict, res.PosteriorH2)
+	}
+}
@@ -0,0 +1,73 @@
+package verification
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"math"
+	"math/rand"
+)
+
+// answerDomain separates answer signatures from anything else the same key
+// might ever sign.
+const answerDomain = "satnet/answer/v1"
+
+// EnableSigning gives the prover an ed25519 key pair. The seed is drawn from
+// math/rand so keys are reproducible under Runner.SetBaseSeed; this is a
+// simulation, not a key-management system.
+func (p *Prover) EnableSigning() {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := 0; i < len(seed); i += 8 {
+		binary.BigEndian.PutUint64(seed[i:], rand.Uint64())
+	}
+	p.signer = ed25519.NewKeyFromSeed(seed)
+	p.PublicKey = p.signer.Public().(ed25519.PublicKey)
+}
+
+// now is the prover's timestamp for an answer: simulated seconds from Clock,
+// or 0 without one. The batch verifier queries once the simulation is over,
+// so its answers all carry the end time; the seq beside it is what tells
+// them apart.
+func (p *Prover) now() float64 {
+	if p.Clock != nil {
+		return p.Clock()
+	}
+	return 0
+}
+
+func (p *Prover) sign(q query, a answer) answer {
+	a.timestamp = p.now()
+	p.answered++
+	a.seq = p.answered
+	if p.signer != nil {
+		a.signature = ed25519.Sign(p.signer, answerMessage(q, a))
+	}
+	return a
+}
+
+// answerMessage is the byte string a signature covers: the full query, the
+// claim, the prover's timestamp and its answer seq, so no signed answer can
+// stand in for another even at the same time. Floats go in as raw bits so
+// the message survives a JSON round trip through the audit log unchanged.
+func answerMessage(q query, a answer) []byte {
+	buf := make([]byte, 0, len(answerDomain)+8*7+1)
+	buf = append(buf, answerDomain...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(q.packetID))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(q.batchID))
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(q.observedDelay))
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(q.sentTime))
+	if a.isMinimal {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	buf = binary.BigEndian.AppendUint64(buf, math.Float64bits(a.timestamp))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(a.seq))
+	return buf
+}
+
+func verifyAnswer(pub ed25519.PublicKey, q query, a answer) bool {
+	if len(pub) != ed25519.PublicKeySize || len(a.signature) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(pub, answerMessage(q, a), a.signature)
+}
@@ -0,0 +1,293 @@
+package verification
+
+import (
+	"math/rand"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+)
+
+// StreamingConfig controls the online verifier. Latencies are in simulated
+// seconds.
+type StreamingConfig struct {
+	Enabled          bool
+	BatchSize        int     // a batch is audited once this many packets have arrived
+	BatchTimeout     float64 // ...or this long after its first arrival, whichever is first
+	Channel          ChannelConfig
+	QueryDeadline    float64 // seconds after sending before a query counts as unanswered; 0 waits until the run ends
+	SLAWarmupBatches int     // audited batches before the raw flag rate is checked against the SLA
+	HaltSimulation   bool    // stop the simulation as soon as a verdict is reached
+	Continuous       bool    // keep auditing past a TRUSTED verdict; only an accusation ends the audit
+}
+
+func DefaultStreamingConfig() StreamingConfig {
+	return StreamingConfig{
+		Enabled:          true,
+		BatchTimeout:     1.0,
+		Channel:          ChannelConfig{Latency: 0.05},
+		QueryDeadline:    1.0,
+		SLAWarmupBatches: 10,
+	}
+}
+
+// StreamingVerifier audits traffic while the simulation runs. It is the
+// destination of the customer's packets, so it only ever sees what has
+// actually arrived; queries and answers take simulated time, and the verdict
+// can be reached long before the trial ends.
+type StreamingVerifier struct {
+	Prover  *Prover
+	Config  VerificationConfig
+	Stream  StreamingConfig
+	Audit   *AuditLog
+	Probes  *ProbeLog
+	Packets []*network.Packet
+
+	sim     *engine.Simulation
+	st      *auditState
+	pending map[int][]*network.Packet
+	audited map[int]bool
+	batches int
+
+	minimumQueries map[int]bool // packet IDs asked about only for being the batch minimum
+	waiting        []func()     // with no QueryDeadline, settles a query that never came back
+
+	minima   []float64 // per audited batch, in audit order
+	segments int
+
+	headerWritten bool
+	decided       bool
+	decisionTime  float64
+	lastAnswer    float64
+
+	LateAnswers int // answers that arrived after their query's deadline
+}
+
+// NewStreamingVerifier fills in config.ChannelLoss from the channel when it is
+// unset; see withChannel.
+func NewStreamingVerifier(sim *engine.Simulation, prover *Prover, config VerificationConfig, stream StreamingConfig) *StreamingVerifier {
+	if prover.Clock == nil {
+		prover.Clock = func() float64 { return sim.Now }
+	}
+	config = config.withChannel(stream.Channel)
+	return &StreamingVerifier{
+		Prover:  prover,
+		Config:  config,
+		Stream:  stream,
+		sim:     sim,
+		st:      newAuditState(config, 0, 0),
+		pending: make(map[int][]*network.Packet),
+		audited: make(map[int]bool),
+
+		minimumQueries: make(map[int]bool),
+	}
+}
+
+// Receive implements network.Destination.
+func (sv *StreamingVerifier) Receive(sim *engine.Simulation, pkt network.Packet, pathUsed string) {
+	p := new(network.Packet)
+	*p = pkt
+	sv.Packets = append(sv.Packets, p)
+	sv.st.totalPackets++
+	if p.IsFlagged {
+		sv.st.flaggedCount++
+	}
+
+	if sv.audited[p.BatchID] {
+		return // straggler from a batch that already timed out
+	}
+	batch := append(sv.pending[p.BatchID], p)
+	sv.pending[p.BatchID] = batch
+
+	if len(batch) == 1 && sv.Stream.BatchTimeout > 0 {
+		bid := p.BatchID
+		sim.Schedule(sv.Stream.BatchTimeout, func() { sv.auditBatch(bid) })
+	}
+	if sv.Stream.BatchSize > 0 && len(batch) >= sv.Stream.BatchSize {
+		sv.auditBatch(p.BatchID)
+	}
+}
+
+func (sv *StreamingVerifier) auditBatch(bid int) {
+	if sv.audited[bid] {
+		return
+	}
+	batch := sv.pending[bid]
+	sv.audited[bid] = true
+	delete(sv.pending, bid)
+	if sv.decided {
+		return
+	}
+	sv.batches++
+
+	if !sv.headerWritten {
+		sv.Audit.writeHeader(sv.Config, sv.Prover.PublicKey, sv.st.flaggedCount, sv.st.totalPackets, true, sv.Stream.Continuous)
+		sv.headerWritten = true
+	}
+	if sv.batches >= sv.Stream.SLAWarmupBatches && sv.st.flagRateExceeded() {
+		sv.st.slaBreached = true
+		sv.Audit.writeSLACheck(sv.st)
+		sv.decide()
+		return
+	}
+	if len(batch) < 2 {
+		return
+	}
+
+	// Under BaselineSegment the segmentation is redone over every batch
+	// audited so far, and this batch is compared with the segment it ends.
+	minDelay := batchMin(batch)
+	segBaseline := 0.0
+	if sv.Config.Baseline == BaselineSegment {
+		sv.minima = append(sv.minima, minDelay)
+		var baselines []float64
+		baselines, sv.segments = sv.Config.segmentBaselines(sv.minima)
+		segBaseline = baselines[len(baselines)-1]
+	}
+
+	sentTime := fastest(batch).SentTime
+	if ceiling, ok := sv.Config.delayCeiling(sentTime); ok {
+		exceeded := sv.st.observeBound(minDelay, ceiling)
+		sv.Audit.writeBoundCheck(bid, sentTime, minDelay, ceiling, exceeded)
+		if sv.st.concluded(sv.Stream.Continuous) {
+			sv.decide()
+			return
+		}
+	}
+
+	var commitment Commitment
+	if sv.Config.RequireCommitments {
+		commitment = sv.Prover.CommitBatch(bid)
+		sv.Audit.writeCommitment(commitment, sv.sim.Now)
+	}
+
+	queriesThisBatch := max(1, min(sv.Config.QueriesPerBatch, len(batch)))
+	plan := sv.Probes.queryPlan(batch, rand.Perm(len(batch)), queriesThisBatch)
+	if sv.Config.QueryMinimum {
+		var minQuery *network.Packet
+		if plan, minQuery = withMinimum(plan, batch, minDelay); minQuery != nil {
+			sv.minimumQueries[minQuery.ID] = true
+		}
+	}
+	for _, p := range plan {
+		q := query{packetID: p.ID, batchID: p.BatchID, observedDelay: p.TotalDelay, sentTime: p.SentTime}
+		sv.send(p, q, minDelay, segBaseline, commitment)
+	}
+}
+
+// send puts q on the channel and arms its deadline. Whichever of the reply and
+// the deadline lands first settles the query; anything later is ignored. With
+// no deadline, a query still open when the run ends is settled unanswered by
+// Result, as the batch verifier counts a lost one.
+func (sv *StreamingVerifier) send(p *network.Packet, q query, minDelay, segBaseline float64, c Commitment) {
+	settled := false
+	timeout := func() {
+		if !settled {
+			settled = true
+			sv.handleUnanswered(p, q, minDelay, c, evidence{unanswered: true})
+		}
+	}
+	if sv.Stream.QueryDeadline > 0 {
+		sv.sim.Schedule(sv.Stream.QueryDeadline, timeout)
+	} else {
+		sv.waiting = append(sv.waiting, timeout)
+	}
+
+	ch := sv.Stream.Channel
+	if ch.lost() {
+		return
+	}
+	sv.sim.Schedule(ch.delay(), func() {
+		r := sv.Prover.respond(q)
+		if r.silent || ch.lost() {
+			return
+		}
+		sv.sim.Schedule(r.delay+ch.delay(), func() {
+			if settled {
+				sv.LateAnswers++
+				return
+			}
+			settled = true
+			if r.refused {
+				sv.handleUnanswered(p, q, minDelay, c, evidence{refused: true})
+				return
+			}
+			sv.handleAnswer(p, q, r.ans, minDelay, segBaseline, c)
+		})
+	})
+}
+
+func (sv *StreamingVerifier) handleAnswer(p *network.Packet, q query, ans answer, minDelay, segBaseline float64, c Commitment) {
+	if sv.decided {
+		return // answers still in flight when the verdict landed
+	}
+	sv.lastAnswer = sv.sim.Now
+
+	e := assessAnswer(p.TotalDelay, minDelay, p.IsFlagged, ans)
+	e.minimumQuery = sv.minimumQueries[p.ID]
+	if sv.Config.Baseline == BaselineSegment {
+		e.segmentBaseline = segBaseline
+		e.segmentExposed = segmentExposed(p.TotalDelay, minDelay, segBaseline)
+		e.segmentContradiction = segmentContradiction(p.TotalDelay, minDelay, segBaseline, ans)
+	}
+	sv.Probes.annotate(&e, p, minDelay)
+	if sv.Config.RequireCommitments {
+		e.proofFailed = !checkInclusion(p, ans, c)
+	}
+	if sv.Config.RequireSignatures {
+		e.signatureFailed = !verifyAnswer(sv.Prover.PublicKey, q, ans)
+	}
+	sv.st.observe(e)
+	sv.Audit.writeQuery(q, ans, minDelay, p.IsFlagged, c, e, sv.st)
+
+	if sv.st.concluded(sv.Stream.Continuous) {
+		sv.decide()
+	}
+}
+
+func (sv *StreamingVerifier) handleUnanswered(p *network.Packet, q query, minDelay float64, c Commitment, e evidence) {
+	if sv.decided {
+		return
+	}
+	sv.lastAnswer = sv.sim.Now
+
+	sv.Probes.annotate(&e, p, minDelay)
+	sv.st.observe(e)
+	sv.Audit.writeQuery(q, answer{}, minDelay, p.IsFlagged, c, e, sv.st)
+
+	if sv.st.concluded(sv.Stream.Continuous) {
+		sv.decide()
+	}
+}
+
+func (sv *StreamingVerifier) decide() {
+	sv.decided = true
+	sv.decisionTime = sv.sim.Now
+	if sv.Stream.HaltSimulation {
+		sv.sim.Stop()
+	}
+}
+
+// Result reports the verdict so far, first settling as unanswered any query
+// that had no deadline and never came back. DecisionTime is when the verdict
+// was reached, or when the last answer arrived if it never was.
+func (sv *StreamingVerifier) Result() VerificationResult {
+	if len(sv.Packets) < 2 {
+		return insufficientData(sv.Config)
+	}
+	for _, timeout := range sv.waiting {
+		timeout()
+	}
+	sv.waiting = nil
+	res := sv.st.result()
+	res.Segments = sv.segments
+	res.DecisionTime = sv.lastAnswer
+	if sv.decided {
+		res.DecisionTime = sv.decisionTime
+	}
+	if !sv.headerWritten {
+		sv.Audit.writeHeader(sv.Config, sv.Prover.PublicKey, sv.st.flaggedCount, sv.st.totalPackets, true, sv.Stream.Continuous)
+		sv.headerWritten = true
+	}
+	sv.Audit.writeVerdict(res)
+	return res
+}
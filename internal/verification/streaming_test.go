@@ -0,0 +1,79 @@
+package verification
+
+import (
+	"bytes"
+	"testing"
+
+	"satnet-simulator/internal/engine"
+	"satnet-simulator/internal/network"
+)
+
+// streamHonest runs an honest operator past a streaming verifier and returns
+// the verdict with the audit log it wrote.
+func streamHonest(t *testing.T, stream StreamingConfig) (VerificationResult, []byte) {
+	t.Helper()
+	sim := engine.NewSimulation()
+	prover := NewProver(AdversaryConfig{AnsweringStr: AnswerHonest})
+	stream.BatchSize = 4
+	sv := NewStreamingVerifier(sim, prover, DefaultVerificationConfig(), stream)
+	var buf bytes.Buffer
+	sv.Audit = NewAuditLog(&buf)
+
+	id := 0
+	for b := range 60 {
+		for range 4 {
+			pkt := network.NewPacket(id, b, "Source", float64(b))
+			pkt.TotalDelay = 0.040 // base delay alone, as an honest operator's batch
+			prover.RecordTransmission(pkt)
+			sim.Schedule(pkt.SentTime+pkt.TotalDelay, func() { sv.Receive(sim, pkt, "") })
+			id++
+		}
+	}
+	sim.Run(100)
+	res := sv.Result()
+	if err := sv.Audit.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return res, buf.Bytes()
+}
+
+// A continuous monitor keeps querying past a settled TRUSTED verdict; its log
+// must still replay clean.
+func TestContinuousAuditLogReplay(t *testing.T) {
+	stream := DefaultStreamingConfig()
+	once, _ := streamHonest(t, stream)
+	stream.Continuous = true
+	res, log := streamHonest(t, stream)
+	if res.Verdict != "TRUSTED" || res.TotalQueries <= once.TotalQueries {
+		t.Fatalf("continuous run: %s after %d queries; stopping at TRUSTED took %d",
+			res.Verdict, res.TotalQueries, once.TotalQueries)
+	}
+
+	rep, err := ReplayAuditLog(bytes.NewReader(log), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.OK() {
+		t.Fatalf("continuous log failed replay: %v", rep.Problems)
+	}
+	if rep.Replayed.TotalQueries != res.TotalQueries {
+		t.Errorf("replayed %d queries, run made %d", rep.Replayed.TotalQueries, res.TotalQueries)
+	}
+}
+
+// With no deadline a lost query waits until the run ends and is then settled
+// unanswered, as the batch verifier counts it.
+func TestLostQueriesSettledWithoutDeadline(t *testing.T) {
+	for _, deadline := range []float64{1, 0} {
+		stream := DefaultStreamingConfig()
+		stream.Channel = ChannelConfig{LossRate: 1}
+		stream.QueryDeadline = deadline
+		res, log := streamHonest(t, stream)
+		if res.TotalQueries == 0 || res.Unanswered != res.TotalQueries {
+			t.Errorf("deadline %v: %d of %d lost queries unanswered", deadline, res.Unanswered, res.TotalQueries)
+		}
+		if rep, err := ReplayAuditLog(bytes.NewReader(log), nil); err != nil || !rep.OK() {
+			t.Errorf("deadline %v: replay failed: %v %v", deadline, err, rep.Problems)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 )
 
 type query struct {
+	packetID      int
 	batchID       int
 	observedDelay float64
 	sentTime      float64
@@ -16,6 +17,10 @@ func (q query) String() string {
 
 type answer struct {
 	isMinimal bool
+	proof     *inclusionProof // nil unless the batch was committed
+	timestamp float64
+	seq       int    // the prover's count of answers given, this one included
+	signature []byte // nil unless the prover signs
 }
 
 func (a answer) String() string {
@@ -23,4 +28,4 @@ func (a answer) String() string {
 		return "MINIMAL"
 	}
 	return "NOT_MINIMAL"
-}
\ No newline at end of file
+}
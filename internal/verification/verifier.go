@@ -1,9 +1,13 @@
 package verification
 
 import (
+	"errors"
+	"fmt"
+	"maps"
 	"math"
 	"math/rand"
 	"slices"
+	"strings"
 
 	"satnet-simulator/internal/network"
 )
@@ -14,6 +18,38 @@ type VerificationConfig struct {
 	FlaggingRateThreshold float64
 	Epsilon               float64
 	QueriesPerBatch       int
+	RequireCommitments    bool // prover must publish per-batch Merkle roots before any query
+	RequireSignatures     bool // every answer must carry a valid ed25519 signature from the prover
+	TimeoutPolicy         TimeoutPolicy
+	ChannelLoss           *float64 // round-trip loss the verifier blames on the channel rather than the prover; nil takes it from the channel
+
+	Model          VerifierModel
+	RatePriorAlpha float64 // Beta prior on each rate under ModelRates; 0 means 1
+	RatePriorBeta  float64
+	CredibleLevel  float64 // width of the reported rate intervals; 0 means 0.95
+
+	Prior        [3]float64 // weights on H0, H1, H2, each above 0; the zero value is uniform
+	DecisionRule DecisionRule
+	Loss         LossMatrix // zero value is 0-1 loss
+	QueryCost    float64    // in the loss matrix's units
+
+	Baseline           BaselineMode
+	ChangePointPenalty float64 // PELT penalty in s²; 0 means SegmentTolerance²
+	MinSegmentLength   int     // in batches; 0 means 3
+	SegmentTolerance   float64 // seconds above the segment minimum still counted as minimal; 0 means 1 ms
+	SegmentFalseAlarm  float64 // P(segment contradiction | honest); 0 means 0.03
+
+	Path               GroundPath   // zero value: no physical lower bound
+	DeclaredBaseDelay  []DelayRange // operator-declared base-delay ranges
+	InflationTolerance float64      // seconds above the bound still credible; 0 means 1 ms over a declared range and is refused with a Path
+
+	ProbeRate float64 // probes injected per customer packet; every probe is queried
+
+	CheckFalseFlags bool // score flagged packets that were their batch's minimum
+	QueryMinimum    bool // also ask about each audited batch's fastest unflagged packet
+
+	Forgetting float64 // share of the evidence so far discounted at each query; 0 forgets nothing
+	Window     int     // score only the most recent Window queries; 0 keeps them all
 }
 
 func DefaultVerificationConfig() VerificationConfig {
@@ -26,21 +62,144 @@ func DefaultVerificationConfig() VerificationConfig {
 	}
 }
 
+// Validate reports a config the verifier cannot audit with.
+func (cfg VerificationConfig) Validate() error {
+	if cfg.Path.configured() && cfg.InflationTolerance <= 0 {
+		// Honest base delays sit anywhere above the speed-of-light bound, so
+		// the tolerance has to cover their spread; no default can know it.
+		return errors.New("a physical Path needs an explicit InflationTolerance")
+	}
+	if cfg.Prior != ([3]float64{}) {
+		for h, w := range cfg.Prior {
+			// a zero weight would rule H out for good, and the log prior
+			// of -Inf turns NaN under forgetting
+			if !(w > 0) || math.IsInf(w, 1) {
+				return fmt.Errorf("Prior[%d] is %v; set every weight above 0, or none for the uniform prior", h, w)
+			}
+		}
+	}
+	return nil
+}
+
 type VerificationResult struct {
-	Verdict             string
-	Confidence          float64
-	Trustworthy         bool
-	TotalQueries        int
-	ContradictionsFound int
-	PosteriorH0         float64
-	PosteriorH1         float64
-	PosteriorH2         float64
+	Verdict               string
+	Confidence            float64
+	Trustworthy           bool
+	TotalQueries          int
+	ContradictionsFound   int
+	ProofFailures         int
+	SignatureFailures     int
+	Unanswered            int // refused, lost or timed out
+	PosteriorH0           float64
+	PosteriorH1           float64
+	PosteriorH2           float64
+	DecisionTime          float64 // simulated seconds; only set by the streaming verifier
+	LieRate               RateEstimate
+	HiddenDelayRate       RateEstimate
+	ExpectedCost          float64 // posterior expected loss of the verdict plus query costs
+	Segments              int     // base-delay segments found; only under BaselineSegment
+	SegmentContradictions int
+	BoundViolations       int // audited batches whose minimum was above the declared or physical ceiling
+	ProbesQueried         int
+	ProbesDelayed         int // probes that arrived above their batch minimum unflagged
+	FalseFlags            int // flagged packets that were their batch minimum; only with CheckFalseFlags
+	FalseDenials          int // unflagged batch minima claimed not minimal; only with QueryMinimum
+}
+
+// Kinds of evidence against the prover, as listed by EvidenceTypes.
+const (
+	EvidenceSLA           = "SLA"
+	EvidenceContradiction = "CONTRADICTION"
+	EvidenceHiddenDelay   = "HIDDEN_DELAY"
+	EvidenceFalseFlag     = "FALSE_FLAG"
+	EvidenceFalseDenial   = "FALSE_DENIAL"
+	EvidenceProofFailure  = "PROOF_FAILURE"
+	EvidenceUnanswered    = "UNANSWERED"
+	EvidenceSegment       = "SEGMENT"
+	EvidenceBound         = "BOUND"
+	EvidenceProbe         = "PROBE"
+)
+
+// EvidenceTypes lists the kinds of evidence against the prover the audit
+// turned up, in the order above. A verdict usually rests on more than one.
+func (r VerificationResult) EvidenceTypes() []string {
+	var out []string
+	add := func(kind string, seen bool) {
+		if seen {
+			out = append(out, kind)
+		}
+	}
+	add(EvidenceSLA, strings.Contains(r.Verdict, "SLA_BREACHED"))
+	add(EvidenceContradiction, r.ContradictionsFound > 0)
+	add(EvidenceHiddenDelay, r.HiddenDelayRate.Hits > 0)
+	add(EvidenceFalseFlag, r.FalseFlags > 0)
+	add(EvidenceFalseDenial, r.FalseDenials > 0)
+	add(EvidenceProofFailure, r.ProofFailures+r.SignatureFailures > 0)
+	add(EvidenceUnanswered, r.Unanswered > 0)
+	add(EvidenceSegment, r.SegmentContradictions > 0)
+	add(EvidenceBound, r.BoundViolations > 0)
+	add(EvidenceProbe, r.ProbesDelayed > 0)
+	return out
 }
 
 type Verifier struct {
 	Prover  *Prover
 	Packets []*network.Packet
 	Config  VerificationConfig
+	Audit   *AuditLog // optional; nil disables logging
+	Probes  *ProbeLog // optional; nil when no probes were injected
+
+	// Queries go over Channel like the streaming verifier's do, and an answer
+	// whose round trip would take longer than QueryDeadline counts as
+	// unanswered. The zero values are a perfect channel and no deadline.
+	Channel       ChannelConfig
+	QueryDeadline float64
+
+	// Roots the prover published during the run; nil has it commit to every
+	// batch just before the first query, as a caller without a simulation must.
+	Roots *PublishedRoots
+
+	// other customers' batch minima, by batch ID; only set in a coalition
+	// that shares them (see CoalitionConfig.CrossBatches)
+	peerMinima map[int]float64
+}
+
+// PublishedRoots are the Merkle roots a prover published during a run, one
+// per batch at the batch's boundary, as the verifier received them. Each
+// answer about a batch is checked against the root published for it, and
+// packets that reached the verifier after it are left out of the audit: the
+// root does not cover them.
+type PublishedRoots struct {
+	prover *Prover
+	roots  map[int]Commitment
+	times  map[int]float64
+	order  []int // batch IDs in publication order
+}
+
+func NewPublishedRoots(prover *Prover) *PublishedRoots {
+	return &PublishedRoots{
+		prover: prover,
+		roots:  make(map[int]Commitment),
+		times:  make(map[int]float64),
+	}
+}
+
+// Publish has the prover commit to batchID at simulated time at and records
+// the root. A batch is published once; later calls are ignored.
+func (pr *PublishedRoots) Publish(batchID int, at float64) {
+	if _, ok := pr.roots[batchID]; ok {
+		return
+	}
+	pr.roots[batchID] = pr.prover.CommitBatch(batchID)
+	pr.times[batchID] = at
+	pr.order = append(pr.order, batchID)
+}
+
+// covers reports whether p had arrived by the time its batch's root was
+// published. A batch with no root covers everything, and fails every proof.
+func (pr *PublishedRoots) covers(p *network.Packet) bool {
+	at, ok := pr.times[p.BatchID]
+	return !ok || p.SentTime+p.TotalDelay <= at
 }
 
 func NewVerifier(prover *Prover, config VerificationConfig) *Verifier {
@@ -77,142 +236,171 @@ func (v *Verifier) getShuffledBatchIDs(batches map[int][]*network.Packet) []int
 	return times
 }
 
-func (v *Verifier) formatResult(logPost []float64, queries, contradictions int, slaBreached bool) VerificationResult {
-	post := normaliseLogPosterior(logPost)
+func (v *Verifier) RunVerification() VerificationResult {
+	st, segments := v.audit()
+	if st == nil {
+		return insufficientData(v.Config)
+	}
+	return v.finish(st, segments)
+}
 
-	if slaBreached {
-		return VerificationResult{
-			Verdict:             "DISHONEST (SLA_BREACHED)",
-			Confidence:          1.0,
-			Trustworthy:         false,
-			TotalQueries:        queries,
-			ContradictionsFound: contradictions,
-			PosteriorH0:         post[0],
-			PosteriorH1:         post[1],
-			PosteriorH2:         post[2],
-		}
+// audit runs the queries and returns the state they leave, or nil when there
+// is nothing to audit.
+func (v *Verifier) audit() (*auditState, int) {
+	if len(v.Packets) < 2 {
+		return nil, 0
 	}
 
-	verdict := "INCONCLUSIVE"
-	trustworthy := post[0] >= post[1] && post[0] >= post[2]
-	confidence := max(post[0], post[1], post[2])
+	v.Config = v.Config.withChannel(v.Channel)
+	st := newAuditState(v.Config, v.countFlaggedPackets(), len(v.Packets))
+	v.Audit.writeHeader(v.Config, v.Prover.PublicKey, st.flaggedCount, st.totalPackets, false, false)
 
-	alpha := v.Config.ConfidenceThreshold
-	if post[2] > alpha {
-		verdict = "DISHONEST"
-		trustworthy = false
-		confidence = post[2]
-	} else if post[1] > alpha {
-		verdict = "DISHONEST"
-		trustworthy = false
-		confidence = post[1]
-	} else if post[0] > alpha {
-		verdict = "TRUSTED"
-		trustworthy = true
-		confidence = post[0]
+	if st.flagRateExceeded() {
+		st.slaBreached = true
+		return st, 0
 	}
 
-	return VerificationResult{
-		Verdict:             verdict,
-		Confidence:          confidence,
-		Trustworthy:         trustworthy,
-		TotalQueries:        queries,
-		ContradictionsFound: contradictions,
-		PosteriorH0:         post[0],
-		PosteriorH1:         post[1],
-		PosteriorH2:         post[2],
+	plan := v.plan()
+	for _, bid := range plan.order {
+		if st.settled() {
+			break
+		}
+		v.auditBatch(st, plan, bid)
 	}
+	return st, plan.segments
 }
 
-func (v *Verifier) RunVerification() VerificationResult {
-	if len(v.Packets) < 2 {
-		return VerificationResult{
-			Verdict: "INSUFFICIENT_DATA", Trustworthy: true,
-			PosteriorH0: 1.0 / 3, PosteriorH1: 1.0 / 3, PosteriorH2: 1.0 / 3,
+// auditPlan is what the verifier settles before its first query: the batches,
+// the order to audit them in, and what each one is compared against.
+type auditPlan struct {
+	batches      map[int][]*network.Packet
+	order        []int
+	commitments  map[int]Commitment
+	batchMins    map[int]float64
+	segBaselines map[int]float64 // nil unless BaselineSegment
+	segments     int
+}
+
+func (v *Verifier) plan() *auditPlan {
+	plan := &auditPlan{batches: v.groupByBatch()}
+	plan.order = v.getShuffledBatchIDs(plan.batches)
+
+	// The prover commits to every record before it learns which packets will
+	// be queried, so it cannot tailor its story to the sample.
+	if v.Config.RequireCommitments {
+		if v.Roots == nil {
+			v.Roots = NewPublishedRoots(v.Prover)
+			for _, bid := range slices.Sorted(maps.Keys(plan.batches)) {
+				v.Roots.Publish(bid, 0)
+			}
+		}
+		plan.commitments = v.Roots.roots
+		for _, bid := range v.Roots.order {
+			v.Audit.writeCommitment(v.Roots.roots[bid], v.Roots.times[bid])
 		}
 	}
 
-	logPost := []float64{math.Log(1.0 / 3), math.Log(1.0 / 3), math.Log(1.0 / 3)}
-	flaggedCount := v.countFlaggedPackets()
-	totalPackets := len(v.Packets)
+	plan.batchMins, plan.segBaselines, plan.segments = v.baselines(plan.batches)
+	return plan
+}
 
-	if v.Config.FlaggingRateThreshold > 0 && float64(flaggedCount)/float64(totalPackets) > v.Config.FlaggingRateThreshold {
-		return v.formatResult(logPost, 0, 0, true)
+func (v *Verifier) auditBatch(st *auditState, plan *auditPlan, bid int) {
+	batch := plan.batches[bid]
+	if len(batch) < 2 {
+		return
+	}
+	minDelay := plan.batchMins[bid]
+	sentTime := fastest(batch).SentTime
+	if ceiling, ok := v.Config.delayCeiling(sentTime); ok {
+		exceeded := st.observeBound(minDelay, ceiling)
+		v.Audit.writeBoundCheck(bid, sentTime, minDelay, ceiling, exceeded)
 	}
 
-	lt := newLikelihoodTable(v.Config.Epsilon, v.Config.ErrorTolerance)
-	batches := v.groupByBatch()
-	batchIDs := v.getShuffledBatchIDs(batches)
+	queriesThisBatch := max(1, min(v.Config.QueriesPerBatch, len(batch)))
 
-	logAlpha := math.Log(v.Config.ConfidenceThreshold)
-	queries, contradictions, hiddenDelaysFound := 0, 0, 0
-	slaBreached := false
+	indices := make([]int, len(batch))
+	for i := range indices {
+		indices[i] = i
+	}
+	// O(N) shuffle ensures we randomly sample packets within the batch to query.
+	rand.Shuffle(len(indices), func(i, j int) {
+		indices[i], indices[j] = indices[j], indices[i]
+	})
 
-	for _, bid := range batchIDs {
-		if maxLogExceeds(logPost, logAlpha) || slaBreached {
+	queries := v.Probes.queryPlan(batch, indices, queriesThisBatch)
+	var minQuery *network.Packet
+	if v.Config.QueryMinimum {
+		queries, minQuery = withMinimum(queries, batch, minDelay)
+	}
+	for _, p := range queries {
+		if st.settled() {
 			break
 		}
-
-		batch := batches[bid]
-		if len(batch) < 2 {
+		q := query{packetID: p.ID, batchID: p.BatchID, observedDelay: p.TotalDelay, sentTime: p.SentTime}
+		r, answered := v.exchange(q)
+		if !answered || r.refused {
+			e := evidence{unanswered: !answered, refused: r.refused}
+			v.Probes.annotate(&e, p, minDelay)
+			st.observe(e)
+			v.Audit.writeQuery(q, answer{}, minDelay, p.IsFlagged, plan.commitments[p.BatchID], e, st)
 			continue
 		}
-
-		minDelay := batch[0].TotalDelay
-		for _, p := range batch[1:] {
-			if p.TotalDelay < minDelay {
-				minDelay = p.TotalDelay
-			}
+		ans := r.ans
+
+		e := assessAnswer(p.TotalDelay, minDelay, p.IsFlagged, ans)
+		e.minimumQuery = p == minQuery
+		if plan.segBaselines != nil {
+			e.segmentBaseline = plan.segBaselines[bid]
+			e.segmentExposed = segmentExposed(p.TotalDelay, minDelay, e.segmentBaseline)
+			e.segmentContradiction = segmentContradiction(p.TotalDelay, minDelay, e.segmentBaseline, ans)
 		}
-
-		queriesThisBatch := max(1, min(v.Config.QueriesPerBatch, len(batch)))
-
-		indices := make([]int, len(batch))
-		for i := range indices {
-			indices[i] = i
+		v.Probes.annotate(&e, p, minDelay)
+		if v.Config.RequireCommitments {
+			e.proofFailed = !checkInclusion(p, ans, plan.commitments[p.BatchID])
 		}
-		// O(N) shuffle ensures we randomly sample packets within the batch to query.
-		rand.Shuffle(len(indices), func(i, j int) {
-			indices[i], indices[j] = indices[j], indices[i]
-		})
-
-		for qi := range queriesThisBatch {
-			if maxLogExceeds(logPost, logAlpha) {
-				break
-			}
-			p := batch[indices[qi]]
-			q := query{batchID: p.BatchID, observedDelay: p.TotalDelay, sentTime: p.SentTime}
-			ans := v.Prover.AnswerQuery(q)
-			queries++
-
-			contradiction := ans.isMinimal && p.TotalDelay > minDelay
-			flagInconsistent := !ans.isMinimal && !p.IsFlagged
+		if v.Config.RequireSignatures {
+			e.signatureFailed = !verifyAnswer(v.Prover.PublicKey, q, ans)
+		}
+		st.observe(e)
+		v.Audit.writeQuery(q, ans, minDelay, p.IsFlagged, plan.commitments[p.BatchID], e, st)
+	}
+}
 
-			if contradiction {
-				contradictions++
-			}
-			if flagInconsistent {
-				hiddenDelaysFound++
-			}
+// exchange sends q over the channel and waits for the reply. There is no clock
+// to run, so a reply is late when its round trip would overrun the deadline;
+// lost, withheld and late replies all come back unanswered.
+func (v *Verifier) exchange(q query) (r reply, answered bool) {
+	ch := v.Channel
+	if ch.lost() {
+		return reply{}, false
+	}
+	out := ch.delay()
+	r = v.Prover.respond(q)
+	if r.silent || ch.lost() {
+		return reply{}, false
+	}
+	if rtt := out + r.delay + ch.delay(); v.QueryDeadline > 0 && rtt > v.QueryDeadline {
+		return reply{}, false
+	}
+	return r, true
+}
 
-			ll := lt.jointLogLikelihoods(contradiction, flagInconsistent)
-			for i := range 3 {
-				logPost[i] += ll[i]
-			}
+func (v *Verifier) finish(st *auditState, segments int) VerificationResult {
+	res := st.result()
+	res.Segments = segments
+	v.Audit.writeVerdict(res)
+	return res
+}
 
-			// The corrected flag rate accounts for both packets explicitly flagged by the
-			// router and unflagged packets the verifier proved were delayed due to incompetence.
-			if flagInconsistent && v.Config.FlaggingRateThreshold > 0 {
-				correctedFlagRate := float64(hiddenDelaysFound+flaggedCount) / float64(totalPackets)
-				if correctedFlagRate > v.Config.FlaggingRateThreshold {
-					slaBreached = true
-					break
-				}
-			}
-		}
+// checkInclusion recomputes the leaf from what the verifier observed plus the
+// prover's claim, and checks it against the published root. A missing root or
+// missing proof counts as a failure.
+func checkInclusion(p *network.Packet, ans answer, c Commitment) bool {
+	if c.Size == 0 || ans.proof == nil || ans.proof.batchID != p.BatchID {
+		return false
 	}
-
-	return v.formatResult(logPost, queries, contradictions, slaBreached)
+	leaf := commitmentLeaf(p.ID, p.TotalDelay, p.IsFlagged, ans.isMinimal)
+	return verifyMerkleProof(leaf, ans.proof.steps, c.Root)
 }
 
 // log-sum-exp trick to avoid numerical underflow
@@ -232,10 +420,64 @@ func maxLogExceeds(logPost []float64, logAlpha float64) bool {
 	return max(post[0], post[1], post[2]) > math.Exp(logAlpha)
 }
 
+// baselines returns each batch's minimum and, under BaselineSegment, its
+// segment baseline and the number of segments found.
+func (v *Verifier) baselines(batches map[int][]*network.Packet) (batchMins, segBaselines map[int]float64, segments int) {
+	ids := make([]int, 0, len(batches))
+	for bid := range batches {
+		ids = append(ids, bid)
+	}
+	slices.Sort(ids)
+
+	minima := make([]float64, len(ids))
+	batchMins = make(map[int]float64, len(ids))
+	for i, bid := range ids {
+		minima[i] = batchMin(batches[bid])
+		if peer, ok := v.peerMinima[bid]; ok {
+			minima[i] = min(minima[i], peer)
+		}
+		batchMins[bid] = minima[i]
+	}
+	if v.Config.Baseline != BaselineSegment {
+		return batchMins, nil, 0
+	}
+
+	seg, segments := v.Config.segmentBaselines(minima)
+	segBaselines = make(map[int]float64, len(ids))
+	for i, bid := range ids {
+		segBaselines[bid] = seg[i]
+	}
+	return batchMins, segBaselines, segments
+}
+
+func batchMin(batch []*network.Packet) float64 {
+	m := batch[0].TotalDelay
+	for _, p := range batch[1:] {
+		m = min(m, p.TotalDelay)
+	}
+	return m
+}
+
+// fastest is the packet that set the batch minimum; its send time picks the
+// delay ceiling the minimum is held to.
+func fastest(batch []*network.Packet) *network.Packet {
+	f := batch[0]
+	for _, p := range batch[1:] {
+		if p.TotalDelay < f.TotalDelay {
+			f = p
+		}
+	}
+	return f
+}
+
+// groupByBatch leaves out packets a published root does not cover.
 func (v *Verifier) groupByBatch() map[int][]*network.Packet {
 	batches := make(map[int][]*network.Packet)
 	for _, p := range v.Packets {
+		if v.Config.RequireCommitments && v.Roots != nil && !v.Roots.covers(p) {
+			continue
+		}
 		batches[p.BatchID] = append(batches[p.BatchID], p)
 	}
 	return batches
-}
\ No newline at end of file
+}